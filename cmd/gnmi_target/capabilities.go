@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+)
+
+// Capabilities overrides the Capabilities func of gnmi.Target to audit it.
+func (s *server) Capabilities(ctx context.Context, req *pb.CapabilityRequest) (*pb.CapabilityResponse, error) {
+	resp, err := s.Server.Capabilities(ctx, req)
+	s.audit(ctx, audit.OperationCapabilities, nil, err, nil)
+	return resp, err
+}
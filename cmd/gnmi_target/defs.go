@@ -8,24 +8,68 @@ import (
 	"flag"
 	"time"
 
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
 	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	gnoifile "github.com/onosproject/gnxi-simulators/pkg/gnoi/file"
+	"github.com/onosproject/gnxi-simulators/pkg/messages"
+	"github.com/onosproject/gnxi-simulators/pkg/profile/openflow"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
 )
 
 var (
 	bindAddr            = flag.String("bind_address", ":10161", "Bind to address:port or just :port")
-	configFile          = flag.String("config", "", "IETF JSON file for target startup config")
-	readOnlyPath        = `elem:<name:"system" > elem:<name:"openflow" > elem:<name:"controllers" > elem:<name:"controller" key:<key:"name" value:"main" > > elem:<name:"connections" > elem:<name:"connection" key:<key:"aux-id" value:"0" > > elem:<name:"state" > elem:<name:"address" > `
+	configFile          = flag.String("config", "", "IETF JSON file for target startup config (the startup config of -profile by default)")
+	profileName         = flag.String("profile", openflow.Name, "Device profile of the simulated devices, which sets their models, default startup config and state generators")
+	yangDir             = flag.String("yang_dir", "", "Directory of YANG modules compiled at startup and served instead of the models of -profile")
+	targetName          = flag.String("target_name", "", "Name of the target, which requests give as the target of their prefix (any target is accepted when empty)")
+	aaaAuth             = flag.Bool("aaa", false, "Authenticate users against system/aaa/authentication in the config tree instead of -username/-password")
+	certUserMap         = flag.String("cert_user_map", "", "JSON file with rules mapping the CN or SAN of client certificates to usernames")
+	rebootDuration      = flag.Duration("reboot_duration", 5*time.Second, "Time the gNMI service is unavailable while the target reboots")
+	osVersion           = flag.String("os_version", "1.0.0", "Version of the operating system the target boots with")
+	osDir               = flag.String("os_dir", "", "Scratch directory for the images installed with gNOI OS (a temporary directory by default)")
+	osFaults            = flag.String("os_faults", "", "Comma separated failures injected into gNOI OS: corrupt-image, activation-failure")
+	fileDir             = flag.String("file_dir", "", "Sandbox directory the gNOI File paths are rooted at (a temporary directory by default)")
+	fileMaxSize         = flag.Int64("file_max_size", gnoifile.DefaultMaxSize, "Size in bytes of the largest file accepted by gNOI File Put")
+	devicesFile         = flag.String("devices", "", "JSON file listing the devices to simulate, with their name, port, substitutions and interface names in the -config template")
+	deviceCount         = flag.Int("device_count", 0, "Number of devices to simulate, generated from the -config template")
+	devicePrefix        = flag.String("device_prefix", "device", "Name prefix of the devices generated with -device_count")
+	deviceBasePort      = flag.Int("device_base_port", 0, "Base port of the devices generated with -device_count: device i listens on device_base_port+i (0 to share -bind_address)")
+	mirrorState         = flag.Bool("mirror_state", true, "Mirror the config containers to their sibling state containers, with the derivations of -profile")
+	operStatusDelay     = flag.Duration("oper_status_delay", 2*time.Second, "Time the delayed derivations of the state take, e.g. an interface becoming operationally up")
+	alarmInterval       = flag.Duration("alarm_interval", time.Second, "Interval of the evaluation of the alarm rules of -profile (not evaluated when 0)")
+	linkFlapInterval    = flag.Duration("link_flap_interval", 0, "Average time between the random flaps of the links of the interfaces of a device (no random flap when 0)")
+	linkFlapDuration    = flag.Duration("link_flap_duration", 5*time.Second, "Longest time the carrier of a link stays down in a random flap")
+	inventoryFile       = flag.String("inventory", "", "JSON file with the compact profile of the hardware of the devices (the inventory of -profile by default)")
+	sensorInterval      = flag.Duration("sensor_interval", time.Second, "Interval of the updates of the sensors of the inventory (no inventory when 0)")
+	processCPU          = flag.Bool("process_cpu", false, "Derive the CPU statistics of the inventory from the CPU usage of the simulator process (Linux only)")
+	openflowAgent       = flag.Bool("openflow_agent", false, "Run the OpenFlow 1.3 agent of the devices, which connects to the OpenFlow controllers of their config and reports the state of the connections")
+	openflowController  = flag.String("openflow_controller", "", "Address of a local OpenFlow controller stand-in the devices connect to with -openflow_agent, instead of the controllers of their config")
+	maxSubscriptions    = flag.Int("max_subscriptions", 0, "Number of concurrent Subscribe streams of a device, beyond which they fail with RESOURCE_EXHAUSTED (unlimited when 0)")
+	notificationRate    = flag.Float64("notification_rate", 0, "Notifications per second a device sends over its Subscribe streams, beyond which they are throttled (unlimited when 0)")
+	setTimePerKB        = flag.Duration("set_time_per_kb", 0, "Time a device takes to process a Set request per KB of its payload")
+	maxSetSize          = flag.Int("max_set_size", 0, "Size in bytes of the largest Set request of a device, beyond which it fails with RESOURCE_EXHAUSTED (unlimited when 0)")
+	minSampleInterval   = flag.Duration("lowest_sample_interval", 5*time.Second, "Lowest sample interval of the SAMPLE subscriptions, and interval of those not setting one")
+	adminHTTPAddress    = flag.String("admin_http_address", "", "Address of the JSON/HTTP gateway of the admin services of all the devices (no gateway when empty)")
+	metricsAddress      = flag.String("metrics_address", "", "Address of the Prometheus metrics endpoint /metrics of all the devices (no endpoint when empty)")
+	auditLogFile        = flag.String("audit_log", "", "File of the audit log of the RPCs of all the devices, written as JSON lines (no audit log when empty)")
+	auditLogMaxSize     = flag.Int64("audit_log_max_size", 10<<20, "Size in bytes beyond which the audit log is rotated (never rotated when 0)")
+	auditLogBackups     = flag.Int("audit_log_backups", 5, "Number of rotated files of the audit log kept")
+	auditAccounting     = flag.Bool("audit_accounting", false, "Account the RPCs of each device as the COMMAND events of its system/aaa/accounting configuration")
 	randomEventInterval = time.Duration(5) * time.Second
 )
 
 type server struct {
 	*gnmi.Server
-	Model               *gnmi.Model
-	configStruct        ygot.ValidatedGoStruct
-	UpdateChann         chan *pb.Update
-	readOnlyUpdateValue *pb.Update
+	Model         *gnmi.Model
+	configStruct  ygot.ValidatedGoStruct
+	UpdateChann   chan *pb.Update
+	authenticator *aaa.Authenticator
+	certMapper    *aaa.CertMapper
+	messages      *messages.Generator
+	auditors      []audit.Recorder
 }
 
 type streamClient struct {
@@ -34,3 +78,23 @@ type streamClient struct {
 	stream  pb.GNMI_SubscribeServer
 	errChan chan<- error
 }
+
+// identityStream is a Subscribe stream whose context carries the identity of the client.
+type identityStream struct {
+	pb.GNMI_SubscribeServer
+	ctx   context.Context
+	first *pb.SubscribeRequest
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+// Recv receives a request, keeping the first one for the audit of the stream.
+func (s *identityStream) Recv() (*pb.SubscribeRequest, error) {
+	req, err := s.GNMI_SubscribeServer.Recv()
+	if s.first == nil {
+		s.first = req
+	}
+	return req, err
+}
@@ -0,0 +1,451 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/admin"
+	"github.com/onosproject/gnxi-simulators/pkg/devices"
+	"github.com/onosproject/gnxi-simulators/pkg/fault"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	gnoicert "github.com/onosproject/gnxi-simulators/pkg/gnoi/cert"
+	gnoifile "github.com/onosproject/gnxi-simulators/pkg/gnoi/file"
+	gnoios "github.com/onosproject/gnxi-simulators/pkg/gnoi/os"
+	"github.com/onosproject/gnxi-simulators/pkg/gnoi/system"
+	"github.com/onosproject/gnxi-simulators/pkg/inventory"
+	"github.com/onosproject/gnxi-simulators/pkg/link"
+	"github.com/onosproject/gnxi-simulators/pkg/messages"
+	"github.com/onosproject/gnxi-simulators/pkg/metrics"
+	"github.com/onosproject/gnxi-simulators/pkg/ofagent"
+	"github.com/onosproject/gnxi-simulators/pkg/profile"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	fpb "github.com/openconfig/gnoi/file"
+	ospb "github.com/openconfig/gnoi/os"
+	spb "github.com/openconfig/gnoi/system"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// device is a simulated device: a gNMI server with its own config tree and
+// the gNOI services acting on it. Its gNMI requests are served through faults,
+// which injects the faults set by the admin faults service.
+type device struct {
+	*server
+	name   string
+	port   int
+	system *system.Server
+	os     *gnoios.Server
+	file   *gnoifile.Server
+	links  *link.Simulator
+	faults *fault.Injector
+}
+
+// loadDevices returns the devices of the -devices or -device_count flags, or
+// a single device named -target_name listening on -bind_address. The
+// generated devices rename the interfaces of the template config.
+func loadDevices(template []byte) ([]devices.Device, error) {
+	switch {
+	case *devicesFile != "" && *deviceCount != 0:
+		return nil, fmt.Errorf("-devices and -device_count are mutually exclusive")
+	case *devicesFile != "":
+		return devices.Load(*devicesFile)
+	case *deviceCount < 0:
+		return nil, fmt.Errorf("invalid -device_count %d", *deviceCount)
+	case *deviceCount > 0:
+		interfaces, err := devices.InterfaceNames(template)
+		if err != nil {
+			return nil, err
+		}
+		return devices.Generate(*deviceCount, *devicePrefix, *deviceBasePort, interfaces), nil
+	}
+	return []devices.Device{{Name: *targetName}}, nil
+}
+
+// newDevice creates a device of profile p whose config is rendered from the
+// template config and whose state is updated by the generators of p and,
+// unless -mirror_state is false, mirrored from the config. Its alarms are
+// raised by the alarm rules of p, evaluated every -alarm_interval. The links
+// of its interfaces flap at random when -link_flap_interval is set. Its link
+// changes and reboots are published as messages. Its inventory is built from
+// hardware, when not nil, with sensors updated every -sensor_interval and CPU
+// statistics derived from the simulator process with -process_cpu. With
+// -openflow_agent, it connects to the OpenFlow controllers of its config, or
+// to the -openflow_controller stand-in. Its capacity is set by the
+// -max_subscriptions, -notification_rate, -set_time_per_kb, -max_set_size and
+// -lowest_sample_interval flags.
+func newDevice(p profile.Profile, model *gnmi.Model, spec devices.Device, template []byte, hardware *inventory.Spec) (*device, error) {
+	config, err := spec.Render(template)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newServer(model, config)
+	if err != nil {
+		return nil, err
+	}
+	s.SetTarget(spec.Name)
+	links := link.NewSimulator(s.Server)
+	if *mirrorState {
+		if err := s.EnableMirroring(links.Gate(p.Derivations()), *operStatusDelay); err != nil {
+			return nil, fmt.Errorf("error in mirroring the config to the state: %v", err)
+		}
+	}
+	if err := s.SetAlarmRules(p.AlarmRules()); err != nil {
+		return nil, fmt.Errorf("error in setting the alarm rules: %v", err)
+	}
+	err = s.SetCapacity(gnmi.Capacity{
+		MaxSubscriptions:     *maxSubscriptions,
+		NotificationRate:     *notificationRate,
+		SetTimePerKB:         *setTimePerKB,
+		MaxSetSize:           *maxSetSize,
+		LowestSampleInterval: *minSampleInterval,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error in setting the capacity: %v", err)
+	}
+	d := &device{server: s, name: spec.Name, port: spec.Port, links: links, faults: fault.NewInjector(s)}
+	if d.system, err = system.NewServer(s.Server, config, *rebootDuration); err != nil {
+		return nil, fmt.Errorf("error in creating gnoi system service: %v", err)
+	}
+	if d.os, err = newOSServer(s, d.system, spec.Name); err != nil {
+		return nil, fmt.Errorf("error in creating gnoi os service: %v", err)
+	}
+	d.system.AddBootHandler(func() { s.messages.Log(messages.Boot()) })
+	links.AddChangeHandler(func(st link.Status) { s.messages.Log(messages.Link(st.Interface, st.Up)) })
+	if d.file, err = newFileServer(spec.Name); err != nil {
+		return nil, fmt.Errorf("error in creating gnoi file service: %v", err)
+	}
+	for _, generate := range p.StateGenerators() {
+		go generate(context.Background(), d.Server)
+	}
+	if *alarmInterval > 0 {
+		go s.MonitorAlarms(context.Background(), *alarmInterval)
+	}
+	if hardware != nil && *sensorInterval > 0 {
+		sim := inventory.NewSimulator(s.Server, *hardware)
+		if *processCPU {
+			if err := sim.UseProcessCPU(); err != nil {
+				return nil, fmt.Errorf("error in reading the CPU usage of the process: %v", err)
+			}
+		}
+		go sim.Run(context.Background(), *sensorInterval)
+	}
+	if *openflowAgent {
+		agent := ofagent.NewAgent(s.Server)
+		if *openflowController != "" {
+			agent.Redirect(*openflowController)
+		}
+		go agent.Run(context.Background())
+	}
+	if *linkFlapInterval > 0 {
+		go links.Run(context.Background(), *linkFlapInterval, *linkFlapDuration)
+	}
+	return d, nil
+}
+
+// newGRPCServer creates the gRPC server of a port. A port with a single device
+// serves the gNMI and gNOI services of the device. A port shared by several
+// devices routes each gNMI request to the device named by the target of its
+// prefix, and each gNOI request to the device named by its target metadata.
+// Both serve the admin services of the devices, which only admin users may
+// call. Their RPCs are recorded by rpcs, when not nil.
+func newGRPCServer(devs []*device, certServer *gnoicert.Server, rpcs *metrics.RPCs) *grpc.Server {
+	opts := []grpc.ServerOption{grpc.StatsHandler(loginTracker{})}
+	if certServer != nil {
+		opts = append(opts, certServer.ServerCredentials()...)
+	}
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	if rpcs != nil {
+		unary = append(unary, rpcs.UnaryInterceptor)
+		stream = append(stream, rpcs.StreamInterceptor)
+	}
+	unary = append(unary, adminInterceptor(devs))
+	if len(devs) > 1 {
+		r := newRouter(devs)
+		unary = append(unary, r.unaryInterceptor)
+		stream = append(stream, r.streamInterceptor)
+		opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+		g := grpc.NewServer(opts...)
+		pb.RegisterGNMIServer(g, r)
+		r.register(g, &spb.System_ServiceDesc, func(d *device) interface{} { return d.system })
+		r.register(g, &ospb.OS_ServiceDesc, func(d *device) interface{} { return d.os })
+		r.register(g, &fpb.File_ServiceDesc, func(d *device) interface{} { return d.file })
+		registerAdmin(g, devs)
+		if certServer != nil {
+			certServer.Register(g)
+		}
+		reflection.Register(g)
+		return g
+	}
+
+	d := devs[0]
+	unary = append(unary, d.unaryInterceptor)
+	stream = append(stream, d.streamInterceptor)
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	g := grpc.NewServer(opts...)
+	pb.RegisterGNMIServer(g, d.faults)
+	spb.RegisterSystemServer(g, d.system)
+	ospb.RegisterOSServer(g, d.os)
+	fpb.RegisterFileServer(g, d.file)
+	registerAdmin(g, devs)
+	if certServer != nil {
+		certServer.Register(g)
+	}
+	reflection.Register(g)
+	return g
+}
+
+// registerAdmin registers on r the admin schema service of the model devs
+// share and the admin links, alarms, faults and device services of devs.
+func registerAdmin(r grpc.ServiceRegistrar, devs []*device) {
+	admin.RegisterSchemaServer(r, admin.NewSchema(devs[0].Model))
+	admin.RegisterLinksServer(r, newLinks(devs))
+	admin.RegisterAlarmsServer(r, newAlarms(devs))
+	admin.RegisterFaultsServer(r, newFaults(devs))
+	admin.RegisterDeviceServer(r, newDeviceService(devs))
+}
+
+// adminInterceptor provides user auth to the RPCs of the admin services of
+// devs. The user must be allowed to administer the device named by the target
+// of the request, or every device of devs when the request names none of them.
+func adminInterceptor(devs []*device) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, "/gnxi.admin.") {
+			return handler(ctx, req)
+		}
+		targets := devs
+		if r, ok := req.(interface{ GetTarget() string }); ok {
+			for _, d := range devs {
+				if d.name == r.GetTarget() {
+					targets = []*device{d}
+				}
+			}
+		}
+		authorized := ctx
+		for _, d := range targets {
+			c, msg, ok := d.authorizeUser(ctx, aaa.OperationAdmin)
+			if !ok {
+				log.Infof("denied a %s request: %v", info.FullMethod, msg)
+				err := status.Error(codes.PermissionDenied, msg)
+				d.audit(c, info.FullMethod, nil, err, nil)
+				return nil, err
+			}
+			authorized = c
+		}
+		log.Infof("allowed a %s request from %s: %v", info.FullMethod, aaa.UsernameFromContext(authorized), req)
+		resp, err := handler(authorized, req)
+		for _, d := range targets {
+			d.audit(authorized, info.FullMethod, nil, err, nil)
+		}
+		return resp, err
+	}
+}
+
+// newLinks returns the admin links service of devs.
+func newLinks(devs []*device) *admin.Links {
+	simulators := make(map[string]*link.Simulator)
+	for _, d := range devs {
+		simulators[d.name] = d.links
+	}
+	return admin.NewLinks(simulators)
+}
+
+// newAlarms returns the admin alarms service of devs.
+func newAlarms(devs []*device) *admin.Alarms {
+	targets := make(map[string]*gnmi.Server)
+	for _, d := range devs {
+		targets[d.name] = d.Server
+	}
+	return admin.NewAlarms(targets)
+}
+
+// newFaults returns the admin faults service of devs.
+func newFaults(devs []*device) *admin.Faults {
+	injectors := make(map[string]*fault.Injector)
+	for _, d := range devs {
+		injectors[d.name] = d.faults
+	}
+	return admin.NewFaults(injectors)
+}
+
+// newDeviceService returns the admin device service of devs.
+func newDeviceService(devs []*device) *admin.Device {
+	targets := make(map[string]admin.DeviceTarget)
+	for _, d := range devs {
+		targets[d.name] = admin.DeviceTarget{Server: d.Server, System: d.system}
+	}
+	return admin.NewDevice(targets)
+}
+
+// portAddress returns the address of port on the host of -bind_address, or
+// -bind_address itself for port 0.
+func portAddress(port int) (string, error) {
+	if port == 0 {
+		return *bindAddr, nil
+	}
+	host, _, err := net.SplitHostPort(*bindAddr)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
+
+// targetKey is the key of the request metadata naming the device of a gNOI
+// request on a shared port.
+const targetKey = "target"
+
+// router serves the gNMI and gNOI requests of the devices sharing a port.
+type router struct {
+	devices map[string]*device
+	first   *device
+}
+
+func newRouter(devs []*device) *router {
+	r := &router{devices: make(map[string]*device), first: devs[0]}
+	for _, d := range devs {
+		r.devices[d.name] = d
+	}
+	return r
+}
+
+// route returns the device named target.
+func (r *router) route(target string) (*device, error) {
+	if target == "" {
+		return nil, status.Error(codes.InvalidArgument, "the port is shared by several devices, set the target of the prefix to select one")
+	}
+	d, ok := r.devices[target]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown target %q", target)
+	}
+	return d, nil
+}
+
+// routeMetadata returns the device named by the target metadata of ctx. The
+// gNOI requests have no prefix to name their target.
+func (r *router) routeMetadata(ctx context.Context) (*device, error) {
+	var target string
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(targetKey)) > 0 {
+		target = md.Get(targetKey)[0]
+	}
+	return r.route(target)
+}
+
+// unaryInterceptor provides the user auth of the device named by the target
+// metadata of a unary gNOI request.
+func (r *router) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/gnoi.") {
+		return handler(ctx, req)
+	}
+	d, err := r.routeMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.unaryInterceptor(ctx, req, info, handler)
+}
+
+// streamInterceptor provides the user auth of the device named by the target
+// metadata of a streaming gNOI request.
+func (r *router) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !strings.HasPrefix(info.FullMethod, "/gnoi.") {
+		return handler(srv, stream)
+	}
+	d, err := r.routeMetadata(stream.Context())
+	if err != nil {
+		return err
+	}
+	return d.streamInterceptor(srv, stream, info, handler)
+}
+
+// register registers on g the service of desc, whose RPCs are served by the
+// implementation impl returns for the device named by their target metadata.
+func (r *router) register(g *grpc.Server, desc *grpc.ServiceDesc, impl func(d *device) interface{}) {
+	routed := *desc
+	routed.Methods = make([]grpc.MethodDesc, len(desc.Methods))
+	for i, method := range desc.Methods {
+		handler := method.Handler
+		routed.Methods[i] = grpc.MethodDesc{
+			MethodName: method.MethodName,
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				d, err := r.routeMetadata(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return handler(impl(d), ctx, dec, interceptor)
+			},
+		}
+	}
+	routed.Streams = make([]grpc.StreamDesc, len(desc.Streams))
+	for i, s := range desc.Streams {
+		handler := s.Handler
+		routed.Streams[i] = s
+		routed.Streams[i].Handler = func(_ interface{}, stream grpc.ServerStream) error {
+			d, err := r.routeMetadata(stream.Context())
+			if err != nil {
+				return err
+			}
+			return handler(impl(d), stream)
+		}
+	}
+	g.RegisterService(&routed, impl(r.first))
+}
+
+// Capabilities returns the capabilities of the devices, which share the same model.
+func (r *router) Capabilities(ctx context.Context, req *pb.CapabilityRequest) (*pb.CapabilityResponse, error) {
+	return r.first.faults.Capabilities(ctx, req)
+}
+
+// Get routes a Get request.
+func (r *router) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	d, err := r.route(req.GetPrefix().GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	return d.faults.Get(ctx, req)
+}
+
+// Set routes a Set request.
+func (r *router) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse, error) {
+	d, err := r.route(req.GetPrefix().GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	return d.faults.Set(ctx, req)
+}
+
+// Subscribe routes a Subscribe stream according to its first request.
+func (r *router) Subscribe(stream pb.GNMI_SubscribeServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	d, err := r.route(req.GetSubscribe().GetPrefix().GetTarget())
+	if err != nil {
+		return err
+	}
+	return d.faults.Subscribe(&replayStream{GNMI_SubscribeServer: stream, first: req})
+}
+
+// replayStream is a Subscribe stream whose first request was already received.
+type replayStream struct {
+	pb.GNMI_SubscribeServer
+	first *pb.SubscribeRequest
+}
+
+func (s *replayStream) Recv() (*pb.SubscribeRequest, error) {
+	if req := s.first; req != nil {
+		s.first = nil
+		return req, nil
+	}
+	return s.GNMI_SubscribeServer.Recv()
+}
@@ -5,7 +5,8 @@
 package main
 
 import (
-	"github.com/google/gnxi/utils/credentials"
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
@@ -14,12 +15,16 @@ import (
 
 // Get overrides the Get func of gnmi.Target to provide user auth.
 func (s *server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
-	msg, ok := credentials.AuthorizeUser(ctx)
+	ctx, msg, ok := s.authorizeUser(ctx, aaa.OperationRead)
 	if !ok {
 		log.Infof("denied a Get request: %v", msg)
-		return nil, status.Error(codes.PermissionDenied, msg)
+		err := status.Error(codes.PermissionDenied, msg)
+		s.audit(ctx, audit.OperationGet, audit.RequestPaths(req), err, nil)
+		return nil, err
 	}
 
-	log.Infof("allowed a Get request %+v", req)
-	return s.Server.Get(ctx, req)
+	log.Infof("allowed a Get request from %s: %+v", aaa.UsernameFromContext(ctx), req)
+	resp, err := s.Server.Get(ctx, req)
+	s.audit(ctx, audit.OperationGet, audit.RequestPaths(req), err, nil)
+	return resp, err
 }
@@ -10,37 +10,29 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
-	"reflect"
-	"time"
 
 	"github.com/onosproject/onos-lib-go/pkg/logging"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
-
-	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
-	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
-	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
-
-	"github.com/google/gnxi/utils/credentials"
-
-	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/onosproject/gnxi-simulators/pkg/admin"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
+	"github.com/onosproject/gnxi-simulators/pkg/inventory"
+	"github.com/onosproject/gnxi-simulators/pkg/metrics"
+	"github.com/onosproject/gnxi-simulators/pkg/ofagent"
+	"github.com/onosproject/gnxi-simulators/pkg/profile"
 )
 
 var log = logging.GetLogger("main")
 
 func main() {
-	model := gnmi.NewModel(modeldata.ModelData,
-		reflect.TypeOf((*gostruct.Device)(nil)),
-		gostruct.SchemaTree["Device"],
-		gostruct.Unmarshal,
-		gostruct.ΛEnum)
-
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Supported models:\n")
-		for _, m := range model.SupportedModels() {
-			fmt.Fprintf(os.Stderr, "  %s\n", m)
+		fmt.Fprintf(os.Stderr, "Device profiles:\n")
+		for _, name := range profile.Names() {
+			p, _ := profile.Lookup(name)
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", name, p.Description())
 		}
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -49,44 +41,146 @@ func main() {
 
 	flag.Parse()
 
-	opts := credentials.ServerCredentials()
-	g := grpc.NewServer(opts...)
+	p, err := selectProfile()
+	if err != nil {
+		log.Fatalf("Error in selecting the device profile: %v", err)
+	}
+	model, err := p.Model()
+	if err != nil {
+		log.Fatalf("Error in loading the model of the %s profile: %v", p.Name(), err)
+	}
+	log.Infof("Simulating %s devices serving the models %v", p.Name(), model.SupportedModels())
 
-	var configData []byte
+	configData := p.StartupConfig()
 	if *configFile != "" {
-		var err error
 		configData, err = ioutil.ReadFile(*configFile)
 		if err != nil {
 			log.Fatalf("Error in reading config file: %v", err)
 		}
 	}
 
-	s, err := newServer(model, configData)
-
+	hardware, err := loadInventory(p)
 	if err != nil {
-		log.Fatalf("Error in creating gnmi target: %v", err)
+		log.Fatalf("Error in loading the inventory: %v", err)
 	}
-	pb.RegisterGNMIServer(g, s)
-	reflection.Register(g)
 
-	log.Infof("Starting gNMI agent to listen on %s", *bindAddr)
-	listen, err := net.Listen("tcp", *bindAddr)
+	specs, err := loadDevices(configData)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		log.Fatalf("Error in loading devices: %v", err)
+	}
+	certServer, err := newCertServer()
+	if err != nil {
+		log.Fatalf("Error in creating gnoi certificate management service: %v", err)
+	}
+	if certServer == nil {
+		log.Info("gNOI CertificateManagement is not served without TLS")
 	}
 
-	go func() {
+	if *openflowController != "" {
+		listen, err := net.Listen("tcp", *openflowController)
+		if err != nil {
+			log.Fatalf("Failed to listen for the OpenFlow controller stand-in: %v", err)
+		}
+		log.Infof("Starting the OpenFlow controller stand-in on %s", *openflowController)
+		go func() {
+			if err := ofagent.NewController().Serve(listen); err != nil {
+				log.Errorf("OpenFlow controller stand-in failed: %v", err)
+			}
+		}()
+	}
 
-		for {
-			s.SetDateTime()
-			time.Sleep(time.Second * 1)
+	var auditLog *audit.Log
+	if *auditLogFile != "" {
+		if auditLog, err = audit.NewLog(*auditLogFile, *auditLogMaxSize, *auditLogBackups); err != nil {
+			log.Fatalf("Error in opening the audit log: %v", err)
 		}
+		defer auditLog.Close()
+		log.Infof("Writing the audit log to %s", *auditLogFile)
+	}
 
-	}()
+	// Group the devices by port, keeping the order of the devices.
+	var ports []int
+	var devs []*device
+	portDevices := make(map[int][]*device)
+	for _, spec := range specs {
+		d, err := newDevice(p, model, spec, configData, hardware)
+		if err != nil {
+			log.Fatalf("Error in creating device %s: %v", spec.Name, err)
+		}
+		if auditLog != nil {
+			d.auditors = append(d.auditors, auditLog)
+		}
+		devs = append(devs, d)
+		if _, ok := portDevices[d.port]; !ok {
+			ports = append(ports, d.port)
+		}
+		portDevices[d.port] = append(portDevices[d.port], d)
+	}
+
+	errs := make(chan error, len(ports)+2)
+	if *adminHTTPAddress != "" {
+		gateway := admin.NewGateway()
+		registerAdmin(gateway, devs)
+		log.Infof("Starting the admin gateway on %s", *adminHTTPAddress)
+		go func() {
+			errs <- http.ListenAndServe(*adminHTTPAddress, gateway)
+		}()
+	}
+	var rpcs *metrics.RPCs
+	if *metricsAddress != "" {
+		registry := prometheus.NewRegistry()
+		rpcs = metrics.NewRPCs(registry)
+		devices := metrics.NewDevices(registry)
+		for _, d := range devs {
+			devices.Add(d.name, d.Server)
+			if d.authenticator != nil {
+				devices.AddAuthenticator(d.name, d.authenticator)
+			}
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		log.Infof("Starting the metrics endpoint on %s", *metricsAddress)
+		go func() {
+			errs <- http.ListenAndServe(*metricsAddress, mux)
+		}()
+	}
+	for _, port := range ports {
+		addr, err := portAddress(port)
+		if err != nil {
+			log.Fatalf("Invalid bind address: %v", err)
+		}
+		g := newGRPCServer(portDevices[port], certServer, rpcs)
+		log.Infof("Starting gNMI agent to listen on %s for %d devices", addr, len(portDevices[port]))
+		listen, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("Failed to listen: %v", err)
+		}
+		go func() {
+			errs <- g.Serve(listen)
+		}()
+	}
 
-	log.Infof("Starting gNMI agent to serve on %s", *bindAddr)
-	if err := g.Serve(listen); err != nil {
+	log.Infof("Serving %d devices on %d ports", len(specs), len(ports))
+	if err := <-errs; err != nil {
 		log.Fatalf("Failed to serve: %v", err)
 	}
 
 }
+
+// loadInventory returns the inventory of the -inventory flag, or else of
+// profile p.
+func loadInventory(p profile.Profile) (*inventory.Spec, error) {
+	if *inventoryFile != "" {
+		return inventory.Load(*inventoryFile)
+	}
+	return p.Inventory(), nil
+}
+
+// selectProfile returns the profile of the -yang_dir flag, or else of the
+// -profile flag.
+func selectProfile() (profile.Profile, error) {
+	if *yangDir != "" {
+		return profile.NewYANG(*yangDir), nil
+	}
+	return profile.Lookup(*profileName)
+}
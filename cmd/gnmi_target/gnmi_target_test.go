@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	fpb "github.com/openconfig/gnoi/file"
+	spb "github.com/openconfig/gnoi/system"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/admin"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
+	"github.com/onosproject/gnxi-simulators/pkg/devices"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/profile"
+)
+
+const aaaConfig = `{
+  "openconfig-system:system": {
+    "config": {"hostname": "switch1"},
+    "aaa": {
+      "authentication": {
+        "admin-user": {"config": {"admin-password": "admin"}},
+        "users": {"user": [
+          {"username": "alice", "config": {"username": "alice", "password": "secret"}}
+        ]}
+      }
+    }
+  }
+}`
+
+// newTestDevices creates the devices of specs with config, authenticating
+// their users against the AAA config, without the background simulations.
+func newTestDevices(t *testing.T, config string, specs ...devices.Device) []*device {
+	dir, err := ioutil.TempDir("", "gnmi_target")
+	if err != nil {
+		t.Fatalf("error in creating a directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	*osDir, *fileDir = dir, dir
+	*aaaAuth, *openflowAgent = true, false
+	*alarmInterval, *sensorInterval = 0, 0
+
+	p, err := profile.Lookup(*profileName)
+	if err != nil {
+		t.Fatalf("error in looking up the profile: %v", err)
+	}
+	model, err := p.Model()
+	if err != nil {
+		t.Fatalf("error in loading the model: %v", err)
+	}
+	var devs []*device
+	for _, spec := range specs {
+		d, err := newDevice(p, model, spec, []byte(config), nil)
+		if err != nil {
+			t.Fatalf("error in creating device %s: %v", spec.Name, err)
+		}
+		devs = append(devs, d)
+	}
+	return devs
+}
+
+// serve serves the gRPC server of the port of devs over a loopback
+// connection.
+func serve(t *testing.T, devs []*device) *grpc.ClientConn {
+	g := newGRPCServer(devs, nil, nil)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		g.Stop()
+	})
+	return conn
+}
+
+// withUser returns ctx with the credentials of username in its metadata.
+func withUser(ctx context.Context, username, password string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "username", username, "password", password)
+}
+
+func TestAdminAuth(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		specs []devices.Device
+	}{
+		{"single device", []devices.Device{{Name: "switch1"}}},
+		{"shared port", []devices.Device{{Name: "switch1"}, {Name: "switch2"}}},
+	} {
+		conn := serve(t, newTestDevices(t, aaaConfig, tc.specs...))
+		ctx := context.Background()
+		for _, c := range []struct {
+			user     string
+			password string
+			want     codes.Code
+		}{
+			{"", "", codes.PermissionDenied},
+			{"admin", "wrong", codes.PermissionDenied},
+			{"alice", "secret", codes.PermissionDenied},
+			{"admin", "admin", codes.OK},
+		} {
+			err := conn.Invoke(withUser(ctx, c.user, c.password), "/gnxi.admin.Device/Dump",
+				&admin.DumpRequest{Target: "switch1"}, &admin.DumpResponse{})
+			if status.Code(err) != c.want {
+				t.Errorf("%s: dump by %q: got %v, want %v", tc.desc, c.user, err, c.want)
+			}
+			err = conn.Invoke(withUser(ctx, c.user, c.password), "/gnxi.admin.Links/List",
+				&admin.ListLinksRequest{Target: "switch1"}, &admin.ListLinksResponse{})
+			if status.Code(err) != c.want {
+				t.Errorf("%s: links listed by %q: got %v, want %v", tc.desc, c.user, err, c.want)
+			}
+		}
+	}
+}
+
+func TestLoginMessages(t *testing.T) {
+	devs := newTestDevices(t, aaaConfig, devices.Device{Name: "switch1"})
+	client := pb.NewGNMIClient(serve(t, devs))
+	// takeMessage returns the msgid of the last message of the device, and
+	// clears it.
+	takeMessage := func() string {
+		var msgid string
+		_ = devs[0].InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+			messages := config.(*gostruct.Device).Messages
+			if messages != nil && messages.State != nil && messages.State.Message != nil {
+				msgid = *messages.State.Message.Msgid
+				messages.State.Message = nil
+			}
+			return nil
+		})
+		return msgid
+	}
+	req := &pb.GetRequest{Path: []*pb.Path{{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}}}
+	for i, tc := range []struct {
+		user     string
+		password string
+		want     string
+	}{
+		{"alice", "secret", "LOGIN_SUCCESS"},
+		{"alice", "secret", ""},
+		{"alice", "wrong", "LOGIN_FAILED"},
+		{"alice", "wrong", "LOGIN_FAILED"},
+		{"admin", "admin", "LOGIN_SUCCESS"},
+		{"alice", "secret", ""},
+	} {
+		_, _ = client.Get(withUser(context.Background(), tc.user, tc.password), req)
+		if got := takeMessage(); got != tc.want {
+			t.Errorf("request %d by %s: got message %q, want %q", i, tc.user, got, tc.want)
+		}
+	}
+}
+
+func TestRouting(t *testing.T) {
+	hostname := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}
+	for _, tc := range []struct {
+		desc   string
+		specs  []devices.Device
+		target string
+		want   codes.Code
+		// wantTarget is the target named in the prefix of the response.
+		wantTarget string
+	}{
+		{"single device", []devices.Device{{Name: "switch1"}}, "switch1", codes.OK, "switch1"},
+		{"single device without target", []devices.Device{{Name: "switch1"}}, "", codes.OK, "switch1"},
+		{"single device with unknown target", []devices.Device{{Name: "switch1"}}, "switch3", codes.NotFound, ""},
+		{"shared port first device", []devices.Device{{Name: "switch1"}, {Name: "switch2"}}, "switch1", codes.OK, "switch1"},
+		{"shared port second device", []devices.Device{{Name: "switch1"}, {Name: "switch2"}}, "switch2", codes.OK, "switch2"},
+		{"shared port without target", []devices.Device{{Name: "switch1"}, {Name: "switch2"}}, "", codes.InvalidArgument, ""},
+		{"shared port with unknown target", []devices.Device{{Name: "switch1"}, {Name: "switch2"}}, "switch3", codes.NotFound, ""},
+	} {
+		client := pb.NewGNMIClient(serve(t, newTestDevices(t, aaaConfig, tc.specs...)))
+		ctx := withUser(context.Background(), "alice", "secret")
+		prefix := &pb.Path{Target: tc.target}
+
+		resp, err := client.Get(ctx, &pb.GetRequest{Prefix: prefix, Path: []*pb.Path{hostname}})
+		if status.Code(err) != tc.want {
+			t.Errorf("%s: Get got %v, want %v", tc.desc, err, tc.want)
+		} else if err == nil {
+			if got := resp.GetNotification()[0].GetPrefix().GetTarget(); got != tc.wantTarget {
+				t.Errorf("%s: Get answered by %q, want %q", tc.desc, got, tc.wantTarget)
+			}
+		}
+
+		setResp, err := client.Set(ctx, &pb.SetRequest{Prefix: prefix, Update: []*pb.Update{{
+			Path: hostname,
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch9"}},
+		}}})
+		if status.Code(err) != tc.want {
+			t.Errorf("%s: Set got %v, want %v", tc.desc, err, tc.want)
+		} else if err == nil {
+			if got := setResp.GetPrefix().GetTarget(); got != tc.wantTarget {
+				t.Errorf("%s: Set answered by %q, want %q", tc.desc, got, tc.wantTarget)
+			}
+		}
+	}
+}
+
+func TestGNOIRouting(t *testing.T) {
+	conn := serve(t, newTestDevices(t, aaaConfig, devices.Device{Name: "switch1"}, devices.Device{Name: "switch2"}))
+	if err := ioutil.WriteFile(filepath.Join(*fileDir, "switch2", "hello"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("error in writing a file: %v", err)
+	}
+	for _, tc := range []struct {
+		desc   string
+		target string
+		want   codes.Code
+		// wantStat is the result of the Stat of the file of switch2.
+		wantStat codes.Code
+	}{
+		{"first device", "switch1", codes.OK, codes.NotFound},
+		{"second device", "switch2", codes.OK, codes.OK},
+		{"without target", "", codes.InvalidArgument, codes.InvalidArgument},
+		{"unknown target", "switch3", codes.NotFound, codes.NotFound},
+	} {
+		ctx := withUser(context.Background(), "alice", "secret")
+		if tc.target != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "target", tc.target)
+		}
+		if _, err := spb.NewSystemClient(conn).Time(ctx, &spb.TimeRequest{}); status.Code(err) != tc.want {
+			t.Errorf("%s: Time got %v, want %v", tc.desc, err, tc.want)
+		}
+		if _, err := fpb.NewFileClient(conn).Stat(ctx, &fpb.StatRequest{Path: "/hello"}); status.Code(err) != tc.wantStat {
+			t.Errorf("%s: Stat got %v, want %v", tc.desc, err, tc.wantStat)
+		}
+	}
+
+	ctx := metadata.AppendToOutgoingContext(withUser(context.Background(), "alice", "wrong"), "target", "switch2")
+	if _, err := spb.NewSystemClient(conn).Time(ctx, &spb.TimeRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Time with a wrong password got %v, want %v", err, codes.PermissionDenied)
+	}
+}
+
+// recorder keeps the audit entries it records.
+type recorder struct {
+	entries []audit.Entry
+}
+
+func (r *recorder) Record(e audit.Entry) {
+	r.entries = append(r.entries, e)
+}
+
+func TestAuditedSet(t *testing.T) {
+	devs := newTestDevices(t, aaaConfig, devices.Device{Name: "switch1"}, devices.Device{Name: "switch2"})
+	r := &recorder{}
+	for _, d := range devs {
+		d.auditors = append(d.auditors, r)
+	}
+	client := pb.NewGNMIClient(serve(t, devs))
+
+	req := &pb.SetRequest{Prefix: &pb.Path{Target: "switch2"}, Update: []*pb.Update{{
+		Path: &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}},
+		Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch9"}},
+	}}}
+	if _, err := client.Set(withUser(context.Background(), "alice", "secret"), req); err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	if len(r.entries) != 1 {
+		t.Fatalf("got audit entries %+v, want one", r.entries)
+	}
+	e := r.entries[0]
+	if e.Operation != audit.OperationSet || e.Target != "switch2" || e.User != "alice" || e.Status != codes.OK.String() || e.Peer == "" {
+		t.Errorf("got audit entry %+v, want the Set of alice on switch2", e)
+	}
+	if want := []string{"/system/config/hostname"}; !reflect.DeepEqual(e.Paths, want) {
+		t.Errorf("got audited paths %v, want %v", e.Paths, want)
+	}
+	if want := []gnmi.Change{{Path: "/system/config/hostname", Old: "switch1", New: "switch9"}}; !reflect.DeepEqual(e.Diff, want) {
+		t.Errorf("got audited diff %+v, want %+v", e.Diff, want)
+	}
+}
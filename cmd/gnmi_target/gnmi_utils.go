@@ -6,13 +6,24 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/google/gnxi/utils/credentials"
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
 	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/messages"
 	"github.com/onosproject/gnxi-simulators/pkg/utils"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
 )
 
@@ -23,16 +34,137 @@ func newServer(model *gnmi.Model, config []byte) (*server, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.SetUserFunc(aaa.UsernameFromContext)
 
 	newconfig, _ := model.NewConfigStruct(config)
 	channelUpdate := make(chan *pb.Update)
 	server := server{Server: s, Model: model,
 		configStruct: newconfig,
 		UpdateChann:  channelUpdate}
+	// Only the openconfig trees have messages to publish into.
+	if _, ok := newconfig.(*gostruct.Device); ok {
+		server.messages = messages.NewGenerator(s)
+	}
+	if *aaaAuth {
+		server.authenticator = aaa.NewAuthenticator(s)
+	}
+	if *auditAccounting {
+		server.auditors = append(server.auditors, audit.NewAccounting(s, server.messages))
+	}
+	if *certUserMap != "" {
+		if server.certMapper, err = aaa.LoadCertMapper(*certUserMap); err != nil {
+			return nil, err
+		}
+	}
 
 	return &server, nil
 }
 
+// authorizeUser authorizes the user of a request. The user is the one the
+// client certificate maps to when -cert_user_map is set, otherwise the one in
+// the request metadata; it is checked against the AAA configuration of the
+// target or against the -username/-password flags. The returned context
+// carries the identity of the user. Every rejected login is published as a
+// message of the target, while the login of a known user is only published
+// for the first request of the user on a connection.
+func (s *server) authorizeUser(ctx context.Context, op aaa.Operation) (context.Context, string, bool) {
+	ctx, msg, allowed := s.authorize(ctx, op)
+	user := aaa.UsernameFromContext(ctx)
+	if !allowed || user != "" && firstLogin(ctx, s.Target(), user) {
+		s.messages.Log(messages.Login(user, msg, allowed))
+	}
+	return ctx, msg, allowed
+}
+
+// connLogins are the users logged in to each target over a connection.
+type connLogins struct {
+	mu     sync.Mutex
+	logins map[string]bool
+}
+
+type connLoginsKey struct{}
+
+// loginTracker is the stats.Handler of the gRPC servers that gives each
+// connection its connLogins.
+type loginTracker struct{}
+
+func (loginTracker) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, connLoginsKey{}, &connLogins{logins: make(map[string]bool)})
+}
+
+func (loginTracker) HandleConn(context.Context, stats.ConnStats) {}
+
+func (loginTracker) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (loginTracker) HandleRPC(context.Context, stats.RPCStats) {}
+
+// firstLogin reports whether the request of ctx is the first of user to
+// target on its connection, and false for requests not served by a gRPC
+// server with a loginTracker.
+func firstLogin(ctx context.Context, target, user string) bool {
+	c, ok := ctx.Value(connLoginsKey{}).(*connLogins)
+	if !ok {
+		return false
+	}
+	key := target + "/" + user
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.logins[key] {
+		return false
+	}
+	c.logins[key] = true
+	return true
+}
+
+// authorize authorizes the user of a request as described in authorizeUser.
+func (s *server) authorize(ctx context.Context, op aaa.Operation) (context.Context, string, bool) {
+	if s.certMapper != nil {
+		if cert, ok := aaa.PeerCertificate(ctx); ok {
+			if username, ok := s.certMapper.Username(cert); ok {
+				msg, allowed := fmt.Sprintf("authorized certificate %q as \"%s\"", cert.Subject, username), true
+				if s.authenticator != nil {
+					msg, allowed = s.authenticator.AuthorizeIdentity(username, op)
+				}
+				id := &aaa.Identity{Username: username, Source: aaa.SourceCertificate, Subject: cert.Subject.String()}
+				return aaa.NewContext(ctx, id), msg, allowed
+			}
+		}
+	}
+
+	var msg string
+	var allowed bool
+	if s.authenticator != nil {
+		msg, allowed = s.authenticator.AuthorizeUser(ctx, op)
+	} else {
+		msg, allowed = credentials.AuthorizeUser(ctx)
+	}
+	if headers, ok := metadata.FromIncomingContext(ctx); ok && len(headers["username"]) > 0 {
+		ctx = aaa.NewContext(ctx, &aaa.Identity{Username: headers["username"][0], Source: aaa.SourcePassword})
+	}
+	return ctx, msg, allowed
+}
+
+// audit records RPC operation of the user of ctx on paths, which ended with
+// err, and the changes of the config it made, in the audit log and the AAA
+// accounting of the target when enabled.
+func (s *server) audit(ctx context.Context, operation string, paths []string, err error, diff []gnmi.Change) {
+	if len(s.auditors) == 0 {
+		return
+	}
+	e := audit.NewEntry(operation, s.Target(), err)
+	e.User = aaa.UsernameFromContext(ctx)
+	if p, ok := peer.FromContext(ctx); ok {
+		e.Peer = p.Addr.String()
+	}
+	e.Paths = paths
+	e.Diff = diff
+	for _, r := range s.auditors {
+		r.Record(e)
+	}
+}
+
 // sendResponse sends an SubscribeResponse to a gNMI client.
 func (s *server) sendResponse(response *pb.SubscribeResponse, stream pb.GNMI_SubscribeServer) {
 	log.Info("Sending SubscribeResponse out to gNMI client: ", response)
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/gnxi/utils/credentials"
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	gnoicert "github.com/onosproject/gnxi-simulators/pkg/gnoi/cert"
+	gnoifile "github.com/onosproject/gnxi-simulators/pkg/gnoi/file"
+	gnoios "github.com/onosproject/gnxi-simulators/pkg/gnoi/os"
+	"github.com/onosproject/gnxi-simulators/pkg/gnoi/system"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gnoiReadMethods are the gNOI RPCs that do not change the target.
+var gnoiReadMethods = map[string]bool{
+	"/gnoi.system.System/Time":         true,
+	"/gnoi.system.System/Ping":         true,
+	"/gnoi.system.System/Traceroute":   true,
+	"/gnoi.system.System/RebootStatus": true,
+	"/gnoi.os.OS/Verify":               true,
+	"/gnoi.file.File/Get":              true,
+	"/gnoi.file.File/Stat":             true,
+
+	"/gnoi.certificate.CertificateManagement/GetCertificates": true,
+	"/gnoi.certificate.CertificateManagement/CanGenerateCSR":  true,
+}
+
+// newOSServer creates the gNOI OS service of a device from the -os_* flags.
+// Named devices get their own subdirectory of the scratch directory.
+func newOSServer(s *server, systemServer *system.Server, name string) (*gnoios.Server, error) {
+	faults, err := gnoios.ParseFaults(*osFaults)
+	if err != nil {
+		return nil, err
+	}
+	dir := *osDir
+	if dir == "" {
+		if dir, err = ioutil.TempDir("", "gnmi_target-os-"); err != nil {
+			return nil, err
+		}
+	}
+	osServer, err := gnoios.NewServer(s.Server, systemServer, filepath.Join(dir, name), *osVersion)
+	if err != nil {
+		return nil, err
+	}
+	osServer.SetFaults(faults)
+	return osServer, nil
+}
+
+// newFileServer creates the gNOI File service of a device from the -file_*
+// flags. Named devices get their own subdirectory of the sandbox.
+func newFileServer(name string) (*gnoifile.Server, error) {
+	dir := *fileDir
+	if dir == "" {
+		var err error
+		if dir, err = ioutil.TempDir("", "gnmi_target-file-"); err != nil {
+			return nil, err
+		}
+	}
+	return gnoifile.NewServer(filepath.Join(dir, name), *fileMaxSize)
+}
+
+// newCertServer creates the gNOI CertificateManagement service starting with
+// the -cert, -key and -ca credentials of the target. It returns nil with -notls.
+func newCertServer() (*gnoicert.Server, error) {
+	if credentialsFlag("notls") == "true" {
+		return nil, nil
+	}
+	certificates, _ := credentials.LoadCertificates()
+	caBundle, err := ioutil.ReadFile(credentialsFlag("ca"))
+	if err != nil {
+		return nil, err
+	}
+	clientAuth := tls.RequireAndVerifyClientCert
+	if credentialsFlag("insecure") == "true" {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	return gnoicert.NewServer(certificates[0], caBundle, clientAuth)
+}
+
+// credentialsFlag returns the value of a flag of the gnxi credentials package.
+func credentialsFlag(name string) string {
+	return flag.Lookup(name).Value.String()
+}
+
+// gnoiOperation returns the kind of access requested by a gNOI RPC.
+func gnoiOperation(fullMethod string) aaa.Operation {
+	if gnoiReadMethods[fullMethod] {
+		return aaa.OperationRead
+	}
+	return aaa.OperationWrite
+}
+
+// gnoiStream is a gNOI server stream whose context carries the identity of the client.
+type gnoiStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *gnoiStream) Context() context.Context {
+	return s.ctx
+}
+
+// unaryInterceptor provides user auth to the unary gNOI RPCs. The gNMI RPCs
+// are authorized by the server methods themselves.
+func (s *server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !strings.HasPrefix(info.FullMethod, "/gnoi.") {
+		return handler(ctx, req)
+	}
+	ctx, msg, ok := s.authorizeUser(ctx, gnoiOperation(info.FullMethod))
+	if !ok {
+		log.Infof("denied a %s request: %v", info.FullMethod, msg)
+		err := status.Error(codes.PermissionDenied, msg)
+		s.audit(ctx, info.FullMethod, nil, err, nil)
+		return nil, err
+	}
+	log.Infof("allowed a %s request from %s: %v", info.FullMethod, aaa.UsernameFromContext(ctx), req)
+	resp, err := handler(ctx, req)
+	s.audit(ctx, info.FullMethod, nil, err, nil)
+	return resp, err
+}
+
+// streamInterceptor provides user auth to the streaming gNOI RPCs.
+func (s *server) streamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !strings.HasPrefix(info.FullMethod, "/gnoi.") {
+		return handler(srv, stream)
+	}
+	ctx, msg, ok := s.authorizeUser(stream.Context(), gnoiOperation(info.FullMethod))
+	if !ok {
+		log.Infof("denied a %s request: %v", info.FullMethod, msg)
+		err := status.Error(codes.PermissionDenied, msg)
+		s.audit(ctx, info.FullMethod, nil, err, nil)
+		return err
+	}
+	log.Infof("allowed a %s request from %s", info.FullMethod, aaa.UsernameFromContext(ctx))
+	err := handler(srv, &gnoiStream{ServerStream: stream, ctx: ctx})
+	s.audit(ctx, info.FullMethod, nil, err, nil)
+	return err
+}
@@ -5,21 +5,30 @@
 package main
 
 import (
-	"github.com/google/gnxi/utils/credentials"
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
+	"github.com/onosproject/gnxi-simulators/pkg/messages"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// Set overrides the Set func of gnmi.Target to provide user auth.
+// Set overrides the Set func of gnmi.Target to provide user auth. A committed
+// Set is published as a message of the target.
 func (s *server) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse, error) {
-	msg, ok := credentials.AuthorizeUser(ctx)
+	ctx, msg, ok := s.authorizeUser(ctx, aaa.OperationWrite)
 	if !ok {
 		log.Infof("denied a Set request: %v", msg)
-		return nil, status.Error(codes.PermissionDenied, msg)
+		err := status.Error(codes.PermissionDenied, msg)
+		s.audit(ctx, audit.OperationSet, audit.RequestPaths(req), err, nil)
+		return nil, err
 	}
-	log.Infof("allowed a Set request: %v", req)
-	setResponse, err := s.Server.Set(ctx, req)
+	log.Infof("allowed a Set request from %s: %v", aaa.UsernameFromContext(ctx), req)
+	setResponse, diff, err := s.SetChanges(ctx, req)
+	if err == nil {
+		s.messages.Log(messages.Commit(aaa.UsernameFromContext(ctx)))
+	}
+	s.audit(ctx, audit.OperationSet, audit.RequestPaths(req), err, diff)
 	return setResponse, err
 }
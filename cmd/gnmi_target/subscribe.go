@@ -5,7 +5,8 @@
 package main
 
 import (
-	"github.com/google/gnxi/utils/credentials"
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/audit"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -14,11 +15,17 @@ import (
 
 // Subscribe overrides the Subscribe function of gnmi.Target to provide user auth.
 func (s *server) Subscribe(stream pb.GNMI_SubscribeServer) error {
-	msg, ok := credentials.AuthorizeUser(stream.Context())
+	ctx, msg, ok := s.authorizeUser(stream.Context(), aaa.OperationRead)
 	if !ok {
 		log.Infof("denied a Subscribe request: %v", msg)
-		return status.Error(codes.PermissionDenied, msg)
+		err := status.Error(codes.PermissionDenied, msg)
+		s.audit(ctx, audit.OperationSubscribe, nil, err, nil)
+		return err
 	}
 
-	return s.Server.Subscribe(stream)
+	log.Infof("allowed a Subscribe request from %s", aaa.UsernameFromContext(ctx))
+	identity := &identityStream{GNMI_SubscribeServer: stream, ctx: ctx}
+	err := s.Server.Subscribe(identity)
+	s.audit(ctx, audit.OperationSubscribe, audit.RequestPaths(identity.first), err, nil)
+	return err
 }
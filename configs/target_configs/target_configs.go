@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package targetconfigs embeds the startup configs of the simulated devices.
+package targetconfigs
+
+import (
+	// Embeds the configs.
+	_ "embed"
+)
+
+// TypicalOfswConfig is the IETF JSON startup config of a typical OpenFlow
+// switch, with the placeholders substituted by pkg/devices.
+//
+//go:embed typical_ofsw_config.json
+var TypicalOfswConfig []byte
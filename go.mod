@@ -4,13 +4,17 @@ go 1.16
 
 require (
 	github.com/eapache/channels v1.1.0
-	github.com/golang/protobuf v1.5.0
+	github.com/golang/protobuf v1.5.2
 	github.com/google/gnxi v0.0.0-20190228205329-8521faedac37
 	github.com/onosproject/onos-lib-go v0.8.0
 	github.com/openconfig/gnmi v0.0.0-20200617225440-d2b4e6a45802
+	github.com/openconfig/gnoi v0.1.0
 	github.com/openconfig/goyang v0.0.0-20200803193518-78bac27bdff1
 	github.com/openconfig/ygot v0.8.3
+	github.com/prometheus/client_golang v1.12.2
+	golang.org/x/crypto v0.0.0-20210616213533-5ff15b29337e
 	golang.org/x/net v0.0.0-20210614182718-04defd469f4e
-	google.golang.org/genproto v0.0.0-20200731012542-8145dea6a485
-	google.golang.org/grpc v1.33.2
+	google.golang.org/genproto v0.0.0-20220608133413-ed9918b62aac
+	google.golang.org/grpc v1.47.0
+	google.golang.org/protobuf v1.28.0
 )
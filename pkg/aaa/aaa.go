@@ -0,0 +1,316 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package aaa authenticates and authorizes gNMI clients against the
+// openconfig-system AAA configuration of the simulated device.
+package aaa
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var log = logging.GetLogger("aaa")
+
+const (
+	usernameKey = "username"
+	passwordKey = "password"
+
+	// AdminUsername is the name of the account configured by system/aaa/authentication/admin-user.
+	AdminUsername = "admin"
+)
+
+// Operation is the kind of access requested by an RPC.
+type Operation int
+
+const (
+	// OperationRead is requested by Capabilities, Get and Subscribe RPCs.
+	OperationRead Operation = iota
+	// OperationWrite is requested by Set RPCs.
+	OperationWrite
+	// OperationAdmin is requested by the RPCs of the admin services, which
+	// drive the simulator itself.
+	OperationAdmin
+)
+
+func (op Operation) String() string {
+	return [...]string{"read", "write", "administer"}[op]
+}
+
+// ConfigStore gives access to the config tree holding the AAA configuration.
+// It is implemented by gnmi.Server.
+type ConfigStore interface {
+	InternalRead(fp func(config ygot.ValidatedGoStruct) error) error
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+}
+
+// Counters are the AAA counters of the target.
+type Counters struct {
+	AuthenticationAccepts uint64
+	AuthenticationRejects uint64
+	AuthorizationAccepts  uint64
+	AuthorizationRejects  uint64
+	AccountingRecords     uint64
+}
+
+// Authenticator authenticates users against the users and admin-user in
+// system/aaa/authentication of the live config tree, so accounts added or
+// changed through gNMI Set take effect on the next request.
+type Authenticator struct {
+	store    ConfigStore
+	mu       sync.Mutex
+	counters Counters
+}
+
+// account is a local user account found in the config tree.
+type account struct {
+	password       string
+	passwordHashed string
+	role           string
+}
+
+// NewAuthenticator creates an Authenticator reading the AAA configuration from store.
+func NewAuthenticator(store ConfigStore) *Authenticator {
+	return &Authenticator{store: store}
+}
+
+// Counters returns a snapshot of the AAA counters.
+func (a *Authenticator) Counters() Counters {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.counters
+}
+
+// AuthorizeUser checks the username and password in the context metadata
+// against the AAA configuration and whether the user may perform op. It
+// returns a message describing the outcome and whether the request is allowed.
+func (a *Authenticator) AuthorizeUser(ctx context.Context, op Operation) (string, bool) {
+	headers, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		a.count(false, false)
+		return "no Metadata found", false
+	}
+	user, ok := headers[usernameKey]
+	if !ok || len(user) == 0 {
+		a.count(false, false)
+		return "no username in Metadata", false
+	}
+	pass, ok := headers[passwordKey]
+	if !ok || len(pass) == 0 {
+		a.count(false, false)
+		return fmt.Sprintf("found username \"%s\" but no password in Metadata", user[0]), false
+	}
+
+	return a.authorize(user[0], &pass[0], op)
+}
+
+// AuthorizeIdentity checks whether the user of an identity already
+// established by other means, such as a mapped client certificate, exists in
+// the AAA configuration and may perform op. No password is verified.
+func (a *Authenticator) AuthorizeIdentity(username string, op Operation) (string, bool) {
+	return a.authorize(username, nil, op)
+}
+
+// authorize looks up username in the AAA configuration, verifies password
+// unless it is nil and checks whether the user may perform op. The account is
+// copied under the read lock of the store, so that the slow hashing of the
+// password holds no lock. The events of an authorized request are only
+// recorded under the write lock when their state is not already the one
+// recorded, which it is after the first request of each operation.
+func (a *Authenticator) authorize(username string, password *string, op Operation) (string, bool) {
+	var acct *account
+	var unrecorded bool
+	err := a.store.InternalRead(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return fmt.Errorf("config tree is not an openconfig device: %T", config)
+		}
+		acct, _ = lookupAccount(device, username)
+		unrecorded = recordEvents(device, op, false)
+		return nil
+	})
+	if err != nil {
+		log.Errorf("error in reading AAA configuration: %v", err)
+		return err.Error(), false
+	}
+	if acct == nil {
+		a.count(false, false)
+		return fmt.Sprintf("unknown user \"%s\"", username), false
+	}
+	if password != nil {
+		if match, err := acct.checkPassword(*password); !match {
+			if err != nil {
+				log.Warnf("cannot verify password of user %s: %v", username, err)
+			}
+			a.count(false, false)
+			return fmt.Sprintf("not authorized with \"%s\"", username), false
+		}
+	}
+	if !acct.mayPerform(op) {
+		a.count(true, false)
+		return fmt.Sprintf("user \"%s\" with role %s is not authorized to %s", username, acct.role, op), false
+	}
+	if unrecorded {
+		err = a.store.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+			if device, ok := config.(*gostruct.Device); ok {
+				recordEvents(device, op, true)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errorf("error in recording AAA events: %v", err)
+		}
+	}
+	a.count(true, true)
+	return fmt.Sprintf("authorized with \"%s\"", username), true
+}
+
+// count updates the counters after an authentication attempt.
+func (a *Authenticator) count(authenticated, authorized bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !authenticated {
+		a.counters.AuthenticationRejects++
+		return
+	}
+	a.counters.AuthenticationAccepts++
+	if !authorized {
+		a.counters.AuthorizationRejects++
+		return
+	}
+	a.counters.AuthorizationAccepts++
+	a.counters.AccountingRecords++
+}
+
+// lookupAccount finds the account of username in the authentication config.
+func lookupAccount(device *gostruct.Device, username string) (*account, bool) {
+	if device.System == nil || device.System.Aaa == nil || device.System.Aaa.Authentication == nil {
+		return nil, false
+	}
+	authentication := device.System.Aaa.Authentication
+	if username == AdminUsername && authentication.AdminUser != nil && authentication.AdminUser.Config != nil {
+		c := authentication.AdminUser.Config
+		return &account{
+			password:       stringValue(c.AdminPassword),
+			passwordHashed: stringValue(c.AdminPasswordHashed),
+			role:           gostruct.OpenconfigAaaTypes_SYSTEM_DEFINED_ROLES_SYSTEM_ROLE_ADMIN.String(),
+		}, true
+	}
+	if authentication.Users == nil {
+		return nil, false
+	}
+	user, ok := authentication.Users.User[username]
+	if !ok || user.Config == nil {
+		return nil, false
+	}
+	acct := &account{
+		password:       stringValue(user.Config.Password),
+		passwordHashed: stringValue(user.Config.PasswordHashed),
+	}
+	switch role := user.Config.Role.(type) {
+	case *gostruct.OpenconfigSystem_System_Aaa_Authentication_Users_User_Config_Role_Union_E_OpenconfigAaaTypes_SYSTEM_DEFINED_ROLES:
+		acct.role = role.E_OpenconfigAaaTypes_SYSTEM_DEFINED_ROLES.String()
+	case *gostruct.OpenconfigSystem_System_Aaa_Authentication_Users_User_Config_Role_Union_String:
+		acct.role = role.String
+	}
+	return acct, true
+}
+
+// checkPassword verifies password against the clear text or hashed password of the account.
+func (acct *account) checkPassword(password string) (bool, error) {
+	if acct.passwordHashed != "" {
+		return CheckPassword(acct.passwordHashed, password)
+	}
+	return acct.password != "" && acct.password == password, nil
+}
+
+// mayPerform reports whether the account is allowed to perform op. Accounts
+// without a role or with SYSTEM_ROLE_ADMIN may write; any other role is
+// read-only. Only SYSTEM_ROLE_ADMIN, which the admin-user has, may
+// administer.
+func (acct *account) mayPerform(op Operation) bool {
+	admin := acct.role == gostruct.OpenconfigAaaTypes_SYSTEM_DEFINED_ROLES_SYSTEM_ROLE_ADMIN.String()
+	switch op {
+	case OperationRead:
+		return true
+	case OperationWrite:
+		return acct.role == "" || admin
+	}
+	return admin
+}
+
+// recordEvents reports whether the configured accounting and authorization
+// events that apply to op differ from their state containers, and reflects
+// them into the state containers when record is set. The AAA configuration
+// may have been removed since the account was looked up.
+func recordEvents(device *gostruct.Device, op Operation, record bool) bool {
+	if device.System == nil || device.System.Aaa == nil || device.System.Aaa.Authentication == nil {
+		return false
+	}
+	aaa := device.System.Aaa
+	changed := false
+	authorizationEvent := gostruct.OpenconfigAaaTypes_AAA_AUTHORIZATION_EVENT_TYPE_AAA_AUTHORIZATION_EVENT_COMMAND
+	if op != OperationRead {
+		authorizationEvent = gostruct.OpenconfigAaaTypes_AAA_AUTHORIZATION_EVENT_TYPE_AAA_AUTHORIZATION_EVENT_CONFIG
+	}
+	if aaa.Authorization != nil && aaa.Authorization.Events != nil {
+		if event, ok := aaa.Authorization.Events.Event[authorizationEvent]; ok && event.Config != nil {
+			if event.State == nil || event.State.EventType != event.Config.EventType {
+				changed = true
+				if record {
+					event.State = &gostruct.OpenconfigSystem_System_Aaa_Authorization_Events_Event_State{
+						EventType: event.Config.EventType,
+					}
+				}
+			}
+		}
+	}
+	if aaa.Accounting != nil && aaa.Accounting.Events != nil {
+		for _, eventType := range []gostruct.E_OpenconfigAaaTypes_AAA_ACCOUNTING_EVENT_TYPE{
+			gostruct.OpenconfigAaaTypes_AAA_ACCOUNTING_EVENT_TYPE_AAA_ACCOUNTING_EVENT_LOGIN,
+			gostruct.OpenconfigAaaTypes_AAA_ACCOUNTING_EVENT_TYPE_AAA_ACCOUNTING_EVENT_COMMAND,
+		} {
+			event, ok := aaa.Accounting.Events.Event[eventType]
+			if !ok || event.Config == nil {
+				continue
+			}
+			if event.State == nil || event.State.EventType != event.Config.EventType || event.State.Record != event.Config.Record {
+				changed = true
+				if record {
+					event.State = &gostruct.OpenconfigSystem_System_Aaa_Accounting_Events_Event_State{
+						EventType: event.Config.EventType,
+						Record:    event.Config.Record,
+					}
+				}
+			}
+		}
+	}
+	if admin := aaa.Authentication.AdminUser; admin != nil {
+		if admin.State == nil || admin.State.AdminUsername == nil || *admin.State.AdminUsername != AdminUsername {
+			changed = true
+			if record {
+				if admin.State == nil {
+					admin.State = &gostruct.OpenconfigSystem_System_Aaa_Authentication_AdminUser_State{}
+				}
+				admin.State.AdminUsername = ygot.String(AdminUsername)
+			}
+		}
+	}
+	return changed
+}
+
+// stringValue dereferences a GoStruct string leaf, returning "" if it is unset.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aaa
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+// testStore is a ConfigStore over a fixed config tree, counting its updates.
+type testStore struct {
+	device  *gostruct.Device
+	updates int
+}
+
+func (ts *testStore) InternalRead(fp func(config ygot.ValidatedGoStruct) error) error {
+	return fp(ts.device)
+}
+
+func (ts *testStore) InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error {
+	ts.updates++
+	return fp(ts.device)
+}
+
+func TestCheckPassword(t *testing.T) {
+	tds := []struct {
+		hashed   string
+		password string
+		want     bool
+	}{
+		{"$1$saltstri$YMyguxXMBpd2TEZ.vS/3q1", "Hello world!", true},
+		{"$1$ab$oKsM6dtDD2L1bKowOBX.7.", "password", true},
+		{"$1$ab$oKsM6dtDD2L1bKowOBX.7.", "Password", false},
+		{"$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5", "Hello world!", true},
+		{"$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA", "Hello world!", true},
+		{"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", "Hello world!", true},
+		{"$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.", "Hello world!", true},
+		{"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", "hello world!", false},
+	}
+	for _, td := range tds {
+		got, err := CheckPassword(td.hashed, td.password)
+		if err != nil {
+			t.Errorf("CheckPassword(%s): unexpected error %v", td.hashed, err)
+		}
+		if got != td.want {
+			t.Errorf("CheckPassword(%s, %s): got %v, want %v", td.hashed, td.password, got, td.want)
+		}
+	}
+	if _, err := CheckPassword("plain", "plain"); err == nil {
+		t.Error("CheckPassword of an unsupported hash: got nil error")
+	}
+	for _, hashed := range []string{
+		"$6$rounds=999999999$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+		"$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy",
+	} {
+		if ok, err := CheckPassword(hashed, "Hello world!"); ok || err == nil {
+			t.Errorf("CheckPassword(%s): got %v, %v, want an error for its cost", hashed, ok, err)
+		}
+	}
+}
+
+func TestAuthorizeUser(t *testing.T) {
+	jsonConfig := `{
+		"openconfig-system:system": {
+			"aaa": {
+				"authentication": {
+					"admin-user": {"config": {"admin-password": "password"}},
+					"users": {"user": [
+						{"username": "alice", "config": {"username": "alice", "password-hashed": "$1$ab$oKsM6dtDD2L1bKowOBX.7."}},
+						{"username": "bob", "config": {"username": "bob", "password": "secret", "role": "read-only"}}
+					]}
+				}
+			}
+		}
+	}`
+	device := &gostruct.Device{}
+	if err := gostruct.Unmarshal([]byte(jsonConfig), device); err != nil {
+		t.Fatalf("error in unmarshaling config: %v", err)
+	}
+	store := &testStore{device: device}
+	a := NewAuthenticator(store)
+
+	tds := []struct {
+		desc     string
+		username string
+		password string
+		op       Operation
+		want     bool
+	}{
+		{"admin user", "admin", "password", OperationWrite, true},
+		{"admin user with wrong password", "admin", "secret", OperationRead, false},
+		{"user with hashed password", "alice", "password", OperationWrite, true},
+		{"read-only user reads", "bob", "secret", OperationRead, true},
+		{"read-only user writes", "bob", "secret", OperationWrite, false},
+		{"unknown user", "carol", "secret", OperationRead, false},
+	}
+	for _, td := range tds {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(usernameKey, td.username, passwordKey, td.password))
+		msg, got := a.AuthorizeUser(ctx, td.op)
+		if got != td.want {
+			t.Errorf("%s: got %v (%s), want %v", td.desc, got, msg, td.want)
+		}
+	}
+	if _, ok := a.AuthorizeUser(context.Background(), OperationRead); ok {
+		t.Error("request without metadata: got authorized")
+	}
+
+	want := Counters{AuthenticationAccepts: 4, AuthenticationRejects: 3, AuthorizationAccepts: 3, AuthorizationRejects: 1, AccountingRecords: 3}
+	if got := a.Counters(); got != want {
+		t.Errorf("got counters %+v, want %+v", got, want)
+	}
+	if got := device.System.Aaa.Authentication.AdminUser.State; got == nil || got.AdminUsername == nil || *got.AdminUsername != AdminUsername {
+		t.Errorf("got admin-user state %+v, want the admin username", got)
+	}
+	if store.updates != 1 {
+		t.Errorf("got %d updates of the config tree, want 1 recording the events once", store.updates)
+	}
+	if _, ok := a.AuthorizeIdentity("bob", OperationRead); !ok {
+		t.Error("identity of a read-only user reads: got denied")
+	}
+	if _, ok := a.AuthorizeIdentity("bob", OperationWrite); ok {
+		t.Error("identity of a read-only user writes: got authorized")
+	}
+	if _, ok := a.AuthorizeIdentity("carol", OperationRead); ok {
+		t.Error("identity of an unknown user: got authorized")
+	}
+	if _, ok := a.AuthorizeIdentity("admin", OperationAdmin); !ok {
+		t.Error("identity of the admin user administers: got denied")
+	}
+	if _, ok := a.AuthorizeIdentity("alice", OperationAdmin); ok {
+		t.Error("identity of a user without role administers: got authorized")
+	}
+	if got := stringValue(device.System.Aaa.Authentication.AdminUser.State.AdminUsername); got != AdminUsername {
+		t.Errorf("got admin-username state %q, want %q", got, AdminUsername)
+	}
+}
+
+func TestCertMapper(t *testing.T) {
+	m, err := NewCertMapper([]CertRule{
+		{Field: FieldSAN, Match: `^spiffe://example\.org/user/(\w+)$`, Username: "$1"},
+		{Field: FieldCommonName, Match: `^(\w+)\.clients\.example\.org$`, Username: "$1"},
+		{Field: FieldCommonName, Match: `^onos-config`, Username: "admin"},
+	})
+	if err != nil {
+		t.Fatalf("error in creating mapper: %v", err)
+	}
+	spiffe, _ := url.Parse("spiffe://example.org/user/carol")
+	tds := []struct {
+		desc   string
+		cert   *x509.Certificate
+		want   string
+		wantOk bool
+	}{
+		{"CN with capture", &x509.Certificate{Subject: pkix.Name{CommonName: "alice.clients.example.org"}}, "alice", true},
+		{"CN with fixed username", &x509.Certificate{Subject: pkix.Name{CommonName: "onos-config-0"}}, "admin", true},
+		{"SAN URI takes precedence", &x509.Certificate{Subject: pkix.Name{CommonName: "bob.clients.example.org"}, URIs: []*url.URL{spiffe}}, "carol", true},
+		{"no match", &x509.Certificate{Subject: pkix.Name{CommonName: "dave"}, DNSNames: []string{"dave.example.org"}}, "", false},
+	}
+	for _, td := range tds {
+		got, ok := m.Username(td.cert)
+		if got != td.want || ok != td.wantOk {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", td.desc, got, ok, td.want, td.wantOk)
+		}
+	}
+
+	if _, err := NewCertMapper([]CertRule{{Field: "O", Match: ".*"}}); err == nil {
+		t.Error("rule on an unsupported field: got nil error")
+	}
+	if _, err := NewCertMapper([]CertRule{{Field: FieldCommonName, Match: "("}}); err == nil {
+		t.Error("rule with an invalid expression: got nil error")
+	}
+}
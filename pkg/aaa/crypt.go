@@ -0,0 +1,261 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aaa
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	cryptAlphabet       = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	md5CryptMagic       = "$1$"
+	sha256CryptMagic    = "$5$"
+	sha512CryptMagic    = "$6$"
+	shaCryptRoundsKey   = "rounds="
+	shaCryptRounds      = 5000
+	shaCryptMinRounds   = 1000
+	shaCryptMaxRounds   = 1000000
+	bcryptMaxCost       = 14
+	shaCryptMaxSaltSize = 16
+	md5CryptMaxSaltSize = 8
+)
+
+// sha256CryptOrder and sha512CryptOrder are the byte permutations used by
+// SHA-crypt to encode the final digest.
+var (
+	sha256CryptOrder = [][3]int{{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29}}
+	sha512CryptOrder = [][3]int{{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+		{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10},
+		{53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35}, {15, 36, 57}, {37, 58, 16},
+		{59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41}}
+	md5CryptOrder = [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}}
+)
+
+// CheckPassword reports whether password matches the crypt(3) style hashed
+// password. MD5 ($1$), SHA-256 ($5$), SHA-512 ($6$) and bcrypt ($2a$, $2b$,
+// $2y$) hashes are supported. Hashes of more than 1,000,000 SHA-crypt rounds
+// or of a bcrypt cost above 14 are rejected with an error, since every RPC
+// checks the password.
+func CheckPassword(hashed, password string) (bool, error) {
+	var computed string
+	var err error
+	switch {
+	case strings.HasPrefix(hashed, md5CryptMagic):
+		computed = md5Crypt([]byte(password), []byte(strings.TrimPrefix(hashed, md5CryptMagic)))
+	case strings.HasPrefix(hashed, sha256CryptMagic):
+		computed, err = shaCrypt(sha256.New, sha256CryptMagic, sha256CryptOrder, []byte(password), strings.TrimPrefix(hashed, sha256CryptMagic))
+	case strings.HasPrefix(hashed, sha512CryptMagic):
+		computed, err = shaCrypt(sha512.New, sha512CryptMagic, sha512CryptOrder, []byte(password), strings.TrimPrefix(hashed, sha512CryptMagic))
+	case strings.HasPrefix(hashed, "$2a$"), strings.HasPrefix(hashed, "$2b$"), strings.HasPrefix(hashed, "$2y$"):
+		if cost, err := bcrypt.Cost([]byte(hashed)); err != nil || cost > bcryptMaxCost {
+			return false, fmt.Errorf("unsupported bcrypt cost in password hash")
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	default:
+		return false, fmt.Errorf("unsupported password hash format")
+	}
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(hashed)) == 1, nil
+}
+
+// shaCrypt implements the SHA-crypt algorithm used by glibc for $5$ and $6$
+// hashes. setting is the part of the hash following the magic prefix. Rounds
+// below the minimum are raised to it, as glibc does, while rounds above the
+// maximum are an error rather than minutes of hashing.
+func shaCrypt(newHash func() hash.Hash, magic string, order [][3]int, password []byte, setting string) (string, error) {
+	rounds := shaCryptRounds
+	customRounds := false
+	if strings.HasPrefix(setting, shaCryptRoundsKey) {
+		parts := strings.SplitN(strings.TrimPrefix(setting, shaCryptRoundsKey), "$", 2)
+		if n, err := strconv.Atoi(parts[0]); err == nil && len(parts) == 2 {
+			rounds = n
+			customRounds = true
+			setting = parts[1]
+		}
+		if rounds < shaCryptMinRounds {
+			rounds = shaCryptMinRounds
+		}
+		if rounds > shaCryptMaxRounds {
+			return "", fmt.Errorf("too many rounds in password hash: %d > %d", rounds, shaCryptMaxRounds)
+		}
+	}
+	salt := []byte(cryptSalt(setting, shaCryptMaxSaltSize))
+
+	h := newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	alternate := h.Sum(nil)
+
+	h = newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(repeatBytes(alternate, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write(alternate)
+		} else {
+			h.Write(password)
+		}
+	}
+	digest := h.Sum(nil)
+
+	h = newHash()
+	for range password {
+		h.Write(password)
+	}
+	passwordSeq := repeatBytes(h.Sum(nil), len(password))
+
+	h = newHash()
+	for i := 0; i < 16+int(digest[0]); i++ {
+		h.Write(salt)
+	}
+	saltSeq := repeatBytes(h.Sum(nil), len(salt))
+
+	for i := 0; i < rounds; i++ {
+		h = newHash()
+		if i&1 != 0 {
+			h.Write(passwordSeq)
+		} else {
+			h.Write(digest)
+		}
+		if i%3 != 0 {
+			h.Write(saltSeq)
+		}
+		if i%7 != 0 {
+			h.Write(passwordSeq)
+		}
+		if i&1 != 0 {
+			h.Write(digest)
+		} else {
+			h.Write(passwordSeq)
+		}
+		digest = h.Sum(nil)
+	}
+
+	var b strings.Builder
+	b.WriteString(magic)
+	if customRounds {
+		fmt.Fprintf(&b, "%s%d$", shaCryptRoundsKey, rounds)
+	}
+	b.Write(salt)
+	b.WriteByte('$')
+	for _, o := range order {
+		b.WriteString(encodeCrypt64(digest[o[0]], digest[o[1]], digest[o[2]], 4))
+	}
+	if len(digest) == sha256.Size {
+		b.WriteString(encodeCrypt64(0, digest[31], digest[30], 3))
+	} else {
+		b.WriteString(encodeCrypt64(0, 0, digest[63], 2))
+	}
+	return b.String(), nil
+}
+
+// md5Crypt implements the MD5-crypt algorithm used for $1$ hashes.
+func md5Crypt(password, setting []byte) string {
+	salt := []byte(cryptSalt(string(setting), md5CryptMaxSaltSize))
+
+	h := md5.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	alternate := h.Sum(nil)
+
+	h = md5.New()
+	h.Write(password)
+	h.Write([]byte(md5CryptMagic))
+	h.Write(salt)
+	h.Write(repeatBytes(alternate, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(password[:1])
+		}
+	}
+	digest := h.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		h = md5.New()
+		if i&1 != 0 {
+			h.Write(password)
+		} else {
+			h.Write(digest)
+		}
+		if i%3 != 0 {
+			h.Write(salt)
+		}
+		if i%7 != 0 {
+			h.Write(password)
+		}
+		if i&1 != 0 {
+			h.Write(digest)
+		} else {
+			h.Write(password)
+		}
+		digest = h.Sum(nil)
+	}
+
+	var b strings.Builder
+	b.WriteString(md5CryptMagic)
+	b.Write(salt)
+	b.WriteByte('$')
+	for _, o := range md5CryptOrder {
+		b.WriteString(encodeCrypt64(digest[o[0]], digest[o[1]], digest[o[2]], 4))
+	}
+	b.WriteString(encodeCrypt64(0, 0, digest[11], 2))
+	return b.String()
+}
+
+// cryptSalt extracts the salt from a crypt setting, truncated to maxSize.
+func cryptSalt(setting string, maxSize int) string {
+	if i := strings.IndexByte(setting, '$'); i >= 0 {
+		setting = setting[:i]
+	}
+	if len(setting) > maxSize {
+		setting = setting[:maxSize]
+	}
+	return setting
+}
+
+// repeatBytes returns a slice of length n filled by repeating b.
+func repeatBytes(b []byte, n int) []byte {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		remaining := n - len(out)
+		if remaining > len(b) {
+			remaining = len(b)
+		}
+		out = append(out, b[:remaining]...)
+	}
+	return out
+}
+
+// encodeCrypt64 encodes three bytes into n characters of the crypt base64 alphabet.
+func encodeCrypt64(b2, b1, b0 byte, n int) string {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	out := make([]byte, n)
+	for i := 0; i < n; i++ {
+		out[i] = cryptAlphabet[w&0x3f]
+		w >>= 6
+	}
+	return string(out)
+}
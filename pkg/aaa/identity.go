@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aaa
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// Sources of an Identity.
+const (
+	// SourcePassword is an identity taken from the username and password metadata.
+	SourcePassword = "password"
+	// SourceCertificate is an identity mapped from the client TLS certificate.
+	SourceCertificate = "certificate"
+)
+
+// Fields of a client certificate a CertRule can match.
+const (
+	FieldCommonName = "CN"
+	FieldSAN        = "SAN"
+)
+
+// Identity is the authenticated identity of a gNMI client.
+type Identity struct {
+	Username string
+	Source   string
+	// Subject is the subject of the client certificate the identity was mapped from.
+	Subject string
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the Identity carried by ctx, if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok
+}
+
+// UsernameFromContext returns the username of the Identity carried by ctx, or
+// an empty string.
+func UsernameFromContext(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id.Username
+	}
+	return ""
+}
+
+// CertRule maps a client certificate identity to a username. Match is a
+// regular expression applied to the common name or to each subject
+// alternative name (DNS names, email addresses and URIs) of the certificate,
+// and Username is expanded with the submatches of the first match, e.g. "$1".
+type CertRule struct {
+	Field    string `json:"field"`
+	Match    string `json:"match"`
+	Username string `json:"username"`
+	re       *regexp.Regexp
+}
+
+// CertMapper maps client certificates to usernames using an ordered list of rules.
+type CertMapper struct {
+	rules []CertRule
+}
+
+// NewCertMapper creates a CertMapper from rules.
+func NewCertMapper(rules []CertRule) (*CertMapper, error) {
+	m := &CertMapper{}
+	for _, rule := range rules {
+		if rule.Field != FieldCommonName && rule.Field != FieldSAN {
+			return nil, fmt.Errorf("unsupported certificate field %q, expect %s or %s", rule.Field, FieldCommonName, FieldSAN)
+		}
+		re, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match expression %q: %v", rule.Match, err)
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// LoadCertMapper creates a CertMapper from a JSON file holding a list of rules.
+func LoadCertMapper(file string) (*CertMapper, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rules []CertRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error in parsing certificate mapping rules: %v", err)
+	}
+	return NewCertMapper(rules)
+}
+
+// Username returns the username the first matching rule maps cert to.
+func (m *CertMapper) Username(cert *x509.Certificate) (string, bool) {
+	for _, rule := range m.rules {
+		var names []string
+		if rule.Field == FieldCommonName {
+			names = []string{cert.Subject.CommonName}
+		} else {
+			names = append(names, cert.DNSNames...)
+			names = append(names, cert.EmailAddresses...)
+			for _, uri := range cert.URIs {
+				names = append(names, uri.String())
+			}
+		}
+		for _, name := range names {
+			match := rule.re.FindStringSubmatchIndex(name)
+			if match == nil {
+				continue
+			}
+			username := string(rule.re.ExpandString(nil, rule.Username, name, match))
+			if username != "" {
+				return username, true
+			}
+		}
+	}
+	return "", false
+}
+
+// PeerCertificate returns the verified client certificate of the peer of ctx.
+func PeerCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil, false
+	}
+	for _, chain := range tlsInfo.State.VerifiedChains {
+		if len(chain) > 0 {
+			return chain[0], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,847 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: pkg/admin/admin.proto
+
+package admin
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SchemaClient is the client API for Schema service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SchemaClient interface {
+	// Describe returns the node of a path.
+	Describe(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*DescribeResponse, error)
+	// Children returns the child nodes of a container or list, or of the root
+	// for an empty path. The nodes of choices and cases are returned in place
+	// of the choices.
+	Children(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*ChildrenResponse, error)
+}
+
+type schemaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSchemaClient(cc grpc.ClientConnInterface) SchemaClient {
+	return &schemaClient{cc}
+}
+
+func (c *schemaClient) Describe(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*DescribeResponse, error) {
+	out := new(DescribeResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Schema/Describe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *schemaClient) Children(ctx context.Context, in *SchemaRequest, opts ...grpc.CallOption) (*ChildrenResponse, error) {
+	out := new(ChildrenResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Schema/Children", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SchemaServer is the server API for Schema service.
+// All implementations must embed UnimplementedSchemaServer
+// for forward compatibility
+type SchemaServer interface {
+	// Describe returns the node of a path.
+	Describe(context.Context, *SchemaRequest) (*DescribeResponse, error)
+	// Children returns the child nodes of a container or list, or of the root
+	// for an empty path. The nodes of choices and cases are returned in place
+	// of the choices.
+	Children(context.Context, *SchemaRequest) (*ChildrenResponse, error)
+	mustEmbedUnimplementedSchemaServer()
+}
+
+// UnimplementedSchemaServer must be embedded to have forward compatible implementations.
+type UnimplementedSchemaServer struct {
+}
+
+func (UnimplementedSchemaServer) Describe(context.Context, *SchemaRequest) (*DescribeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Describe not implemented")
+}
+func (UnimplementedSchemaServer) Children(context.Context, *SchemaRequest) (*ChildrenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Children not implemented")
+}
+func (UnimplementedSchemaServer) mustEmbedUnimplementedSchemaServer() {}
+
+// UnsafeSchemaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SchemaServer will
+// result in compilation errors.
+type UnsafeSchemaServer interface {
+	mustEmbedUnimplementedSchemaServer()
+}
+
+func RegisterSchemaServer(s grpc.ServiceRegistrar, srv SchemaServer) {
+	s.RegisterService(&Schema_ServiceDesc, srv)
+}
+
+func _Schema_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Schema/Describe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaServer).Describe(ctx, req.(*SchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Schema_Children_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SchemaServer).Children(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Schema/Children",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SchemaServer).Children(ctx, req.(*SchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Schema_ServiceDesc is the grpc.ServiceDesc for Schema service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Schema_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gnxi.admin.Schema",
+	HandlerType: (*SchemaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Describe",
+			Handler:    _Schema_Describe_Handler,
+		},
+		{
+			MethodName: "Children",
+			Handler:    _Schema_Children_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/admin/admin.proto",
+}
+
+// LinksClient is the client API for Links service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LinksClient interface {
+	// List returns the links of the interfaces of a device.
+	List(ctx context.Context, in *ListLinksRequest, opts ...grpc.CallOption) (*ListLinksResponse, error)
+	// SetCarrier brings the carrier of a link up or down.
+	SetCarrier(ctx context.Context, in *SetCarrierRequest, opts ...grpc.CallOption) (*LinkResponse, error)
+	// Flap brings the carrier of a link down for a while.
+	Flap(ctx context.Context, in *FlapRequest, opts ...grpc.CallOption) (*LinkResponse, error)
+}
+
+type linksClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLinksClient(cc grpc.ClientConnInterface) LinksClient {
+	return &linksClient{cc}
+}
+
+func (c *linksClient) List(ctx context.Context, in *ListLinksRequest, opts ...grpc.CallOption) (*ListLinksResponse, error) {
+	out := new(ListLinksResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Links/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linksClient) SetCarrier(ctx context.Context, in *SetCarrierRequest, opts ...grpc.CallOption) (*LinkResponse, error) {
+	out := new(LinkResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Links/SetCarrier", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *linksClient) Flap(ctx context.Context, in *FlapRequest, opts ...grpc.CallOption) (*LinkResponse, error) {
+	out := new(LinkResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Links/Flap", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LinksServer is the server API for Links service.
+// All implementations must embed UnimplementedLinksServer
+// for forward compatibility
+type LinksServer interface {
+	// List returns the links of the interfaces of a device.
+	List(context.Context, *ListLinksRequest) (*ListLinksResponse, error)
+	// SetCarrier brings the carrier of a link up or down.
+	SetCarrier(context.Context, *SetCarrierRequest) (*LinkResponse, error)
+	// Flap brings the carrier of a link down for a while.
+	Flap(context.Context, *FlapRequest) (*LinkResponse, error)
+	mustEmbedUnimplementedLinksServer()
+}
+
+// UnimplementedLinksServer must be embedded to have forward compatible implementations.
+type UnimplementedLinksServer struct {
+}
+
+func (UnimplementedLinksServer) List(context.Context, *ListLinksRequest) (*ListLinksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedLinksServer) SetCarrier(context.Context, *SetCarrierRequest) (*LinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCarrier not implemented")
+}
+func (UnimplementedLinksServer) Flap(context.Context, *FlapRequest) (*LinkResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Flap not implemented")
+}
+func (UnimplementedLinksServer) mustEmbedUnimplementedLinksServer() {}
+
+// UnsafeLinksServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LinksServer will
+// result in compilation errors.
+type UnsafeLinksServer interface {
+	mustEmbedUnimplementedLinksServer()
+}
+
+func RegisterLinksServer(s grpc.ServiceRegistrar, srv LinksServer) {
+	s.RegisterService(&Links_ServiceDesc, srv)
+}
+
+func _Links_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLinksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinksServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Links/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinksServer).List(ctx, req.(*ListLinksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Links_SetCarrier_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCarrierRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinksServer).SetCarrier(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Links/SetCarrier",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinksServer).SetCarrier(ctx, req.(*SetCarrierRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Links_Flap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LinksServer).Flap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Links/Flap",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LinksServer).Flap(ctx, req.(*FlapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Links_ServiceDesc is the grpc.ServiceDesc for Links service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Links_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gnxi.admin.Links",
+	HandlerType: (*LinksServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _Links_List_Handler,
+		},
+		{
+			MethodName: "SetCarrier",
+			Handler:    _Links_SetCarrier_Handler,
+		},
+		{
+			MethodName: "Flap",
+			Handler:    _Links_Flap_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/admin/admin.proto",
+}
+
+// AlarmsClient is the client API for Alarms service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AlarmsClient interface {
+	// List returns the alarms of a device.
+	List(ctx context.Context, in *ListAlarmsRequest, opts ...grpc.CallOption) (*ListAlarmsResponse, error)
+	// Raise raises an alarm, or updates the alarm of the same id.
+	Raise(ctx context.Context, in *RaiseAlarmRequest, opts ...grpc.CallOption) (*RaiseAlarmResponse, error)
+	// Clear clears an alarm.
+	Clear(ctx context.Context, in *ClearAlarmRequest, opts ...grpc.CallOption) (*ClearAlarmResponse, error)
+}
+
+type alarmsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAlarmsClient(cc grpc.ClientConnInterface) AlarmsClient {
+	return &alarmsClient{cc}
+}
+
+func (c *alarmsClient) List(ctx context.Context, in *ListAlarmsRequest, opts ...grpc.CallOption) (*ListAlarmsResponse, error) {
+	out := new(ListAlarmsResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Alarms/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *alarmsClient) Raise(ctx context.Context, in *RaiseAlarmRequest, opts ...grpc.CallOption) (*RaiseAlarmResponse, error) {
+	out := new(RaiseAlarmResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Alarms/Raise", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *alarmsClient) Clear(ctx context.Context, in *ClearAlarmRequest, opts ...grpc.CallOption) (*ClearAlarmResponse, error) {
+	out := new(ClearAlarmResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Alarms/Clear", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AlarmsServer is the server API for Alarms service.
+// All implementations must embed UnimplementedAlarmsServer
+// for forward compatibility
+type AlarmsServer interface {
+	// List returns the alarms of a device.
+	List(context.Context, *ListAlarmsRequest) (*ListAlarmsResponse, error)
+	// Raise raises an alarm, or updates the alarm of the same id.
+	Raise(context.Context, *RaiseAlarmRequest) (*RaiseAlarmResponse, error)
+	// Clear clears an alarm.
+	Clear(context.Context, *ClearAlarmRequest) (*ClearAlarmResponse, error)
+	mustEmbedUnimplementedAlarmsServer()
+}
+
+// UnimplementedAlarmsServer must be embedded to have forward compatible implementations.
+type UnimplementedAlarmsServer struct {
+}
+
+func (UnimplementedAlarmsServer) List(context.Context, *ListAlarmsRequest) (*ListAlarmsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedAlarmsServer) Raise(context.Context, *RaiseAlarmRequest) (*RaiseAlarmResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Raise not implemented")
+}
+func (UnimplementedAlarmsServer) Clear(context.Context, *ClearAlarmRequest) (*ClearAlarmResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Clear not implemented")
+}
+func (UnimplementedAlarmsServer) mustEmbedUnimplementedAlarmsServer() {}
+
+// UnsafeAlarmsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AlarmsServer will
+// result in compilation errors.
+type UnsafeAlarmsServer interface {
+	mustEmbedUnimplementedAlarmsServer()
+}
+
+func RegisterAlarmsServer(s grpc.ServiceRegistrar, srv AlarmsServer) {
+	s.RegisterService(&Alarms_ServiceDesc, srv)
+}
+
+func _Alarms_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAlarmsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlarmsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Alarms/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlarmsServer).List(ctx, req.(*ListAlarmsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Alarms_Raise_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RaiseAlarmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlarmsServer).Raise(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Alarms/Raise",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlarmsServer).Raise(ctx, req.(*RaiseAlarmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Alarms_Clear_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearAlarmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AlarmsServer).Clear(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Alarms/Clear",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AlarmsServer).Clear(ctx, req.(*ClearAlarmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Alarms_ServiceDesc is the grpc.ServiceDesc for Alarms service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Alarms_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gnxi.admin.Alarms",
+	HandlerType: (*AlarmsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _Alarms_List_Handler,
+		},
+		{
+			MethodName: "Raise",
+			Handler:    _Alarms_Raise_Handler,
+		},
+		{
+			MethodName: "Clear",
+			Handler:    _Alarms_Clear_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/admin/admin.proto",
+}
+
+// FaultsClient is the client API for Faults service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FaultsClient interface {
+	// List returns the fault rules of a device.
+	List(ctx context.Context, in *ListFaultsRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error)
+	// Set replaces the fault rules of a device, removing them all when empty.
+	Set(ctx context.Context, in *SetFaultsRequest, opts ...grpc.CallOption) (*SetFaultsResponse, error)
+}
+
+type faultsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFaultsClient(cc grpc.ClientConnInterface) FaultsClient {
+	return &faultsClient{cc}
+}
+
+func (c *faultsClient) List(ctx context.Context, in *ListFaultsRequest, opts ...grpc.CallOption) (*ListFaultsResponse, error) {
+	out := new(ListFaultsResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Faults/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *faultsClient) Set(ctx context.Context, in *SetFaultsRequest, opts ...grpc.CallOption) (*SetFaultsResponse, error) {
+	out := new(SetFaultsResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Faults/Set", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FaultsServer is the server API for Faults service.
+// All implementations must embed UnimplementedFaultsServer
+// for forward compatibility
+type FaultsServer interface {
+	// List returns the fault rules of a device.
+	List(context.Context, *ListFaultsRequest) (*ListFaultsResponse, error)
+	// Set replaces the fault rules of a device, removing them all when empty.
+	Set(context.Context, *SetFaultsRequest) (*SetFaultsResponse, error)
+	mustEmbedUnimplementedFaultsServer()
+}
+
+// UnimplementedFaultsServer must be embedded to have forward compatible implementations.
+type UnimplementedFaultsServer struct {
+}
+
+func (UnimplementedFaultsServer) List(context.Context, *ListFaultsRequest) (*ListFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedFaultsServer) Set(context.Context, *SetFaultsRequest) (*SetFaultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (UnimplementedFaultsServer) mustEmbedUnimplementedFaultsServer() {}
+
+// UnsafeFaultsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FaultsServer will
+// result in compilation errors.
+type UnsafeFaultsServer interface {
+	mustEmbedUnimplementedFaultsServer()
+}
+
+func RegisterFaultsServer(s grpc.ServiceRegistrar, srv FaultsServer) {
+	s.RegisterService(&Faults_ServiceDesc, srv)
+}
+
+func _Faults_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FaultsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Faults/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FaultsServer).List(ctx, req.(*ListFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Faults_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFaultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FaultsServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Faults/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FaultsServer).Set(ctx, req.(*SetFaultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Faults_ServiceDesc is the grpc.ServiceDesc for Faults service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Faults_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gnxi.admin.Faults",
+	HandlerType: (*FaultsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _Faults_List_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _Faults_Set_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/admin/admin.proto",
+}
+
+// DeviceClient is the client API for Device service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeviceClient interface {
+	// WriteState writes leaves of the tree of a device, typically state
+	// leaves, checking their values against the schema but bypassing the
+	// validation of the tree, and notifies their subscribers.
+	WriteState(ctx context.Context, in *WriteStateRequest, opts ...grpc.CallOption) (*WriteStateResponse, error)
+	// Reboot reboots a device, as gNOI System Reboot does.
+	Reboot(ctx context.Context, in *RebootRequest, opts ...grpc.CallOption) (*RebootResponse, error)
+	// Load replaces the whole tree of a device.
+	Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error)
+	// Dump returns the whole tree of a device.
+	Dump(ctx context.Context, in *DumpRequest, opts ...grpc.CallOption) (*DumpResponse, error)
+	// ListSubscriptions returns the active Subscribe streams of a device.
+	ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*ListSubscriptionsResponse, error)
+}
+
+type deviceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeviceClient(cc grpc.ClientConnInterface) DeviceClient {
+	return &deviceClient{cc}
+}
+
+func (c *deviceClient) WriteState(ctx context.Context, in *WriteStateRequest, opts ...grpc.CallOption) (*WriteStateResponse, error) {
+	out := new(WriteStateResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Device/WriteState", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) Reboot(ctx context.Context, in *RebootRequest, opts ...grpc.CallOption) (*RebootResponse, error) {
+	out := new(RebootResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Device/Reboot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) Load(ctx context.Context, in *LoadRequest, opts ...grpc.CallOption) (*LoadResponse, error) {
+	out := new(LoadResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Device/Load", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) Dump(ctx context.Context, in *DumpRequest, opts ...grpc.CallOption) (*DumpResponse, error) {
+	out := new(DumpResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Device/Dump", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) ListSubscriptions(ctx context.Context, in *ListSubscriptionsRequest, opts ...grpc.CallOption) (*ListSubscriptionsResponse, error) {
+	out := new(ListSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/gnxi.admin.Device/ListSubscriptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeviceServer is the server API for Device service.
+// All implementations must embed UnimplementedDeviceServer
+// for forward compatibility
+type DeviceServer interface {
+	// WriteState writes leaves of the tree of a device, typically state
+	// leaves, checking their values against the schema but bypassing the
+	// validation of the tree, and notifies their subscribers.
+	WriteState(context.Context, *WriteStateRequest) (*WriteStateResponse, error)
+	// Reboot reboots a device, as gNOI System Reboot does.
+	Reboot(context.Context, *RebootRequest) (*RebootResponse, error)
+	// Load replaces the whole tree of a device.
+	Load(context.Context, *LoadRequest) (*LoadResponse, error)
+	// Dump returns the whole tree of a device.
+	Dump(context.Context, *DumpRequest) (*DumpResponse, error)
+	// ListSubscriptions returns the active Subscribe streams of a device.
+	ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*ListSubscriptionsResponse, error)
+	mustEmbedUnimplementedDeviceServer()
+}
+
+// UnimplementedDeviceServer must be embedded to have forward compatible implementations.
+type UnimplementedDeviceServer struct {
+}
+
+func (UnimplementedDeviceServer) WriteState(context.Context, *WriteStateRequest) (*WriteStateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WriteState not implemented")
+}
+func (UnimplementedDeviceServer) Reboot(context.Context, *RebootRequest) (*RebootResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reboot not implemented")
+}
+func (UnimplementedDeviceServer) Load(context.Context, *LoadRequest) (*LoadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Load not implemented")
+}
+func (UnimplementedDeviceServer) Dump(context.Context, *DumpRequest) (*DumpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Dump not implemented")
+}
+func (UnimplementedDeviceServer) ListSubscriptions(context.Context, *ListSubscriptionsRequest) (*ListSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubscriptions not implemented")
+}
+func (UnimplementedDeviceServer) mustEmbedUnimplementedDeviceServer() {}
+
+// UnsafeDeviceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeviceServer will
+// result in compilation errors.
+type UnsafeDeviceServer interface {
+	mustEmbedUnimplementedDeviceServer()
+}
+
+func RegisterDeviceServer(s grpc.ServiceRegistrar, srv DeviceServer) {
+	s.RegisterService(&Device_ServiceDesc, srv)
+}
+
+func _Device_WriteState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).WriteState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Device/WriteState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).WriteState(ctx, req.(*WriteStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_Reboot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).Reboot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Device/Reboot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).Reboot(ctx, req.(*RebootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_Load_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).Load(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Device/Load",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).Load(ctx, req.(*LoadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_Dump_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DumpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).Dump(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Device/Dump",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).Dump(ctx, req.(*DumpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_ListSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).ListSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gnxi.admin.Device/ListSubscriptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).ListSubscriptions(ctx, req.(*ListSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Device_ServiceDesc is the grpc.ServiceDesc for Device service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Device_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gnxi.admin.Device",
+	HandlerType: (*DeviceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "WriteState",
+			Handler:    _Device_WriteState_Handler,
+		},
+		{
+			MethodName: "Reboot",
+			Handler:    _Device_Reboot_Handler,
+		},
+		{
+			MethodName: "Load",
+			Handler:    _Device_Load_Handler,
+		},
+		{
+			MethodName: "Dump",
+			Handler:    _Device_Dump_Handler,
+		},
+		{
+			MethodName: "ListSubscriptions",
+			Handler:    _Device_ListSubscriptions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/admin/admin.proto",
+}
@@ -0,0 +1,475 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/fault"
+	gnmiserver "github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/gnoi/system"
+	"github.com/onosproject/gnxi-simulators/pkg/link"
+)
+
+func newModel() *gnmiserver.Model {
+	return gnmiserver.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+}
+
+// dial serves the services registered by register over a loopback gRPC
+// connection.
+func dial(t *testing.T, register func(*grpc.Server)) (*grpc.ClientConn, func()) {
+	g := grpc.NewServer()
+	register(g)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	return conn, func() {
+		conn.Close()
+		g.Stop()
+	}
+}
+
+func mustPath(t *testing.T, path string) *gnmi.Path {
+	p, err := ygot.StringToStructuredPath(path)
+	if err != nil {
+		t.Fatalf("error in parsing path %s: %v", path, err)
+	}
+	return p
+}
+
+func TestSchema(t *testing.T) {
+	conn, stop := dial(t, func(g *grpc.Server) { RegisterSchemaServer(g, NewSchema(newModel())) })
+	defer stop()
+	ctx := context.Background()
+
+	describe := func(path string) (*SchemaNode, error) {
+		resp := new(DescribeResponse)
+		err := conn.Invoke(ctx, "/gnxi.admin.Schema/Describe", &SchemaRequest{Path: mustPath(t, path)}, resp)
+		return resp.GetNode(), err
+	}
+	children := func(path string) ([]*SchemaNode, error) {
+		resp := new(ChildrenResponse)
+		err := conn.Invoke(ctx, "/gnxi.admin.Schema/Children", &SchemaRequest{Path: mustPath(t, path)}, resp)
+		return resp.GetChildren(), err
+	}
+
+	tests := []struct {
+		desc string
+		path string
+		want *SchemaNode
+	}{{
+		desc: "list",
+		path: "/interfaces/interface[name=eth0]",
+		want: &SchemaNode{Name: "interface", Prefix: "oc-if", Path: "/interfaces/interface", Kind: SchemaNode_LIST, Config: true, Keys: []string{"name"}},
+	}, {
+		desc: "config leaf",
+		path: "/interfaces/interface/config/mtu",
+		want: &SchemaNode{Name: "mtu", Prefix: "oc-if", Path: "/interfaces/interface/config/mtu", Kind: SchemaNode_LEAF, Config: true, Type: "uint16", BaseType: "uint16"},
+	}, {
+		desc: "state enumeration",
+		path: "/interfaces/interface/state/admin-status",
+		want: &SchemaNode{Name: "admin-status", Prefix: "oc-if", Path: "/interfaces/interface/state/admin-status", Kind: SchemaNode_LEAF, Type: "enumeration", BaseType: "enumeration",
+			EnumValues: []string{"UP", "DOWN", "TESTING"}},
+	}, {
+		desc: "identityref",
+		path: "/system/aaa/accounting/events/event/config/event-type",
+		want: &SchemaNode{Name: "event-type", Prefix: "oc-aaa", Path: "/system/aaa/accounting/events/event/config/event-type", Kind: SchemaNode_LEAF, Config: true, Type: "identityref", BaseType: "identityref",
+			EnumValues: []string{"AAA_ACCOUNTING_EVENT_COMMAND", "AAA_ACCOUNTING_EVENT_LOGIN"}},
+	}}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := describe(test.path)
+			if err != nil {
+				t.Fatalf("error in describing %s: %v", test.path, err)
+			}
+			got.Description = ""
+			if !proto.Equal(got, test.want) {
+				t.Errorf("describing %s got %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+
+	nodes, err := children("/")
+	if err != nil {
+		t.Fatalf("error in listing the root: %v", err)
+	}
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	if want := []string{"components", "interfaces", "messages", "system"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("root children are %v, want %v", names, want)
+	}
+
+	if _, err := describe("/interfaces/unknown"); status.Code(err) != codes.NotFound {
+		t.Errorf("describing an unknown path got %v, want NotFound", err)
+	}
+	if _, err := children("/interfaces/interface/config/mtu"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("listing the children of a leaf got %v, want InvalidArgument", err)
+	}
+}
+
+func TestSchemaOfYANGModel(t *testing.T) {
+	model, err := gnmiserver.NewSchemaModel("../gnmi/testdata/yang")
+	if err != nil {
+		t.Fatalf("error in loading the YANG models: %v", err)
+	}
+	s := NewSchema(model)
+	ctx := context.Background()
+
+	resp, err := s.Children(ctx, &SchemaRequest{Path: mustPath(t, "/switch/ports/port/config")})
+	if err != nil {
+		t.Fatalf("error in listing children: %v", err)
+	}
+	nodes := make(map[string]*SchemaNode)
+	var names []string
+	for _, node := range resp.GetChildren() {
+		nodes[node.Name] = node
+		names = append(names, node.Name)
+	}
+	if want := []string{"description", "enabled", "lldp", "mode", "name", "speed", "vlans"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("children are %v, want %v", names, want)
+	}
+	if got, want := nodes["speed"].GetEnumValues(), []string{"SPEED_10GB", "SPEED_1GB"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("speed values are %v, want %v", got, want)
+	}
+	if got, want := nodes["mode"].GetEnumValues(), []string{"ACCESS", "TRUNK"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mode values are %v, want %v", got, want)
+	}
+	if got := nodes["vlans"]; got.GetKind() != SchemaNode_LEAF_LIST || got.GetType() != "vlan-id" || got.GetBaseType() != "uint16" {
+		t.Errorf("vlans is %v, want a leaf-list of vlan-id", got)
+	}
+	if got := nodes["lldp"]; got.GetPrefix() != "ex-lldp" || !got.GetConfig() {
+		t.Errorf("lldp is %v, want a config leaf of ex-lldp", got)
+	}
+
+	state, err := s.Describe(ctx, &SchemaRequest{Path: mustPath(t, "/switch/ports/port/state/in-octets")})
+	if err != nil {
+		t.Fatalf("error in describing: %v", err)
+	}
+	if node := state.GetNode(); node.GetConfig() || node.GetBaseType() != "uint64" {
+		t.Errorf("in-octets is %v, want a uint64 state leaf", node)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	config := `{"openconfig-interfaces:interfaces": {"interface": [
+		{"name": "eth1", "config": {"name": "eth1"}},
+		{"name": "eth2", "config": {"name": "eth2"}}
+	]}}`
+	target, err := gnmiserver.NewServer(newModel(), []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	simulators := map[string]*link.Simulator{"switch1": link.NewSimulator(target)}
+	conn, stop := dial(t, func(g *grpc.Server) { RegisterLinksServer(g, NewLinks(simulators)) })
+	defer stop()
+	ctx := context.Background()
+
+	list := new(ListLinksResponse)
+	if err := conn.Invoke(ctx, "/gnxi.admin.Links/List", &ListLinksRequest{}, list); err != nil {
+		t.Fatalf("error in listing the links: %v", err)
+	}
+	want := &ListLinksResponse{Links: []*Link{{Interface: "eth1", Carrier: true, Up: true}, {Interface: "eth2", Carrier: true, Up: true}}}
+	if !proto.Equal(list, want) {
+		t.Errorf("links are %v, want %v", list.GetLinks(), want.GetLinks())
+	}
+
+	resp := new(LinkResponse)
+	if err := conn.Invoke(ctx, "/gnxi.admin.Links/SetCarrier", &SetCarrierRequest{Target: "switch1", Interface: "eth2"}, resp); err != nil {
+		t.Fatalf("error in bringing the carrier down: %v", err)
+	}
+	if want := (&Link{Interface: "eth2", CarrierTransitions: 1}); !proto.Equal(resp.GetLink(), want) {
+		t.Errorf("link is %v, want %v", resp.GetLink(), want)
+	}
+
+	if err := conn.Invoke(ctx, "/gnxi.admin.Links/Flap", &FlapRequest{Interface: "eth1"}, resp); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("flapping without duration got %v, want InvalidArgument", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Links/Flap", &FlapRequest{Interface: "eth3", Duration: 1}, resp); status.Code(err) != codes.NotFound {
+		t.Errorf("flapping an unknown interface got %v, want NotFound", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Links/List", &ListLinksRequest{Target: "switch2"}, list); status.Code(err) != codes.NotFound {
+		t.Errorf("listing the links of an unknown target got %v, want NotFound", err)
+	}
+}
+
+func TestAlarms(t *testing.T) {
+	target, err := gnmiserver.NewServer(newModel(), nil, nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	conn, stop := dial(t, func(g *grpc.Server) {
+		RegisterAlarmsServer(g, NewAlarms(map[string]*gnmiserver.Server{"switch1": target}))
+	})
+	defer stop()
+	ctx := context.Background()
+
+	alarm := &Alarm{Id: "psu", Resource: "PSU1", Severity: "CRITICAL", TypeId: "EQPT", Text: "power supply failure", TimeCreated: 1600000000000000000}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Alarms/Raise", &RaiseAlarmRequest{Alarm: alarm}, new(RaiseAlarmResponse)); err != nil {
+		t.Fatalf("error in raising an alarm: %v", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Alarms/Raise", &RaiseAlarmRequest{Alarm: &Alarm{Id: "psu", Severity: "BAD"}}, new(RaiseAlarmResponse)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("raising an alarm of unknown severity got %v, want InvalidArgument", err)
+	}
+	list := new(ListAlarmsResponse)
+	if err := conn.Invoke(ctx, "/gnxi.admin.Alarms/List", &ListAlarmsRequest{Target: "switch1"}, list); err != nil {
+		t.Fatalf("error in listing the alarms: %v", err)
+	}
+	if got := list.GetAlarms(); len(got) != 1 || !proto.Equal(got[0], alarm) {
+		t.Errorf("alarms are %v, want %v", got, alarm)
+	}
+
+	if err := conn.Invoke(ctx, "/gnxi.admin.Alarms/Clear", &ClearAlarmRequest{Id: "psu"}, new(ClearAlarmResponse)); err != nil {
+		t.Fatalf("error in clearing the alarm: %v", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Alarms/Clear", &ClearAlarmRequest{Id: "psu"}, new(ClearAlarmResponse)); status.Code(err) != codes.NotFound {
+		t.Errorf("clearing a cleared alarm got %v, want NotFound", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Alarms/List", &ListAlarmsRequest{}, list); err != nil || len(list.GetAlarms()) != 0 {
+		t.Errorf("alarms after clearing are %v, %v, want none", list.GetAlarms(), err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	target, err := gnmiserver.NewServer(newModel(), nil, nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	injector := fault.NewInjector(target)
+	conn, stop := dial(t, func(g *grpc.Server) {
+		RegisterFaultsServer(g, NewFaults(map[string]*fault.Injector{"switch1": injector}))
+	})
+	defer stop()
+	ctx := context.Background()
+
+	rule := &FaultRule{Rpc: "Subscribe", Path: "/interfaces", Delay: 1000000, Code: "RESOURCE_EXHAUSTED", DisconnectAfter: 3, DropProbability: 0.5}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Faults/Set", &SetFaultsRequest{Rules: []*FaultRule{rule}}, new(SetFaultsResponse)); err != nil {
+		t.Fatalf("error in setting the faults: %v", err)
+	}
+	want := fault.Rule{RPC: fault.Subscribe, Path: "/interfaces", Delay: time.Millisecond, Code: codes.ResourceExhausted, DisconnectAfter: 3, DropProbability: 0.5}
+	if got := injector.Rules(); len(got) != 1 || got[0] != want {
+		t.Errorf("rules are %+v, want %+v", got, want)
+	}
+	list := new(ListFaultsResponse)
+	if err := conn.Invoke(ctx, "/gnxi.admin.Faults/List", &ListFaultsRequest{Target: "switch1"}, list); err != nil {
+		t.Fatalf("error in listing the faults: %v", err)
+	}
+	if got := list.GetRules(); len(got) != 1 || !proto.Equal(got[0], rule) {
+		t.Errorf("listed rules are %v, want %v", got, rule)
+	}
+
+	for _, r := range []*FaultRule{{Code: "BROKEN"}, {Rpc: "Walk"}, {ErrorProbability: 2}} {
+		if err := conn.Invoke(ctx, "/gnxi.admin.Faults/Set", &SetFaultsRequest{Rules: []*FaultRule{r}}, new(SetFaultsResponse)); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("setting rule %v got %v, want InvalidArgument", r, err)
+		}
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Faults/Set", &SetFaultsRequest{Target: "switch1"}, new(SetFaultsResponse)); err != nil || len(injector.Rules()) != 0 {
+		t.Errorf("rules after clearing are %v, %v, want none", injector.Rules(), err)
+	}
+}
+
+func TestDevice(t *testing.T) {
+	config := `{"openconfig-interfaces:interfaces": {"interface": [{"name": "eth1", "config": {"name": "eth1"}}]}}`
+	target, err := gnmiserver.NewServer(newModel(), []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	sys, err := system.NewServer(target, []byte(config), 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error in creating the system service: %v", err)
+	}
+	conn, stop := dial(t, func(g *grpc.Server) {
+		gnmi.RegisterGNMIServer(g, target)
+		RegisterDeviceServer(g, NewDevice(map[string]DeviceTarget{"switch1": {Server: target, System: sys}}))
+	})
+	defer stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	operStatus := mustPath(t, "/interfaces/interface[name=eth1]/state/oper-status")
+	stream, err := gnmi.NewGNMIClient(conn).Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("error in subscribing: %v", err)
+	}
+	list := &gnmi.SubscriptionList{Subscription: []*gnmi.Subscription{{Path: operStatus, Mode: gnmi.SubscriptionMode_ON_CHANGE}}}
+	if err := stream.Send(&gnmi.SubscribeRequest{Request: &gnmi.SubscribeRequest_Subscribe{Subscribe: list}}); err != nil {
+		t.Fatalf("error in sending the subscription: %v", err)
+	}
+	subscriptions := new(ListSubscriptionsResponse)
+	for start := time.Now(); len(subscriptions.GetSubscriptions()) == 0 || subscriptions.GetSubscriptions()[0].GetRequest() == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("subscriptions are %v, want the subscription to oper-status", subscriptions.GetSubscriptions())
+		}
+		if err := conn.Invoke(ctx, "/gnxi.admin.Device/ListSubscriptions", &ListSubscriptionsRequest{}, subscriptions); err != nil {
+			t.Fatalf("error in listing the subscriptions: %v", err)
+		}
+	}
+	if sub := subscriptions.GetSubscriptions()[0]; sub.GetPeer() == "" || !proto.Equal(sub.GetRequest(), list) {
+		t.Errorf("subscription is %v, want one from a peer with request %v", sub, list)
+	}
+
+	// State leaves are written, and notified to their subscribers.
+	write := &WriteStateRequest{
+		Prefix: mustPath(t, "/interfaces/interface[name=eth1]/state"),
+		Update: []*gnmi.Update{
+			{Path: mustPath(t, "/oper-status"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "DOWN"}}},
+			{Path: mustPath(t, "/counters/in-octets"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_IntVal{IntVal: 1500}}},
+		},
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/WriteState", write, new(WriteStateResponse)); err != nil {
+		t.Fatalf("error in writing the state: %v", err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("error in receiving the notifications: %v", err)
+		}
+		if u := resp.GetUpdate().GetUpdate(); len(u) > 0 {
+			if got := u[0].GetVal().GetStringVal(); got != "DOWN" {
+				t.Errorf("notified oper-status is %q, want DOWN", got)
+			}
+			break
+		}
+	}
+	dump := new(DumpResponse)
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Dump", &DumpRequest{Target: "switch1"}, dump); err != nil {
+		t.Fatalf("error in dumping the tree: %v", err)
+	}
+	for _, want := range []string{`"oper-status": "DOWN"`, `"in-octets": "1500"`} {
+		if !strings.Contains(dump.GetConfig(), want) {
+			t.Errorf("dumped tree %s does not contain %s", dump.GetConfig(), want)
+		}
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Load", &LoadRequest{Config: dump.GetConfig()}, new(LoadResponse)); err != nil {
+		t.Errorf("error in loading the dumped tree: %v", err)
+	}
+
+	// The tree is dumped when the writes make it invalid.
+	write = &WriteStateRequest{Update: []*gnmi.Update{
+		{Path: mustPath(t, "/interfaces/interface[name=eth2]/state/counters/in-octets"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 1}}},
+	}}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/WriteState", write, new(WriteStateResponse)); err != nil {
+		t.Fatalf("error in writing the state: %v", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Dump", &DumpRequest{}, dump); err != nil || !strings.Contains(dump.GetConfig(), `"name": "eth2"`) {
+		t.Errorf("tree after writing eth2 is %s, %v, want eth2", dump.GetConfig(), err)
+	}
+	for _, u := range []*gnmi.Update{
+		{Path: mustPath(t, "/interfaces/interface[name=eth1]/state/unknown"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "x"}}},
+		{Path: mustPath(t, "/interfaces/interface[name=eth1]/state/oper-status"), Val: &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: "BROKEN"}}},
+	} {
+		if err := conn.Invoke(ctx, "/gnxi.admin.Device/WriteState", &WriteStateRequest{Update: []*gnmi.Update{u}}, new(WriteStateResponse)); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("writing %v got %v, want InvalidArgument", u, err)
+		}
+	}
+
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Load", &LoadRequest{Config: `{"openconfig-system:system": {"config": {"hostname": "loaded"}}}`}, new(LoadResponse)); err != nil {
+		t.Fatalf("error in loading a tree: %v", err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Dump", &DumpRequest{}, dump); err != nil || !strings.Contains(dump.GetConfig(), `"hostname": "loaded"`) || strings.Contains(dump.GetConfig(), "eth1") {
+		t.Errorf("tree after loading is %s, %v, want the loaded tree", dump.GetConfig(), err)
+	}
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Load", &LoadRequest{Config: `{"unknown": 1}`}, new(LoadResponse)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("loading an invalid tree got %v, want InvalidArgument", err)
+	}
+
+	if err := conn.Invoke(ctx, "/gnxi.admin.Device/Reboot", &RebootRequest{Message: "test"}, new(RebootResponse)); err != nil {
+		t.Fatalf("error in rebooting: %v", err)
+	}
+	for start := time.Now(); !strings.Contains(dump.GetConfig(), "eth1"); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("tree after the reboot is %s, want the startup config", dump.GetConfig())
+		}
+		if err := conn.Invoke(ctx, "/gnxi.admin.Device/Dump", &DumpRequest{}, dump); err != nil {
+			t.Fatalf("error in dumping the tree: %v", err)
+		}
+	}
+}
+
+func TestGateway(t *testing.T) {
+	target, err := gnmiserver.NewServer(newModel(), nil, nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	gateway := NewGateway()
+	RegisterDeviceServer(gateway, NewDevice(map[string]DeviceTarget{"switch1": {Server: target}}))
+	RegisterFaultsServer(gateway, NewFaults(map[string]*fault.Injector{"switch1": fault.NewInjector(target)}))
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	post := func(path, body string) (int, string) {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("error in posting to %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("error in reading the response of %s: %v", path, err)
+		}
+		return resp.StatusCode, string(b)
+	}
+
+	code, body := post("/gnxi.admin.Device/WriteState", `{"update": [{
+		"path": {"elem": [{"name": "system"}, {"name": "state"}, {"name": "hostname"}]},
+		"val": {"string_val": "written"}
+	}]}`)
+	if code != http.StatusOK {
+		t.Fatalf("writing the state got %d %s, want 200", code, body)
+	}
+	if code, body = post("/gnxi.admin.Device/Dump", ""); code != http.StatusOK || !strings.Contains(body, `\"hostname\": \"written\"`) {
+		t.Errorf("dump got %d %s, want 200 with the written hostname", code, body)
+	}
+	if code, body = post("/gnxi.admin.Faults/Set", `{"rules": [{"rpc": "Get", "error_probability": 0.5, "code": "BROKEN"}]}`); code != http.StatusBadRequest || !strings.Contains(body, "BROKEN") {
+		t.Errorf("setting an invalid fault got %d %s, want 400", code, body)
+	}
+	if code, body = post("/gnxi.admin.Faults/List", `{"target": "switch2"}`); code != http.StatusNotFound {
+		t.Errorf("listing the faults of an unknown target got %d %s, want 404", code, body)
+	}
+	if code, body = post("/gnxi.admin.Faults/List", `{"unknown": 1}`); code != http.StatusBadRequest {
+		t.Errorf("posting an invalid request got %d %s, want 400", code, body)
+	}
+	if code, body = post("/gnxi.admin.Links/List", `{}`); code != http.StatusNotFound {
+		t.Errorf("posting to an unregistered service got %d %s, want 404", code, body)
+	}
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("error in listing the RPCs: %v", err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if methods := strings.Fields(string(b)); len(methods) != 7 || methods[0] != "/gnxi.admin.Device/Dump" {
+		t.Errorf("RPCs are %v, want the 7 RPCs of Device and Faults", methods)
+	}
+}
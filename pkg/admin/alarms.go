@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+)
+
+// Alarms implements the gnxi.admin.Alarms service over the gNMI servers of
+// the devices served on a port.
+type Alarms struct {
+	UnimplementedAlarmsServer
+
+	targets map[string]*gnmi.Server
+}
+
+// NewAlarms returns the Alarms service of the gNMI servers of the devices
+// served on a port, by device name.
+func NewAlarms(targets map[string]*gnmi.Server) *Alarms {
+	return &Alarms{targets: targets}
+}
+
+// List returns the alarms of the target of the request.
+func (a *Alarms) List(ctx context.Context, req *ListAlarmsRequest) (*ListAlarmsResponse, error) {
+	target, err := a.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	alarms, err := target.Alarms()
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListAlarmsResponse{Alarms: make([]*Alarm, len(alarms))}
+	for i, alarm := range alarms {
+		resp.Alarms[i] = &Alarm{
+			Id:          alarm.ID,
+			Resource:    alarm.Resource,
+			Severity:    alarm.Severity,
+			TypeId:      alarm.TypeID,
+			Text:        alarm.Text,
+			TimeCreated: uint64(alarm.TimeCreated.UnixNano()),
+		}
+	}
+	return resp, nil
+}
+
+// Raise raises the alarm of the request.
+func (a *Alarms) Raise(ctx context.Context, req *RaiseAlarmRequest) (*RaiseAlarmResponse, error) {
+	target, err := a.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetAlarm() == nil {
+		return nil, status.Error(codes.InvalidArgument, "no alarm to raise")
+	}
+	alarm := gnmi.Alarm{
+		ID:       req.GetAlarm().GetId(),
+		Resource: req.GetAlarm().GetResource(),
+		Severity: req.GetAlarm().GetSeverity(),
+		TypeID:   req.GetAlarm().GetTypeId(),
+		Text:     req.GetAlarm().GetText(),
+	}
+	if created := req.GetAlarm().GetTimeCreated(); created != 0 {
+		alarm.TimeCreated = time.Unix(0, int64(created))
+	}
+	if err := target.RaiseAlarm(alarm); err != nil {
+		return nil, err
+	}
+	return &RaiseAlarmResponse{}, nil
+}
+
+// Clear clears the alarm of the request.
+func (a *Alarms) Clear(ctx context.Context, req *ClearAlarmRequest) (*ClearAlarmResponse, error) {
+	target, err := a.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	if err := target.ClearAlarm(req.GetId()); err != nil {
+		return nil, err
+	}
+	return &ClearAlarmResponse{}, nil
+}
+
+// target returns the gNMI server of device target, which may be empty when
+// the port serves a single device.
+func (a *Alarms) target(target string) (*gnmi.Server, error) {
+	devices := make(map[string]bool)
+	for name := range a.targets {
+		devices[name] = true
+	}
+	name, err := deviceName(target, devices)
+	if err != nil {
+		return nil, err
+	}
+	return a.targets[name], nil
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"encoding/json"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	spb "github.com/openconfig/gnoi/system"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnoi/system"
+)
+
+// DeviceTarget is a device driven by the Device service: its gNMI server and
+// its gNOI system service, which reboots it.
+type DeviceTarget struct {
+	Server *gnmi.Server
+	System *system.Server
+}
+
+// Device implements the gnxi.admin.Device service over the devices served on
+// a port.
+type Device struct {
+	UnimplementedDeviceServer
+
+	targets map[string]DeviceTarget
+}
+
+// NewDevice returns the Device service of the devices served on a port, by
+// device name.
+func NewDevice(targets map[string]DeviceTarget) *Device {
+	return &Device{targets: targets}
+}
+
+// WriteState deletes the nodes of the request, then writes the values of its
+// leaves, creating their ancestors when missing. The values are checked
+// against the schema, but the tree is not validated, so that state leaves and
+// values a Set would reject are written as the hardware would. The
+// subscribers of the paths or of their prefixes are notified.
+func (d *Device) WriteState(ctx context.Context, req *WriteStateRequest) (*WriteStateResponse, error) {
+	target, err := d.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	schema := target.Server.Model().SchemaRoot()
+	var written []*pb.Path
+	err = target.Server.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		if _, ok := config.(*gnmi.Tree); ok {
+			return status.Error(codes.Unimplemented, "writing the state of a model loaded at runtime is not supported")
+		}
+		for _, path := range req.GetDelete() {
+			path = fullPath(req.GetPrefix(), path)
+			if err := ytypes.DeleteNode(schema, config, path); err != nil {
+				return status.Errorf(codes.InvalidArgument, "error in deleting %v: %v", path, err)
+			}
+			written = append(written, path)
+		}
+		for _, u := range req.GetUpdate() {
+			path := fullPath(req.GetPrefix(), u.GetPath())
+			if err := ytypes.SetNode(schema, config, path, u.GetVal(), &ytypes.InitMissingElements{}, &ytypes.TolerateJSONInconsistencies{}); err != nil {
+				return status.Errorf(codes.InvalidArgument, "error in writing %v: %v", path, err)
+			}
+			written = append(written, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range written {
+		target.Server.NotifyUpdate(path)
+	}
+	return &WriteStateResponse{}, nil
+}
+
+// Reboot schedules a cold reboot of the target of the request.
+func (d *Device) Reboot(ctx context.Context, req *RebootRequest) (*RebootResponse, error) {
+	target, err := d.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	if target.System == nil {
+		return nil, status.Error(codes.Unimplemented, "the target cannot reboot")
+	}
+	if err := target.System.ScheduleReboot(spb.RebootMethod_COLD, time.Duration(req.GetDelay()), req.GetMessage()); err != nil {
+		return nil, err
+	}
+	return &RebootResponse{}, nil
+}
+
+// Load replaces the whole tree of the target of the request, as when it
+// boots from a startup configuration.
+func (d *Device) Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error) {
+	target, err := d.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	if err := target.Server.Reload([]byte(req.GetConfig())); err != nil {
+		if _, ok := status.FromError(err); !ok {
+			err = status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+		}
+		return nil, err
+	}
+	return &LoadResponse{}, nil
+}
+
+// Dump returns the whole tree of the target of the request, which need not be
+// valid after WriteState.
+func (d *Device) Dump(ctx context.Context, req *DumpRequest) (*DumpResponse, error) {
+	target, err := d.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	var config string
+	err = target.Server.InternalUpdate(func(root ygot.ValidatedGoStruct) error {
+		if tree, ok := root.(*gnmi.Tree); ok {
+			b, err := json.MarshalIndent(tree.JSON(), "", "  ")
+			config = string(b)
+			return err
+		}
+		var err error
+		config, err = ygot.EmitJSON(root, &ygot.EmitJSONConfig{
+			Format:         ygot.RFC7951,
+			RFC7951Config:  &ygot.RFC7951JSONConfig{AppendModuleName: true},
+			Indent:         "  ",
+			SkipValidation: true,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error in dumping the tree: %v", err)
+	}
+	return &DumpResponse{Config: config}, nil
+}
+
+// ListSubscriptions returns the active Subscribe streams of the target of the
+// request.
+func (d *Device) ListSubscriptions(ctx context.Context, req *ListSubscriptionsRequest) (*ListSubscriptionsResponse, error) {
+	target, err := d.target(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := target.Server.Subscriptions()
+	resp := &ListSubscriptionsResponse{Subscriptions: make([]*Subscription, len(subscriptions))}
+	for i, sub := range subscriptions {
+		resp.Subscriptions[i] = &Subscription{
+			Id:      sub.ID,
+			Peer:    sub.Peer,
+			User:    sub.User,
+			Started: uint64(sub.Started.UnixNano()),
+			Request: sub.Request,
+		}
+	}
+	return resp, nil
+}
+
+// target returns device target, which may be empty when the port serves a
+// single device.
+func (d *Device) target(target string) (DeviceTarget, error) {
+	devices := make(map[string]bool)
+	for name := range d.targets {
+		devices[name] = true
+	}
+	name, err := deviceName(target, devices)
+	if err != nil {
+		return DeviceTarget{}, err
+	}
+	return d.targets[name], nil
+}
+
+// fullPath returns path under prefix.
+func fullPath(prefix, path *pb.Path) *pb.Path {
+	return &pb.Path{Elem: append(append([]*pb.PathElem(nil), prefix.GetElem()...), path.GetElem()...)}
+}
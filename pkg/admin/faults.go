@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/fault"
+)
+
+// codeNames are the names of the gRPC codes, by code.
+var codeNames = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// Faults implements the gnxi.admin.Faults service over the fault injectors of
+// the devices served on a port.
+type Faults struct {
+	UnimplementedFaultsServer
+
+	injectors map[string]*fault.Injector
+}
+
+// NewFaults returns the Faults service of the fault injectors of the devices
+// served on a port, by device name.
+func NewFaults(injectors map[string]*fault.Injector) *Faults {
+	return &Faults{injectors: injectors}
+}
+
+// List returns the fault rules of the target of the request.
+func (f *Faults) List(ctx context.Context, req *ListFaultsRequest) (*ListFaultsResponse, error) {
+	injector, err := f.injector(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	rules := injector.Rules()
+	resp := &ListFaultsResponse{Rules: make([]*FaultRule, len(rules))}
+	for i, r := range rules {
+		resp.Rules[i] = &FaultRule{
+			Rpc:                r.RPC,
+			Path:               r.Path,
+			Delay:              int64(r.Delay),
+			ErrorProbability:   r.ErrorProbability,
+			DisconnectAfter:    uint32(r.DisconnectAfter),
+			DropProbability:    r.DropProbability,
+			ReorderProbability: r.ReorderProbability,
+			Truncate:           r.Truncate,
+			MaxUpdates:         uint32(r.MaxUpdates),
+		}
+		if r.Code != codes.OK && int(r.Code) < len(codeNames) {
+			resp.Rules[i].Code = codeNames[r.Code]
+		}
+	}
+	return resp, nil
+}
+
+// Set replaces the fault rules of the target of the request.
+func (f *Faults) Set(ctx context.Context, req *SetFaultsRequest) (*SetFaultsResponse, error) {
+	injector, err := f.injector(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]fault.Rule, len(req.GetRules()))
+	for i, r := range req.GetRules() {
+		code, err := codeOf(r.GetCode())
+		if err != nil {
+			return nil, err
+		}
+		rules[i] = fault.Rule{
+			RPC:                r.GetRpc(),
+			Path:               r.GetPath(),
+			Delay:              time.Duration(r.GetDelay()),
+			ErrorProbability:   r.GetErrorProbability(),
+			Code:               code,
+			DisconnectAfter:    int(r.GetDisconnectAfter()),
+			DropProbability:    r.GetDropProbability(),
+			ReorderProbability: r.GetReorderProbability(),
+			Truncate:           r.GetTruncate(),
+			MaxUpdates:         int(r.GetMaxUpdates()),
+		}
+	}
+	if err := injector.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return &SetFaultsResponse{}, nil
+}
+
+// injector returns the fault injector of device target, which may be empty
+// when the port serves a single device.
+func (f *Faults) injector(target string) (*fault.Injector, error) {
+	devices := make(map[string]bool)
+	for name := range f.injectors {
+		devices[name] = true
+	}
+	name, err := deviceName(target, devices)
+	if err != nil {
+		return nil, err
+	}
+	return f.injectors[name], nil
+}
+
+// codeOf returns the gRPC code named name, OK when empty.
+func codeOf(name string) (codes.Code, error) {
+	if name == "" {
+		return codes.OK, nil
+	}
+	for code, n := range codeNames {
+		if n == name {
+			return codes.Code(code), nil
+		}
+	}
+	return codes.OK, status.Errorf(codes.InvalidArgument, "unknown code %q", name)
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var log = logging.GetLogger("admin")
+
+// maxGatewayBody is the size in bytes of the largest request body accepted
+// by the gateway.
+const maxGatewayBody = 16 << 20
+
+// httpStatus maps the gRPC codes to HTTP status codes.
+var httpStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           http.StatusRequestTimeout,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusPreconditionFailed,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// Gateway serves the unary RPCs of the admin services registered on it as
+// JSON over HTTP: the request message is POSTed in the protobuf JSON mapping
+// to the path of the RPC, e.g. /gnxi.admin.Links/Flap, and the response
+// message is returned in the same mapping. A failed RPC returns the HTTP
+// status of its code, with its code and message as a JSON object.
+type Gateway struct {
+	mu      sync.RWMutex
+	methods map[string]gatewayMethod // by path
+}
+
+// gatewayMethod is a unary RPC of a service registered on a gateway.
+type gatewayMethod struct {
+	desc grpc.MethodDesc
+	srv  interface{}
+}
+
+// NewGateway returns a gateway without services.
+func NewGateway() *Gateway {
+	return &Gateway{methods: make(map[string]gatewayMethod)}
+}
+
+// RegisterService registers the unary RPCs of service desc implemented by
+// srv, so that the Register functions of the services register them on the
+// gateway as on a gRPC server.
+func (g *Gateway) RegisterService(desc *grpc.ServiceDesc, srv interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, m := range desc.Methods {
+		g.methods["/"+desc.ServiceName+"/"+m.MethodName] = gatewayMethod{desc: m, srv: srv}
+	}
+}
+
+// Methods returns the paths of the RPCs served by the gateway, sorted.
+func (g *Gateway) Methods() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	paths := make([]string, 0, len(g.methods))
+	for path := range g.methods {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ServeHTTP serves an RPC.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == "/" {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintln(w, strings.Join(g.Methods(), "\n"))
+		return
+	}
+	g.mu.RLock()
+	m, ok := g.methods[r.URL.Path]
+	g.mu.RUnlock()
+	if !ok {
+		writeError(w, status.Errorf(codes.NotFound, "unknown RPC %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "the RPCs are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxGatewayBody))
+	if err != nil {
+		writeError(w, status.Errorf(codes.InvalidArgument, "error in reading the request: %v", err))
+		return
+	}
+	dec := func(in interface{}) error {
+		if len(bytes.TrimSpace(body)) == 0 {
+			return nil
+		}
+		if err := jsonpb.Unmarshal(bytes.NewReader(body), in.(proto.Message)); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		return nil
+	}
+	resp, err := m.desc.Handler(m.srv, r.Context(), dec, nil)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	marshaler := jsonpb.Marshaler{OrigName: true, Indent: "  "}
+	if err := marshaler.Marshal(w, resp.(proto.Message)); err != nil {
+		log.Errorf("Error in writing the response of %s: %v", r.URL.Path, err)
+	}
+}
+
+// writeError writes error err of an RPC.
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	code, ok := httpStatus[st.Code()]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	marshaler := jsonpb.Marshaler{OrigName: true}
+	if err := marshaler.Marshal(w, st.Proto()); err != nil {
+		log.Errorf("Error in writing an error: %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/link"
+)
+
+// Links implements the gnxi.admin.Links service over the link simulators of
+// the devices served on a port.
+type Links struct {
+	UnimplementedLinksServer
+
+	simulators map[string]*link.Simulator
+}
+
+// NewLinks returns the Links service of the link simulators of the devices
+// served on a port, by device name.
+func NewLinks(simulators map[string]*link.Simulator) *Links {
+	return &Links{simulators: simulators}
+}
+
+// List returns the links of the interfaces of the target of the request.
+func (l *Links) List(ctx context.Context, req *ListLinksRequest) (*ListLinksResponse, error) {
+	sim, err := l.simulator(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	links, err := sim.Links()
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListLinksResponse{Links: make([]*Link, len(links))}
+	for i, st := range links {
+		resp.Links[i] = linkMessage(st)
+	}
+	return resp, nil
+}
+
+// SetCarrier brings the carrier of the link of the interface of the request
+// up or down.
+func (l *Links) SetCarrier(ctx context.Context, req *SetCarrierRequest) (*LinkResponse, error) {
+	sim, err := l.simulator(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	if err := sim.SetCarrier(req.GetInterface(), req.GetCarrier()); err != nil {
+		return nil, err
+	}
+	return linkResponse(sim, req.GetInterface())
+}
+
+// Flap brings the carrier of the link of the interface of the request down
+// for the duration of the request.
+func (l *Links) Flap(ctx context.Context, req *FlapRequest) (*LinkResponse, error) {
+	if req.GetDuration() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid flap duration %d", req.GetDuration())
+	}
+	sim, err := l.simulator(req.GetTarget())
+	if err != nil {
+		return nil, err
+	}
+	if err := sim.Flap(req.GetInterface(), time.Duration(req.GetDuration())); err != nil {
+		return nil, err
+	}
+	return linkResponse(sim, req.GetInterface())
+}
+
+// simulator returns the link simulator of device target, which may be empty
+// when the port serves a single device.
+func (l *Links) simulator(target string) (*link.Simulator, error) {
+	devices := make(map[string]bool)
+	for name := range l.simulators {
+		devices[name] = true
+	}
+	name, err := deviceName(target, devices)
+	if err != nil {
+		return nil, err
+	}
+	return l.simulators[name], nil
+}
+
+func linkResponse(sim *link.Simulator, name string) (*LinkResponse, error) {
+	st, err := sim.Link(name)
+	if err != nil {
+		return nil, err
+	}
+	return &LinkResponse{Link: linkMessage(st)}, nil
+}
+
+func linkMessage(st link.Status) *Link {
+	return &Link{
+		Interface:          st.Interface,
+		Carrier:            st.Carrier,
+		Up:                 st.Up,
+		CarrierTransitions: st.CarrierTransitions,
+	}
+}
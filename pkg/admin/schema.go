@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package admin implements the admin services of the simulator, which
+// gnmi_target serves next to gNMI. The services are defined in admin.proto.
+package admin
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Schema implements the gnxi.admin.Schema service over the schema of a model.
+type Schema struct {
+	UnimplementedSchemaServer
+
+	model *gnmi.Model
+}
+
+// NewSchema returns the Schema service of model.
+func NewSchema(model *gnmi.Model) *Schema {
+	return &Schema{model: model}
+}
+
+// Describe returns the schema node of the path of the request.
+func (s *Schema) Describe(ctx context.Context, req *SchemaRequest) (*DescribeResponse, error) {
+	entry, err := s.model.SchemaEntry(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.node(req.GetPath(), entry)
+	if err != nil {
+		return nil, err
+	}
+	return &DescribeResponse{Node: node}, nil
+}
+
+// Children returns the child nodes of the container or list of the path of
+// the request, sorted by name.
+func (s *Schema) Children(ctx context.Context, req *SchemaRequest) (*ChildrenResponse, error) {
+	entry, err := s.model.SchemaEntry(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDir() {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is a leaf, it has no children", schemaPath(req.GetPath()))
+	}
+	children := dataChildren(entry)
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	resp := &ChildrenResponse{Children: make([]*SchemaNode, len(children))}
+	for i, child := range children {
+		path := &pb.Path{Elem: append(append([]*pb.PathElem{}, req.GetPath().GetElem()...), &pb.PathElem{Name: child.Name})}
+		if resp.Children[i], err = s.node(path, child); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// node describes entry, the schema entry of path.
+func (s *Schema) node(path *pb.Path, entry *yang.Entry) (*SchemaNode, error) {
+	node := &SchemaNode{
+		Name:        entry.Name,
+		Path:        schemaPath(path),
+		Config:      !entry.ReadOnly(),
+		Description: entry.Description,
+		Default:     entry.Default,
+		Units:       entry.Units,
+	}
+	if entry.Prefix != nil {
+		node.Prefix = entry.Prefix.Name
+	}
+	switch {
+	case entry.IsList():
+		node.Kind = SchemaNode_LIST
+		node.Keys = strings.Fields(entry.Key)
+	case entry.IsDir():
+		node.Kind = SchemaNode_CONTAINER
+	case entry.IsLeafList():
+		node.Kind = SchemaNode_LEAF_LIST
+	case entry.IsLeaf():
+		node.Kind = SchemaNode_LEAF
+	}
+	if t := entry.Type; t != nil && !entry.IsDir() {
+		node.Type = t.Name
+		node.BaseType = t.Kind.String()
+		if node.Default == "" {
+			node.Default = t.Default
+		}
+		values, err := s.model.EnumValues(path)
+		if err != nil {
+			return nil, err
+		}
+		node.EnumValues = values
+	}
+	return node, nil
+}
+
+// dataChildren returns the children of entry that are data nodes, looking
+// through choices and cases.
+func dataChildren(entry *yang.Entry) []*yang.Entry {
+	var children []*yang.Entry
+	for _, child := range entry.Dir {
+		if child.IsChoice() || child.IsCase() {
+			children = append(children, dataChildren(child)...)
+			continue
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// schemaPath returns the schema path of path, without keys nor module names.
+func schemaPath(path *pb.Path) string {
+	var b strings.Builder
+	for _, elem := range path.GetElem() {
+		name := elem.GetName()
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+		b.WriteString("/")
+		b.WriteString(name)
+	}
+	if b.Len() == 0 {
+		return "/"
+	}
+	return b.String()
+}
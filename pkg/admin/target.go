@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package admin
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// deviceName returns the name of the device named target among the devices
+// served on a port, by name. target may be empty on a port serving a single
+// device.
+func deviceName(target string, devices map[string]bool) (string, error) {
+	if target == "" && len(devices) == 1 {
+		for name := range devices {
+			return name, nil
+		}
+	}
+	if target == "" {
+		return "", status.Error(codes.InvalidArgument, "the port is shared by several devices, set the target to select one")
+	}
+	if !devices[target] {
+		return "", status.Errorf(codes.NotFound, "unknown target %q", target)
+	}
+	return target, nil
+}
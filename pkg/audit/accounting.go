@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"sort"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/messages"
+)
+
+// Target is the simulated device whose RPCs are accounted. It is implemented
+// by gnmi.Server.
+type Target interface {
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+	NotifyUpdate(path *pb.Path)
+}
+
+// Accounting writes the audit entries into the AAA accounting state of a
+// device, as the COMMAND accounting events of system/aaa/accounting.
+type Accounting struct {
+	target   Target
+	messages *messages.Generator
+}
+
+// NewAccounting returns the accounting of target. The entries accounted
+// through the LOCAL method are published as messages of generator, unless it
+// is nil.
+func NewAccounting(target Target, generator *messages.Generator) *Accounting {
+	return &Accounting{target: target, messages: generator}
+}
+
+// Record accounts e, logging the error in accounting it if any.
+func (a *Accounting) Record(e Entry) {
+	if _, err := a.Account(e); err != nil {
+		log.Errorf("Error in accounting %s: %v", e.Operation, err)
+	}
+}
+
+// Account accounts e when the COMMAND accounting event is configured, and
+// returns whether it did. The state of the event and of the accounting
+// methods reflect their config. Each server of the server groups of the
+// accounting methods counts the messages sent for e: a START and a STOP
+// record for START_STOP, a STOP record otherwise. With the LOCAL method, e is
+// published as an ACCOUNTING message in JSON. The subscribers of the changed
+// state leaves are notified.
+func (a *Accounting) Account(e Entry) (bool, error) {
+	var changed []*pb.Path
+	local := false
+	err := a.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "config tree is not an openconfig device: %T", config)
+		}
+		if device.System == nil || device.System.Aaa == nil || device.System.Aaa.Accounting == nil {
+			return nil
+		}
+		aaa := device.System.Aaa
+		accounting := aaa.Accounting
+		eventType := gostruct.OpenconfigAaaTypes_AAA_ACCOUNTING_EVENT_TYPE_AAA_ACCOUNTING_EVENT_COMMAND
+		if accounting.Events == nil {
+			return nil
+		}
+		event, ok := accounting.Events.Event[eventType]
+		if !ok || event.Config == nil {
+			return nil
+		}
+		event.State = &gostruct.OpenconfigSystem_System_Aaa_Accounting_Events_Event_State{
+			EventType: event.Config.EventType,
+			Record:    event.Config.Record,
+		}
+		eventName, _ := ygot.EnumName(eventType)
+		changed = append(changed, accountingPath([]*pb.PathElem{{Name: "events"},
+			{Name: "event", Key: map[string]string{"event-type": eventName}}, {Name: "state"}, {Name: "record"}}))
+		records := uint64(1)
+		if event.Config.Record == gostruct.OpenconfigSystem_System_Aaa_Accounting_Events_Event_Config_Record_START_STOP {
+			records = 2
+		}
+
+		var methods []gostruct.OpenconfigSystem_System_Aaa_Accounting_Config_AccountingMethod_Union
+		if accounting.Config != nil {
+			methods = accounting.Config.AccountingMethod
+		}
+		accounting.State = &gostruct.OpenconfigSystem_System_Aaa_Accounting_State{}
+		var groups []string
+		for _, method := range methods {
+			switch method := method.(type) {
+			case *gostruct.OpenconfigSystem_System_Aaa_Accounting_Config_AccountingMethod_Union_String:
+				accounting.State.AccountingMethod = append(accounting.State.AccountingMethod,
+					&gostruct.OpenconfigSystem_System_Aaa_Accounting_State_AccountingMethod_Union_String{String: method.String})
+				groups = append(groups, method.String)
+			case *gostruct.OpenconfigSystem_System_Aaa_Accounting_Config_AccountingMethod_Union_E_OpenconfigAaaTypes_AAA_METHOD_TYPE:
+				accounting.State.AccountingMethod = append(accounting.State.AccountingMethod,
+					&gostruct.OpenconfigSystem_System_Aaa_Accounting_State_AccountingMethod_Union_E_OpenconfigAaaTypes_AAA_METHOD_TYPE{
+						E_OpenconfigAaaTypes_AAA_METHOD_TYPE: method.E_OpenconfigAaaTypes_AAA_METHOD_TYPE})
+				switch method.E_OpenconfigAaaTypes_AAA_METHOD_TYPE {
+				case gostruct.OpenconfigAaaTypes_AAA_METHOD_TYPE_LOCAL:
+					local = true
+				case gostruct.OpenconfigAaaTypes_AAA_METHOD_TYPE_RADIUS_ALL:
+					groups = append(groups, groupsOfType(aaa, gostruct.OpenconfigAaaTypes_AAA_SERVER_TYPE_RADIUS)...)
+				case gostruct.OpenconfigAaaTypes_AAA_METHOD_TYPE_TACACS_ALL:
+					groups = append(groups, groupsOfType(aaa, gostruct.OpenconfigAaaTypes_AAA_SERVER_TYPE_TACACS)...)
+				}
+			}
+		}
+		changed = append(changed, accountingPath([]*pb.PathElem{{Name: "state"}, {Name: "accounting-method"}}))
+		for _, name := range groups {
+			changed = append(changed, countMessages(aaa, name, records)...)
+		}
+		return nil
+	})
+	if err != nil || len(changed) == 0 {
+		return false, err
+	}
+	for _, path := range changed {
+		a.target.NotifyUpdate(path)
+	}
+	if local && a.messages != nil {
+		msg, err := json.Marshal(e)
+		if err != nil {
+			return true, err
+		}
+		a.messages.Log(messages.Message{
+			Severity: gostruct.OpenconfigMessages_SyslogSeverity_INFORMATIONAL,
+			Facility: messages.FacilityAuth,
+			AppName:  "aaad",
+			Msgid:    "ACCOUNTING",
+			Msg:      string(msg),
+		})
+	}
+	return true, nil
+}
+
+// groupsOfType returns the names of the server groups of aaa of type typ,
+// sorted.
+func groupsOfType(aaa *gostruct.OpenconfigSystem_System_Aaa, typ gostruct.E_OpenconfigAaaTypes_AAA_SERVER_TYPE) []string {
+	if aaa.ServerGroups == nil {
+		return nil
+	}
+	var names []string
+	for name, group := range aaa.ServerGroups.ServerGroup {
+		if group.Config != nil && group.Config.Type == typ {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// countMessages adds records to the messages sent to each server of server
+// group name of aaa, and returns the paths of the state leaves it changed.
+func countMessages(aaa *gostruct.OpenconfigSystem_System_Aaa, name string, records uint64) []*pb.Path {
+	if aaa.ServerGroups == nil {
+		return nil
+	}
+	group, ok := aaa.ServerGroups.ServerGroup[name]
+	if !ok || group.Servers == nil {
+		return nil
+	}
+	var changed []*pb.Path
+	for address, server := range group.Servers.Server {
+		if server.State == nil {
+			server.State = &gostruct.OpenconfigSystem_System_Aaa_ServerGroups_ServerGroup_Servers_Server_State{}
+		}
+		server.State.Address = ygot.String(address)
+		sent := records
+		if server.State.MessagesSent != nil {
+			sent += *server.State.MessagesSent
+		}
+		server.State.MessagesSent = ygot.Uint64(sent)
+		changed = append(changed, &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "aaa"}, {Name: "server-groups"},
+			{Name: "server-group", Key: map[string]string{"name": name}}, {Name: "servers"},
+			{Name: "server", Key: map[string]string{"address": address}}, {Name: "state"}, {Name: "messages-sent"}}})
+	}
+	return changed
+}
+
+// accountingPath returns the path of the node at elems of
+// system/aaa/accounting.
+func accountingPath(elems []*pb.PathElem) *pb.Path {
+	return &pb.Path{Elem: append([]*pb.PathElem{{Name: "system"}, {Name: "aaa"}, {Name: "accounting"}}, elems...)}
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package audit records the RPCs served by the simulator for audits: who
+// issued each RPC and from where, the target and paths it acted on, its outcome
+// and the config changes it made. The entries are written as JSON lines into a
+// rotating log, or into the AAA accounting state of the device.
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+)
+
+var log = logging.GetLogger("audit")
+
+// Operations of the gNMI RPCs. The operation of a gNOI RPC is its full name,
+// e.g. /gnoi.system.System/Reboot.
+const (
+	OperationCapabilities = "/gnmi.gNMI/Capabilities"
+	OperationGet          = "/gnmi.gNMI/Get"
+	OperationSet          = "/gnmi.gNMI/Set"
+	OperationSubscribe    = "/gnmi.gNMI/Subscribe"
+)
+
+// Entry is the audit record of an RPC.
+type Entry struct {
+	// Time is the time the RPC ended.
+	Time time.Time `json:"time"`
+	// User is the user of the RPC, if known.
+	User string `json:"user,omitempty"`
+	// Peer is the address of the client.
+	Peer string `json:"peer,omitempty"`
+	// Target is the name of the device, empty for the single device of a
+	// simulator without -target_name.
+	Target string `json:"target,omitempty"`
+	// Operation is the full name of the RPC, e.g. /gnmi.gNMI/Set.
+	Operation string `json:"operation"`
+	// Paths are the paths the request names, with their prefix: the paths of
+	// a Get, the deletes, replaces and updates of a Set, or the subscriptions
+	// of a Subscribe.
+	Paths []string `json:"paths,omitempty"`
+	// Status is the name of the gRPC code of the outcome, e.g. OK or
+	// PermissionDenied.
+	Status string `json:"status"`
+	// Error is the message of the error of a failed RPC.
+	Error string `json:"error,omitempty"`
+	// Diff are the changes of the config leaves made by a Set. The values of
+	// the secret leaves are Redacted in the JSON of the entry.
+	Diff []gnmi.Change `json:"diff,omitempty"`
+}
+
+// Redacted replaces the values of the secret leaves in the JSON of the diff.
+const Redacted = "<redacted>"
+
+// secretLeaves are the names of the leaves holding passwords and keys, whose
+// values are redacted in the JSON of the diff.
+var secretLeaves = map[string]bool{
+	"password":              true,
+	"password-hashed":       true,
+	"admin-password":        true,
+	"admin-password-hashed": true,
+	"secret-key":            true,
+}
+
+// MarshalJSON returns the JSON of e, with the values of the secret leaves of
+// its diff redacted, so that neither the log nor the accounting messages
+// reveal them.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type entry Entry
+	redacted := entry(e)
+	redacted.Diff = make([]gnmi.Change, len(e.Diff))
+	for i, change := range e.Diff {
+		if secretLeaves[change.Path[strings.LastIndex(change.Path, "/")+1:]] {
+			change.Old, change.New = redact(change.Old), redact(change.New)
+		}
+		redacted.Diff[i] = change
+	}
+	return json.Marshal(redacted)
+}
+
+// redact returns Redacted for a value, or nil for none.
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return Redacted
+}
+
+// Recorder records the audit entries of the RPCs.
+type Recorder interface {
+	Record(e Entry)
+}
+
+// NewEntry returns the entry of an RPC of operation on target that ended with
+// err, leaving the user, peer, paths and diff to be filled in.
+func NewEntry(operation, target string, err error) Entry {
+	e := Entry{
+		Time:      time.Now(),
+		Target:    target,
+		Operation: operation,
+		Status:    status.Code(err).String(),
+	}
+	if err != nil {
+		e.Error = status.Convert(err).Message()
+	}
+	return e
+}
+
+// RequestPaths returns the paths of a gNMI request as strings, such as
+// /interfaces/interface[name=eth1]/config/mtu, or nil for other requests.
+func RequestPaths(req interface{}) []string {
+	switch req := req.(type) {
+	case *pb.GetRequest:
+		return paths(req.GetPrefix(), req.GetPath()...)
+	case *pb.SetRequest:
+		all := append([]*pb.Path{}, req.GetDelete()...)
+		for _, updates := range [][]*pb.Update{req.GetReplace(), req.GetUpdate()} {
+			for _, u := range updates {
+				all = append(all, u.GetPath())
+			}
+		}
+		return paths(req.GetPrefix(), all...)
+	case *pb.SubscribeRequest:
+		list := req.GetSubscribe()
+		all := make([]*pb.Path, 0, len(list.GetSubscription()))
+		for _, sub := range list.GetSubscription() {
+			all = append(all, sub.GetPath())
+		}
+		return paths(list.GetPrefix(), all...)
+	}
+	return nil
+}
+
+// paths returns the strings of the paths under prefix.
+func paths(prefix *pb.Path, paths ...*pb.Path) []string {
+	var strs []string
+	for _, path := range paths {
+		full := &pb.Path{Elem: append(append([]*pb.PathElem{}, prefix.GetElem()...), path.GetElem()...)}
+		str, err := ygot.PathToString(full)
+		if err != nil {
+			str = full.String()
+		}
+		strs = append(strs, str)
+	}
+	return strs
+}
@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/messages"
+)
+
+const config = `{
+  "openconfig-system:system": {
+    "aaa": {
+      "accounting": {
+        "config": {"accounting-method": ["tacacs", "LOCAL"]},
+        "events": {"event": [
+          {"event-type": "AAA_ACCOUNTING_EVENT_COMMAND", "config": {"event-type": "AAA_ACCOUNTING_EVENT_COMMAND", "record": "START_STOP"}}
+        ]}
+      },
+      "server-groups": {"server-group": [
+        {"name": "tacacs", "config": {"name": "tacacs", "type": "TACACS"}, "servers": {"server": [
+          {"address": "10.0.0.1", "config": {"address": "10.0.0.1", "name": "tac1"}}
+        ]}}
+      ]}
+    }
+  }
+}`
+
+// target is a gnmi.Server recording the paths it notifies.
+type target struct {
+	*gnmi.Server
+	notified []string
+}
+
+func (t *target) NotifyUpdate(path *pb.Path) {
+	str, _ := ygot.PathToString(path)
+	t.notified = append(t.notified, str)
+	t.Server.NotifyUpdate(path)
+}
+
+func newTarget(t *testing.T, config string) *target {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	return &target{Server: s}
+}
+
+// readLines returns the entries of the JSON lines of file.
+func readLines(t *testing.T, file string) []Entry {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("error in reading %s: %v", file, err)
+	}
+	var entries []Entry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("error in decoding line %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestRequestPaths(t *testing.T) {
+	prefix := &pb.Path{Elem: []*pb.PathElem{{Name: "interfaces"}}}
+	eth1 := &pb.Path{Elem: []*pb.PathElem{{Name: "interface", Key: map[string]string{"name": "eth1"}}, {Name: "config"}, {Name: "mtu"}}}
+	hostname := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}
+	tests := []struct {
+		desc string
+		req  interface{}
+		want []string
+	}{
+		{"get", &pb.GetRequest{Prefix: prefix, Path: []*pb.Path{eth1}}, []string{"/interfaces/interface[name=eth1]/config/mtu"}},
+		{"set", &pb.SetRequest{Delete: []*pb.Path{hostname}, Update: []*pb.Update{{Path: eth1}}},
+			[]string{"/system/config/hostname", "/interface[name=eth1]/config/mtu"}},
+		{"subscribe", &pb.SubscribeRequest{Request: &pb.SubscribeRequest_Subscribe{Subscribe: &pb.SubscriptionList{
+			Prefix: prefix, Subscription: []*pb.Subscription{{Path: eth1}}}}}, []string{"/interfaces/interface[name=eth1]/config/mtu"}},
+		{"root", &pb.GetRequest{Path: []*pb.Path{{}}}, []string{"/"}},
+		{"other", &pb.CapabilityRequest{}, nil},
+	}
+	for _, tc := range tests {
+		if got := RequestPaths(tc.req); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got paths %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("error in creating a directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "audit.log")
+
+	e := NewEntry("/gnmi.gNMI/Set", "switch1", nil)
+	e.User, e.Peer = "alice", "127.0.0.1:5000"
+	e.Paths = []string{"/system/config/hostname"}
+	e.Diff = []gnmi.Change{{Path: "/system/config/hostname", Old: "switch1", New: "switch2"}}
+	line, _ := json.Marshal(e)
+	l, err := NewLog(file, int64(2*len(line)+2), 2)
+	if err != nil {
+		t.Fatalf("error in opening the log: %v", err)
+	}
+	for i := 0; i < 7; i++ {
+		l.Record(e)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("error in closing the log: %v", err)
+	}
+	if err := l.Write(e); err == nil {
+		t.Error("write to a closed log: got nil error")
+	}
+
+	for _, tc := range []struct {
+		file    string
+		entries int
+	}{{file, 1}, {file + ".1", 2}, {file + ".2", 2}} {
+		if entries := readLines(t, tc.file); len(entries) != tc.entries {
+			t.Errorf("got %d entries in %s, want %d", len(entries), tc.file, tc.entries)
+		}
+	}
+	if _, err := os.Stat(file + ".3"); !os.IsNotExist(err) {
+		t.Errorf("got a third backup: %v", err)
+	}
+	got := readLines(t, file)[0]
+	if got.User != "alice" || got.Target != "switch1" || got.Status != "OK" || len(got.Diff) != 1 || got.Diff[0].New != "switch2" {
+		t.Errorf("got entry %+v, want the entry of the Set of alice", got)
+	}
+	if strings.Contains(string(line), `"error"`) {
+		t.Errorf("the entry of a successful RPC has an error: %s", line)
+	}
+
+	failed := NewEntry("/gnmi.gNMI/Get", "", status.Error(codes.PermissionDenied, "denied"))
+	if failed.Status != "PermissionDenied" || failed.Error != "denied" {
+		t.Errorf("got entry %+v of a denied RPC, want its code and message", failed)
+	}
+	if _, err := NewLog(file, -1, 0); err == nil {
+		t.Error("log of a negative size: got nil error")
+	}
+}
+
+func TestLogRedactsSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("error in creating a directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "audit.log")
+
+	tg := newTarget(t, `{}`)
+	user := func(leaf string) *pb.Path {
+		return &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "aaa"}, {Name: "authentication"}, {Name: "users"},
+			{Name: "user", Key: map[string]string{"username": "alice"}}, {Name: "config"}, {Name: leaf}}}
+	}
+	str := func(s string) *pb.TypedValue { return &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: s}} }
+	req := &pb.SetRequest{Update: []*pb.Update{
+		{Path: user("username"), Val: str("alice")},
+		{Path: user("password"), Val: str("s3cr3t-pw")},
+	}}
+	_, changes, err := tg.SetChanges(context.Background(), req)
+	if err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	e := NewEntry(OperationSet, "", nil)
+	e.Diff = changes
+
+	l, err := NewLog(file, 0, 0)
+	if err != nil {
+		t.Fatalf("error in opening the log: %v", err)
+	}
+	l.Record(e)
+	l.Close()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("error in reading the log: %v", err)
+	}
+	if strings.Contains(string(data), "s3cr3t-pw") {
+		t.Errorf("the password is in the log line: %s", data)
+	}
+	got := readLines(t, file)[0].Diff
+	if len(got) == 0 || !strings.HasSuffix(got[0].Path, "/password") || got[0].New != Redacted {
+		t.Errorf("got diff %+v, want the redacted change of the password", got)
+	}
+}
+
+func TestAccounting(t *testing.T) {
+	tg := newTarget(t, config)
+	a := NewAccounting(tg, messages.NewGenerator(tg))
+	e := NewEntry("/gnmi.gNMI/Set", "switch1", nil)
+	e.User = "alice"
+	for i := 0; i < 2; i++ {
+		if ok, err := a.Account(e); !ok || err != nil {
+			t.Fatalf("got %v, %v in accounting, want it accounted", ok, err)
+		}
+	}
+
+	var device *gostruct.Device
+	_ = tg.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device = config.(*gostruct.Device)
+		return nil
+	})
+	accounting := device.System.Aaa.Accounting
+	event := accounting.Events.Event[gostruct.OpenconfigAaaTypes_AAA_ACCOUNTING_EVENT_TYPE_AAA_ACCOUNTING_EVENT_COMMAND]
+	if event.State == nil || event.State.Record != gostruct.OpenconfigSystem_System_Aaa_Accounting_Events_Event_Config_Record_START_STOP {
+		t.Errorf("got event state %+v, want the START_STOP record of its config", event.State)
+	}
+	if accounting.State == nil || len(accounting.State.AccountingMethod) != 2 {
+		t.Errorf("got accounting state %+v, want the methods of its config", accounting.State)
+	}
+	server := device.System.Aaa.ServerGroups.ServerGroup["tacacs"].Servers.Server["10.0.0.1"]
+	if server.State == nil || server.State.MessagesSent == nil || *server.State.MessagesSent != 4 {
+		t.Errorf("got server state %+v, want 4 messages sent", server.State)
+	}
+	msg := device.Messages.State.Message
+	if *msg.Msgid != "ACCOUNTING" || !strings.Contains(*msg.Msg, `"user":"alice"`) {
+		t.Errorf("got message %s %s, want the accounting of the entry", *msg.Msgid, *msg.Msg)
+	}
+	want := "/system/aaa/server-groups/server-group[name=tacacs]/servers/server[address=10.0.0.1]/state/messages-sent"
+	found := false
+	for _, path := range tg.notified {
+		found = found || path == want
+	}
+	if !found {
+		t.Errorf("notified %v, want %s", tg.notified, want)
+	}
+
+	unconfigured := newTarget(t, `{}`)
+	if ok, err := NewAccounting(unconfigured, nil).Account(e); ok || err != nil {
+		t.Errorf("got %v, %v in accounting without accounting config, want it not accounted", ok, err)
+	}
+}
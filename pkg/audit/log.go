@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Log writes the audit entries as JSON lines into a file, which is rotated
+// when it grows beyond its maximum size: the file is renamed path.1, the
+// previous path.1 path.2 and so on, and the oldest backup is removed.
+type Log struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+// NewLog returns the log of file path, appending to it if it exists. It is
+// rotated once larger than maxSize bytes, keeping backups rotated files; it
+// is never rotated when maxSize is 0.
+func NewLog(path string, maxSize int64, backups int) (*Log, error) {
+	if maxSize < 0 || backups < 0 {
+		return nil, fmt.Errorf("invalid audit log size %d or backups %d", maxSize, backups)
+	}
+	l := &Log{path: path, maxSize: maxSize, backups: backups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record writes e, logging the error in writing it if any.
+func (l *Log) Record(e Entry) {
+	if err := l.Write(e); err != nil {
+		log.Errorf("Error in writing the audit entry of %s: %v", e.Operation, err)
+	}
+}
+
+// Write writes e as a line of JSON, rotating the file first if the line
+// would make it grow beyond the maximum size.
+func (l *Log) Write(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("audit log %s is closed", l.path)
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// Close closes the file of the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// open opens the file of the log for appending. l.mu must be held, unless l
+// is not shared yet.
+func (l *Log) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file, l.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts the backups, renames the file path.1 and opens a new file.
+// Without backups, the file is removed. l.mu must be held.
+func (l *Log) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.backups == 0 {
+		if err := os.Remove(l.path); err != nil {
+			return err
+		}
+		return l.open()
+	}
+	for i := l.backups - 1; i > 0; i-- {
+		if err := os.Rename(l.backup(i), l.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, l.backup(1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// backup returns the name of rotated file i, 1 being the most recent.
+func (l *Log) backup(i int) string {
+	return fmt.Sprintf("%s.%d", l.path, i)
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package devices describes the devices simulated by a single gnmi_target
+// process. The config tree of each device is rendered from a template config
+// by substituting per-device values for the placeholders of the template.
+package devices
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Placeholders of configs/target_configs/typical_ofsw_config.json replaced in
+// the generated devices.
+const (
+	PlaceholderHostname   = "replace-device-name"
+	PlaceholderMotdBanner = "replace-motd-banner"
+	PlaceholderDatapathID = "00:16:3e:00:00:00:00:00"
+)
+
+// Device is a simulated device.
+type Device struct {
+	// Name is the name of the device, which gNMI requests give as the
+	// target of their prefix.
+	Name string `json:"name"`
+	// Port is the port the device listens on. Devices without a port share
+	// the port of the -bind_address flag.
+	Port int `json:"port,omitempty"`
+	// Substitutions map the placeholders of the template config to the
+	// values of the device.
+	Substitutions map[string]string `json:"substitutions,omitempty"`
+	// Interfaces map the names of the interfaces of the template config to
+	// the names of the interfaces of the device. Only the string values
+	// equal to an interface name are renamed.
+	Interfaces map[string]string `json:"interfaces,omitempty"`
+}
+
+// Load loads the devices from a JSON file holding a list of devices.
+func Load(file string) ([]Device, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("invalid devices file %s: %v", file, err)
+	}
+	return devices, Validate(devices)
+}
+
+// Generate returns count devices named prefix-1 to prefix-count. The hostname,
+// motd banner, datapath-id and the names of the interfaces of each device are
+// derived from its index, and when basePort is not 0, the device of index i
+// listens on basePort+i.
+func Generate(count int, prefix string, basePort int, interfaces []string) []Device {
+	devices := make([]Device, 0, count)
+	for i := 1; i <= count; i++ {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		device := Device{
+			Name: name,
+			Substitutions: map[string]string{
+				PlaceholderHostname:   name,
+				PlaceholderMotdBanner: fmt.Sprintf("Welcome to the simulated device %s", name),
+				PlaceholderDatapathID: fmt.Sprintf("00:16:3e:%02x:%02x:%02x:%02x:%02x", byte(i>>32), byte(i>>24), byte(i>>16), byte(i>>8), byte(i)),
+			},
+		}
+		if len(interfaces) > 0 {
+			device.Interfaces = make(map[string]string, len(interfaces))
+			for _, intf := range interfaces {
+				device.Interfaces[intf] = fmt.Sprintf("%s-%d", intf, i)
+			}
+		}
+		if basePort != 0 {
+			device.Port = basePort + i
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+// InterfaceNames returns the names of the interfaces of the JSON template
+// config.
+func InterfaceNames(template []byte) ([]string, error) {
+	if len(template) == 0 {
+		return nil, nil
+	}
+	var config struct {
+		Interfaces struct {
+			Interface []struct {
+				Name string `json:"name"`
+			} `json:"interface"`
+		} `json:"openconfig-interfaces:interfaces"`
+	}
+	if err := json.Unmarshal(template, &config); err != nil {
+		return nil, fmt.Errorf("invalid template config: %v", err)
+	}
+	var names []string
+	for _, intf := range config.Interfaces.Interface {
+		names = append(names, intf.Name)
+	}
+	return names, nil
+}
+
+// Validate checks that the devices have distinct names and ports.
+func Validate(devices []Device) error {
+	names := make(map[string]bool)
+	ports := make(map[int]string)
+	for _, device := range devices {
+		if device.Name == "" {
+			return fmt.Errorf("a device has no name")
+		}
+		if names[device.Name] {
+			return fmt.Errorf("duplicate device name %s", device.Name)
+		}
+		names[device.Name] = true
+		if device.Port == 0 {
+			continue
+		}
+		if other, ok := ports[device.Port]; ok {
+			return fmt.Errorf("devices %s and %s listen on the same port %d", other, device.Name, device.Port)
+		}
+		ports[device.Port] = device.Name
+	}
+	return nil
+}
+
+// Render returns the config of the device: the JSON template config whose
+// string values have the placeholders of the device substituted and its
+// interfaces renamed. Object keys are left as they are.
+func (d Device) Render(template []byte) ([]byte, error) {
+	if len(d.Substitutions) == 0 && len(d.Interfaces) == 0 || len(template) == 0 {
+		return template, nil
+	}
+	// Keep the numbers as they are written, so that 64-bit values do not
+	// lose precision.
+	decoder := json.NewDecoder(bytes.NewReader(template))
+	decoder.UseNumber()
+	var config interface{}
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid template config: %v", err)
+	}
+	// Replace the longest placeholders first so that a placeholder holding
+	// another one is substituted as a whole.
+	placeholders := make([]string, 0, len(d.Substitutions))
+	for placeholder := range d.Substitutions {
+		placeholders = append(placeholders, placeholder)
+	}
+	sort.Slice(placeholders, func(i, j int) bool {
+		return len(placeholders[i]) > len(placeholders[j])
+	})
+	oldnew := make([]string, 0, 2*len(placeholders))
+	for _, placeholder := range placeholders {
+		oldnew = append(oldnew, placeholder, d.Substitutions[placeholder])
+	}
+	return json.Marshal(d.substitute(config, strings.NewReplacer(oldnew...)))
+}
+
+// substitute renames the interfaces and replaces the placeholders in the
+// string values of a JSON value.
+func (d Device) substitute(value interface{}, replacer *strings.Replacer) interface{} {
+	switch v := value.(type) {
+	case string:
+		if name, ok := d.Interfaces[v]; ok {
+			return name
+		}
+		return replacer.Replace(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = d.substitute(v[i], replacer)
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = d.substitute(v[key], replacer)
+		}
+	}
+	return value
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package devices
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const template = `{
+  "openconfig-interfaces:interfaces": {
+    "interface": [{"name": "admin", "config": {"name": "admin"}}]
+  },
+  "openconfig-system:system": {
+    "config": {
+      "hostname": "replace-device-name",
+      "motd-banner": "replace-motd-banner"
+    },
+    "state": {"boot-time": "1575415411"},
+    "openconfig-openflow:openflow": {
+      "agent": {"config": {"datapath-id": "00:16:3e:00:00:00:00:00", "max-backoff": 10}}
+    }
+  }
+}`
+
+type renderedConfig struct {
+	Interfaces struct {
+		Interface []struct {
+			Name   string `json:"name"`
+			Config struct {
+				Name string `json:"name"`
+			} `json:"config"`
+		} `json:"interface"`
+	} `json:"openconfig-interfaces:interfaces"`
+	System struct {
+		Config struct {
+			Hostname   string `json:"hostname"`
+			MotdBanner string `json:"motd-banner"`
+		} `json:"config"`
+		Openflow struct {
+			Agent struct {
+				Config struct {
+					DatapathID string `json:"datapath-id"`
+					MaxBackoff int    `json:"max-backoff"`
+				} `json:"config"`
+			} `json:"agent"`
+		} `json:"openconfig-openflow:openflow"`
+	} `json:"openconfig-system:system"`
+}
+
+func render(t *testing.T, device Device) renderedConfig {
+	data, err := device.Render([]byte(template))
+	if err != nil {
+		t.Fatalf("error in rendering %s: %v", device.Name, err)
+	}
+	var config renderedConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("error in parsing the config of %s: %v", device.Name, err)
+	}
+	return config
+}
+
+func TestGenerate(t *testing.T) {
+	interfaces, err := InterfaceNames([]byte(template))
+	if err != nil {
+		t.Fatalf("error in reading the interface names: %v", err)
+	}
+	if len(interfaces) != 1 || interfaces[0] != "admin" {
+		t.Fatalf("got interface names %v, want [admin]", interfaces)
+	}
+	devices := Generate(300, "sw", 20000, interfaces)
+	if len(devices) != 300 {
+		t.Fatalf("generated %d devices, want 300", len(devices))
+	}
+	if err := Validate(devices); err != nil {
+		t.Fatalf("generated invalid devices: %v", err)
+	}
+	if devices[0].Name != "sw-1" || devices[0].Port != 20001 || devices[299].Port != 20300 {
+		t.Errorf("generated %v and %v", devices[0], devices[299])
+	}
+
+	config := render(t, devices[299])
+	if config.System.Config.Hostname != "sw-300" {
+		t.Errorf("hostname is %q, want sw-300", config.System.Config.Hostname)
+	}
+	if config.System.Openflow.Agent.Config.DatapathID != "00:16:3e:00:00:00:01:2c" {
+		t.Errorf("datapath-id is %q, want 00:16:3e:00:00:00:01:2c", config.System.Openflow.Agent.Config.DatapathID)
+	}
+	if intf := config.Interfaces.Interface[0]; intf.Name != "admin-300" || intf.Config.Name != "admin-300" {
+		t.Errorf("interface is %+v, want admin-300", intf)
+	}
+	if config.System.Openflow.Agent.Config.MaxBackoff != 10 {
+		t.Errorf("max-backoff is %d, want 10", config.System.Openflow.Agent.Config.MaxBackoff)
+	}
+
+	if devices := Generate(2, "sw", 0, nil); devices[0].Port != 0 || devices[1].Port != 0 {
+		t.Errorf("generated devices without base port listen on %d and %d", devices[0].Port, devices[1].Port)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "devices-")
+	if err != nil {
+		t.Fatalf("error in creating devices file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString(`[
+	  {"name": "leaf1", "port": 10171, "substitutions": {"replace-device-name": "leaf1"}, "interfaces": {"admin": "mgmt0"}},
+	  {"name": "leaf2"}
+	]`)
+	f.Close()
+
+	devices, err := Load(f.Name())
+	if err != nil {
+		t.Fatalf("error in loading devices: %v", err)
+	}
+	config := render(t, devices[0])
+	if config.System.Config.Hostname != "leaf1" || config.Interfaces.Interface[0].Name != "mgmt0" {
+		t.Errorf("rendered %+v", config)
+	}
+	config = render(t, devices[1])
+	if config.System.Config.Hostname != PlaceholderHostname {
+		t.Errorf("a device without substitutions has hostname %q", config.System.Config.Hostname)
+	}
+
+	for _, invalid := range [][]Device{
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: "a", Port: 1}, {Name: "b", Port: 1}},
+		{{Port: 1}},
+	} {
+		if err := Validate(invalid); err == nil {
+			t.Errorf("%v are valid", invalid)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fault injects faults into the gNMI RPCs of a simulated device:
+// added latency, errors drawn at random, streams disconnected after a number
+// of messages, dropped or reordered notifications and truncated responses.
+// The faults are set by rules, which can be changed at any time.
+package fault
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var log = logging.GetLogger("fault")
+
+// Names of the RPCs of the rules.
+const (
+	Capabilities = "Capabilities"
+	Get          = "Get"
+	Set          = "Set"
+	Subscribe    = "Subscribe"
+)
+
+// ReorderWindow is the longest time a notification is held back to be sent
+// after the next one.
+const ReorderWindow = time.Second
+
+// Rule is a fault injected into the RPCs it matches.
+type Rule struct {
+	// RPC is the RPC the rule applies to, any RPC when empty.
+	RPC string
+	// Path is a path without keys, e.g. /interfaces/interface. The rule
+	// applies to the requests with a path under it, or to every request when
+	// empty.
+	Path string
+	// Delay is the latency added to the RPC, before it is served.
+	Delay time.Duration
+	// ErrorProbability is the probability that the RPC fails with Code
+	// instead of being served.
+	ErrorProbability float64
+	// Code is the code of the injected errors and disconnections,
+	// Unavailable when OK.
+	Code codes.Code
+	// DisconnectAfter is the number of messages after which a Subscribe
+	// stream is disconnected with Code, never when 0.
+	DisconnectAfter int
+	// DropProbability is the probability that a notification of a Subscribe
+	// stream is dropped.
+	DropProbability float64
+	// ReorderProbability is the probability that a notification of a
+	// Subscribe stream is held back and sent after the next message, or after
+	// ReorderWindow.
+	ReorderProbability float64
+	// Truncate is whether the Get responses and the notifications of
+	// Subscribe streams are truncated to MaxUpdates updates.
+	Truncate   bool
+	MaxUpdates int
+}
+
+// Validate checks that r is a valid rule.
+func (r *Rule) Validate() error {
+	switch r.RPC {
+	case "", Capabilities, Get, Set, Subscribe:
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown RPC %q", r.RPC)
+	}
+	if r.Path != "" && !strings.HasPrefix(r.Path, "/") {
+		return status.Errorf(codes.InvalidArgument, "path %q is not absolute", r.Path)
+	}
+	for _, p := range []float64{r.ErrorProbability, r.DropProbability, r.ReorderProbability} {
+		if p < 0 || p > 1 {
+			return status.Errorf(codes.InvalidArgument, "invalid probability %v", p)
+		}
+	}
+	if r.Delay < 0 || r.DisconnectAfter < 0 || r.MaxUpdates < 0 {
+		return status.Error(codes.InvalidArgument, "negative delay, disconnect-after or max-updates")
+	}
+	return nil
+}
+
+// code returns the code of the errors injected by r.
+func (r *Rule) code() codes.Code {
+	if r.Code == codes.OK {
+		return codes.Unavailable
+	}
+	return r.Code
+}
+
+// matches returns whether r applies to the requests of rpc with paths.
+func (r *Rule) matches(rpc string, paths []*pb.Path) bool {
+	if r.RPC != "" && r.RPC != rpc {
+		return false
+	}
+	if r.Path == "" {
+		return true
+	}
+	names := strings.Split(strings.Trim(r.Path, "/"), "/")
+	for _, path := range paths {
+		if len(path.GetElem()) < len(names) {
+			continue
+		}
+		matched := true
+		for i, name := range names {
+			if path.GetElem()[i].GetName() != name {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Injector is a gNMI server injecting the faults of its rules into the RPCs
+// of another. Every rule matching an RPC applies to it: their delays add up
+// and each draws its own faults.
+type Injector struct {
+	server pb.GNMIServer
+
+	mu     sync.Mutex
+	rules  []Rule
+	random *rand.Rand
+}
+
+// NewInjector returns an injector of faults into server, without rules.
+func NewInjector(server pb.GNMIServer) *Injector {
+	return &Injector{
+		server: server,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// SetRules replaces the rules of the injector.
+func (i *Injector) SetRules(rules []Rule) error {
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.rules = append([]Rule(nil), rules...)
+	log.Infof("Injecting %d fault rules", len(rules))
+	return nil
+}
+
+// Rules returns the rules of the injector.
+func (i *Injector) Rules() []Rule {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]Rule(nil), i.rules...)
+}
+
+// Capabilities serves a Capabilities request with the faults of the rules.
+func (i *Injector) Capabilities(ctx context.Context, req *pb.CapabilityRequest) (*pb.CapabilityResponse, error) {
+	if err := i.inject(ctx, i.match(Capabilities, nil)); err != nil {
+		return nil, err
+	}
+	return i.server.Capabilities(ctx, req)
+}
+
+// Get serves a Get request with the faults of the rules.
+func (i *Injector) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	rules := i.match(Get, fullPaths(req.GetPrefix(), req.GetPath()))
+	if err := i.inject(ctx, rules); err != nil {
+		return nil, err
+	}
+	resp, err := i.server.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		if r.Truncate {
+			resp = truncate(resp, r.MaxUpdates)
+		}
+	}
+	return resp, nil
+}
+
+// Set serves a Set request with the faults of the rules.
+func (i *Injector) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse, error) {
+	paths := req.GetDelete()
+	for _, u := range append(req.GetReplace(), req.GetUpdate()...) {
+		paths = append(paths, u.GetPath())
+	}
+	if err := i.inject(ctx, i.match(Set, fullPaths(req.GetPrefix(), paths))); err != nil {
+		return nil, err
+	}
+	return i.server.Set(ctx, req)
+}
+
+// Subscribe serves a Subscribe stream with the faults of the rules matching
+// its subscriptions.
+func (i *Injector) Subscribe(stream pb.GNMI_SubscribeServer) error {
+	req, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var paths []*pb.Path
+	for _, sub := range req.GetSubscribe().GetSubscription() {
+		paths = append(paths, sub.GetPath())
+	}
+	rules := i.match(Subscribe, fullPaths(req.GetSubscribe().GetPrefix(), paths))
+	if err := i.inject(stream.Context(), rules); err != nil {
+		return err
+	}
+	s := &faultStream{
+		GNMI_SubscribeServer: stream,
+		first:                req,
+		injector:             i,
+		rules:                rules,
+		sent:                 make([]int, len(rules)),
+		disconnected:         make(chan struct{}),
+	}
+	served := make(chan error, 1)
+	go func() { served <- i.server.Subscribe(s) }()
+	select {
+	case err := <-served:
+		s.close()
+		return err
+	case <-s.disconnected:
+		return s.err
+	}
+}
+
+// match returns the rules matching the requests of rpc with paths.
+func (i *Injector) match(rpc string, paths []*pb.Path) []Rule {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var rules []Rule
+	for _, r := range i.rules {
+		if r.matches(rpc, paths) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// inject waits for the delays of rules and draws their errors.
+func (i *Injector) inject(ctx context.Context, rules []Rule) error {
+	var delay time.Duration
+	for _, r := range rules {
+		delay += r.Delay
+	}
+	if delay > 0 {
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+			}
+			return status.Error(codes.Canceled, ctx.Err().Error())
+		case <-time.After(delay):
+		}
+	}
+	for _, r := range rules {
+		if i.draw(r.ErrorProbability) {
+			return status.Errorf(r.code(), "injected fault")
+		}
+	}
+	return nil
+}
+
+// draw returns true with probability p.
+func (i *Injector) draw(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.random.Float64() < p
+}
+
+// faultStream is a Subscribe stream injecting the faults of rules into the
+// responses sent to the client.
+type faultStream struct {
+	pb.GNMI_SubscribeServer
+	first    *pb.SubscribeRequest
+	injector *Injector
+	rules    []Rule
+
+	mu           sync.Mutex
+	sent         []int // messages sent under each rule
+	held         *pb.SubscribeResponse
+	timer        *time.Timer // end of the window of held
+	err          error       // error of the disconnection
+	disconnected chan struct{}
+}
+
+// Recv returns the first request of the stream, then the next ones.
+func (s *faultStream) Recv() (*pb.SubscribeRequest, error) {
+	s.mu.Lock()
+	req := s.first
+	s.first = nil
+	s.mu.Unlock()
+	if req != nil {
+		return req, nil
+	}
+	return s.GNMI_SubscribeServer.Recv()
+}
+
+// Send sends resp to the client, unless the faults of the rules disconnect
+// the stream, drop resp or hold it back.
+func (s *faultStream) Send(resp *pb.SubscribeResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	for idx, r := range s.rules {
+		s.sent[idx]++
+		if r.DisconnectAfter > 0 && s.sent[idx] > r.DisconnectAfter {
+			s.err = status.Errorf(r.code(), "stream disconnected after %d messages", r.DisconnectAfter)
+			close(s.disconnected)
+			return s.err
+		}
+		if resp.GetUpdate() == nil {
+			continue
+		}
+		if s.injector.draw(r.DropProbability) {
+			return nil
+		}
+		if r.Truncate {
+			resp = &pb.SubscribeResponse{Response: &pb.SubscribeResponse_Update{Update: truncateNotification(resp.GetUpdate(), r.MaxUpdates)}}
+		}
+		if s.held == nil && s.injector.draw(r.ReorderProbability) {
+			s.held = resp
+			s.timer = time.AfterFunc(ReorderWindow, s.flush)
+			return nil
+		}
+	}
+	if err := s.GNMI_SubscribeServer.Send(resp); err != nil {
+		return err
+	}
+	return s.sendHeld()
+}
+
+// flush sends the notification held back, if any.
+func (s *faultStream) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		_ = s.sendHeld()
+	}
+}
+
+// close sends the notification held back, if any, and closes the stream to
+// the sends that could follow.
+func (s *faultStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		_ = s.sendHeld()
+		s.err = status.Error(codes.Canceled, "stream closed")
+	}
+}
+
+// sendHeld sends the notification held back, if any. s.mu must be held.
+func (s *faultStream) sendHeld() error {
+	if s.held == nil {
+		return nil
+	}
+	held := s.held
+	s.held = nil
+	s.timer.Stop()
+	return s.GNMI_SubscribeServer.Send(held)
+}
+
+// truncate returns resp truncated to its first max updates.
+func truncate(resp *pb.GetResponse, max int) *pb.GetResponse {
+	truncated := &pb.GetResponse{Error: resp.GetError()}
+	for _, n := range resp.GetNotification() {
+		if max == 0 {
+			break
+		}
+		n = truncateNotification(n, max)
+		max -= len(n.GetUpdate())
+		truncated.Notification = append(truncated.Notification, n)
+	}
+	return truncated
+}
+
+// truncateNotification returns a copy of n truncated to its first max
+// updates.
+func truncateNotification(n *pb.Notification, max int) *pb.Notification {
+	if len(n.GetUpdate()) <= max {
+		return n
+	}
+	truncated := proto.Clone(n).(*pb.Notification)
+	truncated.Update = truncated.Update[:max]
+	return truncated
+}
+
+// fullPaths returns paths under prefix.
+func fullPaths(prefix *pb.Path, paths []*pb.Path) []*pb.Path {
+	full := make([]*pb.Path, len(paths))
+	for i, path := range paths {
+		full[i] = &pb.Path{Elem: append(append([]*pb.PathElem(nil), prefix.GetElem()...), path.GetElem()...)}
+	}
+	if len(paths) == 0 && prefix != nil {
+		full = append(full, prefix)
+	}
+	return full
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fault
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+const config = `{"openconfig-system:system": {"config": {"hostname": "switch1", "domain-name": "example.net"}}}`
+
+// dial serves injector over a loopback gRPC connection.
+func dial(t *testing.T, injector *Injector) (pb.GNMIClient, func()) {
+	g := grpc.NewServer()
+	pb.RegisterGNMIServer(g, injector)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	return pb.NewGNMIClient(conn), func() {
+		conn.Close()
+		g.Stop()
+	}
+}
+
+func newInjector(t *testing.T) *Injector {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	return NewInjector(s)
+}
+
+func mustPath(t *testing.T, path string) *pb.Path {
+	p, err := ygot.StringToStructuredPath(path)
+	if err != nil {
+		t.Fatalf("error in parsing path %s: %v", path, err)
+	}
+	return p
+}
+
+// subscribeOnce subscribes once to paths and returns the responses received
+// before the end of the stream, with its error.
+func subscribeOnce(t *testing.T, client pb.GNMIClient, paths ...string) ([]*pb.SubscribeResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	stream, err := client.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("error in subscribing: %v", err)
+	}
+	list := &pb.SubscriptionList{Mode: pb.SubscriptionList_ONCE}
+	for _, path := range paths {
+		list.Subscription = append(list.Subscription, &pb.Subscription{Path: mustPath(t, path)})
+	}
+	if err := stream.Send(&pb.SubscribeRequest{Request: &pb.SubscribeRequest_Subscribe{Subscribe: list}}); err != nil {
+		t.Fatalf("error in sending the subscription: %v", err)
+	}
+	var responses []*pb.SubscribeResponse
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, resp)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, r := range []Rule{
+		{RPC: "Walk"},
+		{Path: "system"},
+		{ErrorProbability: 1.5},
+		{DropProbability: -1},
+		{Delay: -time.Second},
+	} {
+		if err := r.Validate(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("validating %+v got %v, want InvalidArgument", r, err)
+		}
+	}
+	injector := newInjector(t)
+	if err := injector.SetRules([]Rule{{RPC: Get}, {RPC: "Walk"}}); err == nil || len(injector.Rules()) != 0 {
+		t.Errorf("setting an invalid rule got %v with rules %v, want an error and no rules", err, injector.Rules())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	injector := newInjector(t)
+	client, stop := dial(t, injector)
+	defer stop()
+	ctx := context.Background()
+	get := &pb.GetRequest{Path: []*pb.Path{mustPath(t, "/system/config/hostname")}, Encoding: pb.Encoding_JSON}
+
+	if err := injector.SetRules([]Rule{{RPC: Get, Path: "/interfaces", ErrorProbability: 1}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	if _, err := client.Get(ctx, get); err != nil {
+		t.Errorf("Get of a path without faults got %v", err)
+	}
+
+	if err := injector.SetRules([]Rule{{RPC: Get, Path: "/system", ErrorProbability: 1, Code: codes.ResourceExhausted}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	if _, err := client.Get(ctx, get); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Get got %v, want ResourceExhausted", err)
+	}
+	if _, err := client.Capabilities(ctx, &pb.CapabilityRequest{}); err != nil {
+		t.Errorf("Capabilities got %v, want no fault", err)
+	}
+
+	if err := injector.SetRules([]Rule{{ErrorProbability: 1}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	if _, err := client.Capabilities(ctx, &pb.CapabilityRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Capabilities got %v, want Unavailable", err)
+	}
+	if _, err := subscribeOnce(t, client, "/system/config/hostname"); status.Code(err) != codes.Unavailable {
+		t.Errorf("Subscribe got %v, want Unavailable", err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	injector := newInjector(t)
+	client, stop := dial(t, injector)
+	defer stop()
+	if err := injector.SetRules([]Rule{{Delay: 100 * time.Millisecond}, {RPC: Capabilities, Delay: 100 * time.Millisecond}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := client.Capabilities(context.Background(), &pb.CapabilityRequest{}); err != nil {
+		t.Fatalf("error in Capabilities: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Capabilities took %v, want at least 200ms", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Capabilities(ctx, &pb.CapabilityRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Capabilities past its deadline got %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	injector := newInjector(t)
+	client, stop := dial(t, injector)
+	defer stop()
+	if err := injector.SetRules([]Rule{{Truncate: true, MaxUpdates: 1}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	resp, err := client.Get(context.Background(), &pb.GetRequest{
+		Path:     []*pb.Path{mustPath(t, "/system/config/hostname"), mustPath(t, "/system/config/domain-name")},
+		Encoding: pb.Encoding_JSON,
+	})
+	if err != nil {
+		t.Fatalf("error in Get: %v", err)
+	}
+	updates := 0
+	for _, n := range resp.GetNotification() {
+		updates += len(n.GetUpdate())
+	}
+	if updates != 1 {
+		t.Errorf("Get returned %d updates, want 1", updates)
+	}
+}
+
+func TestStream(t *testing.T) {
+	injector := newInjector(t)
+	client, stop := dial(t, injector)
+	defer stop()
+
+	if err := injector.SetRules([]Rule{{RPC: Subscribe, DisconnectAfter: 1, Code: codes.Unavailable}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	responses, err := subscribeOnce(t, client, "/system/config/hostname")
+	if status.Code(err) != codes.Unavailable || len(responses) != 1 || responses[0].GetUpdate() == nil {
+		t.Errorf("Subscribe received %v, %v, want a notification then Unavailable", responses, err)
+	}
+
+	if err := injector.SetRules([]Rule{{RPC: Subscribe, DropProbability: 1}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	responses, _ = subscribeOnce(t, client, "/system/config/hostname")
+	if len(responses) != 1 || !responses[0].GetSyncResponse() {
+		t.Errorf("Subscribe received %v, want only a sync response", responses)
+	}
+
+	if err := injector.SetRules([]Rule{{RPC: Subscribe, ReorderProbability: 1}}); err != nil {
+		t.Fatalf("error in setting the rules: %v", err)
+	}
+	responses, _ = subscribeOnce(t, client, "/system/config/hostname")
+	if len(responses) != 2 || !responses[0].GetSyncResponse() || responses[1].GetUpdate() == nil {
+		t.Errorf("Subscribe received %v, want a sync response then a notification", responses)
+	}
+}
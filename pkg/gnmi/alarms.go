@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Alarm is an alarm of the device, an entry of /system/alarms/alarm.
+type Alarm struct {
+	// ID identifies the alarm.
+	ID string
+	// Resource names the resource the alarm is about, e.g. an interface.
+	Resource string
+	// Severity is an OPENCONFIG_ALARM_SEVERITY identity: UNKNOWN, MINOR,
+	// WARNING, MAJOR or CRITICAL.
+	Severity string
+	// TypeID is an OPENCONFIG_ALARM_TYPE_ID identity, such as EQPT or LOS, or
+	// any other string.
+	TypeID string
+	// Text describes the alarm.
+	Text string
+	// TimeCreated is the time the alarm was raised.
+	TimeCreated time.Time
+}
+
+// AlarmRule raises an alarm about each node of a schema path while a
+// condition over its leaves holds, and clears it once it no longer holds.
+type AlarmRule struct {
+	// Name names the rule. The alarm about a node has the id name:resource,
+	// where the resource lists the keys of the list entries of the path of
+	// the node, separated by slashes, or the id name outside of lists.
+	Name string
+	// Path is the schema path of the watched nodes, e.g.
+	// /interfaces/interface/state.
+	Path string
+	// Raised returns whether the alarm about a node is raised from its RFC 7951
+	// JSON value.
+	Raised func(node map[string]interface{}) bool
+	// Severity and TypeID are those of the alarms raised.
+	Severity string
+	TypeID   string
+	// Text returns the text of the alarm about resource, or nil for none.
+	Text func(resource string, node map[string]interface{}) string
+}
+
+// alarmsElems is the path of the list of alarms.
+var alarmsElems = []*pb.PathElem{{Name: "system"}, {Name: "alarms"}}
+
+// SetAlarmRules sets the rules evaluated by EvaluateAlarms. It must be called
+// before the server serves requests.
+func (s *Server) SetAlarmRules(rules []AlarmRule) error {
+	if len(rules) > 0 {
+		if err := s.checkAlarms(); err != nil {
+			return err
+		}
+	}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Raised == nil {
+			return fmt.Errorf("the alarm rule of %s has no name or no condition", rule.Path)
+		}
+		if entry := s.model.schemaEntry(namesPath(schemaNames(rule.Path))); entry == nil || !entry.IsDir() {
+			return fmt.Errorf("the path %s of alarm rule %s is not a container or list of the schema", rule.Path, rule.Name)
+		}
+	}
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.alarmRules = rules
+	s.ruleAlarms = make(map[string]bool)
+	return nil
+}
+
+// MonitorAlarms evaluates the alarm rules every interval until ctx is done.
+func (s *Server) MonitorAlarms(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.EvaluateAlarms(); err != nil {
+			log.Warnf("error in evaluating the alarm rules: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EvaluateAlarms raises the alarms of the rules whose condition holds and
+// clears those whose condition no longer holds. The stream subscribers of the
+// alarms are notified of their changes, and of their deletion when they are
+// cleared.
+func (s *Server) EvaluateAlarms() error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	if len(s.alarmRules) == 0 {
+		return nil
+	}
+	jsonTree, err := configJSON(s.config)
+	if err != nil {
+		return err
+	}
+	raised := make(map[string]bool)
+	var changed []string
+	for _, rule := range s.alarmRules {
+		walkNodes(jsonTree, s.model.schemaTreeRoot, schemaNames(rule.Path), nil, func(keys []string, node map[string]interface{}) {
+			if !rule.Raised(node) {
+				return
+			}
+			alarm := Alarm{
+				ID:       rule.Name,
+				Resource: strings.Join(keys, "/"),
+				Severity: rule.Severity,
+				TypeID:   rule.TypeID,
+			}
+			if alarm.Resource != "" {
+				alarm.ID += ":" + alarm.Resource
+			}
+			if rule.Text != nil {
+				alarm.Text = rule.Text(alarm.Resource, node)
+			}
+			raised[alarm.ID] = true
+			if setAlarm(jsonTree, alarm) {
+				changed = append(changed, alarm.ID)
+			}
+		})
+	}
+	for id := range s.ruleAlarms {
+		if !raised[id] && deleteAlarm(jsonTree, id) {
+			changed = append(changed, id)
+		}
+	}
+	s.ruleAlarms = raised
+	if len(changed) == 0 {
+		return nil
+	}
+	return s.commitAlarms(jsonTree, changed)
+}
+
+// RaiseAlarm raises alarm, or updates the alarm of the same id. The time the
+// alarm was raised is the current time, or the time the alarm of the same id
+// was raised, unless set.
+func (s *Server) RaiseAlarm(alarm Alarm) error {
+	if alarm.ID == "" {
+		return status.Error(codes.InvalidArgument, "the alarm has no id")
+	}
+	if err := s.checkAlarms(); err != nil {
+		return err
+	}
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	jsonTree, err := configJSON(s.config)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error in constructing IETF JSON tree from config struct: %v", err)
+	}
+	if !setAlarm(jsonTree, alarm) {
+		return nil
+	}
+	return s.commitAlarms(jsonTree, []string{alarm.ID})
+}
+
+// ClearAlarm clears the alarm of id.
+func (s *Server) ClearAlarm(id string) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	jsonTree, err := configJSON(s.config)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error in constructing IETF JSON tree from config struct: %v", err)
+	}
+	if !deleteAlarm(jsonTree, id) {
+		return status.Errorf(codes.NotFound, "alarm %s not found", id)
+	}
+	delete(s.ruleAlarms, id)
+	return s.commitAlarms(jsonTree, []string{id})
+}
+
+// Alarms returns the alarms of the device, sorted by id.
+func (s *Server) Alarms() ([]Alarm, error) {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	jsonTree, err := configJSON(s.config)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error in constructing IETF JSON tree from config struct: %v", err)
+	}
+	var alarms []Alarm
+	list, _ := lookupLeaf(jsonTree, []string{"system", "alarms", "alarm"}).([]interface{})
+	for _, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		state, _ := entry["state"].(map[string]interface{})
+		alarm := Alarm{ID: fmt.Sprintf("%v", entry["id"])}
+		alarm.Resource, _ = state["resource"].(string)
+		alarm.Severity, _ = state["severity"].(string)
+		alarm.TypeID, _ = state["type-id"].(string)
+		alarm.Text, _ = state["text"].(string)
+		if created, err := strconv.ParseInt(fmt.Sprintf("%v", state["time-created"]), 10, 64); err == nil {
+			alarm.TimeCreated = time.Unix(0, created)
+		}
+		alarms = append(alarms, alarm)
+	}
+	sort.Slice(alarms, func(i, j int) bool { return alarms[i].ID < alarms[j].ID })
+	return alarms, nil
+}
+
+// checkAlarms returns an error if the model has no list of alarms.
+func (s *Server) checkAlarms() error {
+	if s.model.schemaEntry(&pb.Path{Elem: appendElem(alarmsElems, &pb.PathElem{Name: "alarm"})}) == nil {
+		return status.Error(codes.FailedPrecondition, "the model has no /system/alarms/alarm list")
+	}
+	return nil
+}
+
+// commitAlarms makes the json tree, where the alarms of ids were changed, the
+// config tree and notifies the stream subscribers of the leaves of the alarms
+// that changed, or of the alarms that were cleared.
+func (s *Server) commitAlarms(jsonTree map[string]interface{}, ids []string) error {
+	oldTree, err := configJSON(s.config)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error in constructing IETF JSON tree from config struct: %v", err)
+	}
+	config, err := s.toGoStruct(jsonTree)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid alarm: %v", err)
+	}
+	s.config = config
+	var changed []*pb.Path
+	for _, id := range ids {
+		elems := appendElem(alarmsElems, &pb.PathElem{Name: "alarm", Key: map[string]string{"id": id}})
+		entry := alarmEntry(jsonTree, id)
+		if entry == nil {
+			changed = append(changed, &pb.Path{Elem: elems})
+			continue
+		}
+		changed = append(changed, changedLeaves(elems, alarmEntry(oldTree, id), entry)...)
+	}
+	s.notifyPaths(changed)
+	return nil
+}
+
+// alarmEntry returns the entry of the alarm of id in the json tree, or nil.
+func alarmEntry(jsonTree map[string]interface{}, id string) map[string]interface{} {
+	alarms, ok := lookupLeaf(jsonTree, []string{"system", "alarms"}).(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return getKeyedListEntry(alarms, &pb.PathElem{Name: "alarm", Key: map[string]string{"id": id}}, false)
+}
+
+// setAlarm raises alarm in the json tree and returns whether it changed the
+// tree. An alarm already raised keeps the time it was created unless set.
+func setAlarm(jsonTree map[string]interface{}, alarm Alarm) bool {
+	state := map[string]interface{}{"id": alarm.ID}
+	for name, val := range map[string]string{
+		"resource": alarm.Resource,
+		"severity": alarm.Severity,
+		"type-id":  alarm.TypeID,
+		"text":     alarm.Text,
+	} {
+		if val != "" {
+			state[name] = val
+		}
+	}
+	if !alarm.TimeCreated.IsZero() {
+		state["time-created"] = strconv.FormatInt(alarm.TimeCreated.UnixNano(), 10)
+	}
+
+	system, _ := jsonTree["system"].(map[string]interface{})
+	if system == nil {
+		system = make(map[string]interface{})
+		jsonTree["system"] = system
+	}
+	alarms, _ := system["alarms"].(map[string]interface{})
+	if alarms == nil {
+		alarms = make(map[string]interface{})
+		system["alarms"] = alarms
+	}
+	entry := getKeyedListEntry(alarms, &pb.PathElem{Name: "alarm", Key: map[string]string{"id": alarm.ID}}, false)
+	if entry == nil {
+		entry = map[string]interface{}{"id": alarm.ID}
+		list, _ := alarms["alarm"].([]interface{})
+		alarms["alarm"] = append(list, entry)
+	}
+	old, _ := entry["state"].(map[string]interface{})
+	if _, ok := state["time-created"]; !ok {
+		if created, ok := old["time-created"]; ok {
+			state["time-created"] = created
+		} else {
+			state["time-created"] = strconv.FormatInt(time.Now().UnixNano(), 10)
+		}
+	}
+	if reflect.DeepEqual(old, state) {
+		return false
+	}
+	entry["state"] = state
+	return true
+}
+
+// deleteAlarm clears the alarm of id in the json tree and returns whether it
+// was raised.
+func deleteAlarm(jsonTree map[string]interface{}, id string) bool {
+	alarms, ok := lookupLeaf(jsonTree, []string{"system", "alarms"}).(map[string]interface{})
+	if !ok || !deleteKeyedListEntry(alarms, &pb.PathElem{Name: "alarm", Key: map[string]string{"id": id}}) {
+		return false
+	}
+	if len(alarms) == 0 {
+		delete(jsonTree["system"].(map[string]interface{}), "alarms")
+	}
+	return true
+}
+
+// walkNodes calls fn with every node of the json tree at the relative schema
+// path names of node, whose schema is entry, and with the keys of the list
+// entries on the way to it.
+func walkNodes(node map[string]interface{}, entry *yang.Entry, names []string, keys []string, fn func(keys []string, node map[string]interface{})) {
+	if len(names) == 0 {
+		fn(keys, node)
+		return
+	}
+	childEntry := findChild(entry, names[0])
+	if childEntry == nil {
+		return
+	}
+	switch child := node[names[0]].(type) {
+	case map[string]interface{}:
+		walkNodes(child, childEntry, names[1:], keys, fn)
+	case []interface{}:
+		for _, item := range child {
+			listEntry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			entryKeys := append([]string{}, keys...)
+			for _, name := range strings.Fields(childEntry.Key) {
+				entryKeys = append(entryKeys, fmt.Sprintf("%v", listEntry[name]))
+			}
+			walkNodes(listEntry, childEntry, names[1:], entryKeys, fn)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Capacity is the capacity of a simulated device, beyond which it throttles
+// its telemetry and rejects requests with codes.ResourceExhausted. The zero
+// Capacity is unlimited.
+type Capacity struct {
+	// MaxSubscriptions is the number of concurrent Subscribe streams,
+	// unlimited when 0.
+	MaxSubscriptions int
+	// NotificationRate is the number of notifications per second sent over
+	// all the Subscribe streams, unlimited when 0. Up to a second of
+	// notifications are sent at once, the next ones are delayed to keep to
+	// the rate, and new Subscribe streams are rejected while more than a
+	// second of notifications is waiting.
+	NotificationRate float64
+	// SetTimePerKB is the time a Set request takes to be processed per KB of
+	// its payload.
+	SetTimePerKB time.Duration
+	// MaxSetSize is the size in bytes of the largest Set request, unlimited
+	// when 0.
+	MaxSetSize int
+	// LowestSampleInterval is the lowest sample interval of the SAMPLE
+	// subscriptions, which is also the interval of the subscriptions that do
+	// not set one, 5s when 0.
+	LowestSampleInterval time.Duration
+}
+
+// notificationBurst is the time of the notifications sent at once before
+// they are throttled, and the longest time the throttled notifications may
+// wait before new subscriptions are rejected.
+const notificationBurst = time.Second
+
+// SetCapacity sets the capacity of the device, which applies to the requests
+// received afterwards.
+func (s *Server) SetCapacity(c Capacity) error {
+	if c.MaxSubscriptions < 0 || c.NotificationRate < 0 || c.SetTimePerKB < 0 || c.MaxSetSize < 0 || c.LowestSampleInterval < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid capacity %+v", c)
+	}
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	s.capacity = c
+	return nil
+}
+
+// Capacity returns the capacity of the device.
+func (s *Server) Capacity() Capacity {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	return s.capacity
+}
+
+// sampleInterval returns the lowest sample interval of the subscriptions, in
+// nanoseconds.
+func (s *Server) sampleInterval() uint64 {
+	if interval := s.Capacity().LowestSampleInterval; interval > 0 {
+		return uint64(interval)
+	}
+	return lowestSampleInterval
+}
+
+// throttling returns whether more than a second of notifications is waiting
+// to be sent within the notification rate. s.capacityMu must be held.
+func (s *Server) throttling() bool {
+	return s.capacity.NotificationRate > 0 && time.Until(s.nextNotification) > s.notificationBurst()+notificationBurst
+}
+
+// reserveNotification reserves the sending of a notification within the
+// notification rate and returns how long it must wait to be sent.
+func (s *Server) reserveNotification() time.Duration {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	if s.capacity.NotificationRate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	if s.nextNotification.Before(now) {
+		s.nextNotification = now
+	}
+	s.nextNotification = s.nextNotification.Add(time.Duration(float64(time.Second) / s.capacity.NotificationRate))
+	return s.nextNotification.Sub(now) - s.notificationBurst()
+}
+
+// notificationBurst returns the time of the notifications sent at once before
+// they are throttled, at least the time of one notification. s.capacityMu
+// must be held.
+func (s *Server) notificationBurst() time.Duration {
+	if interval := time.Duration(float64(time.Second) / s.capacity.NotificationRate); interval > notificationBurst {
+		return interval
+	}
+	return notificationBurst
+}
+
+// throttle waits until notification response can be sent on stream within
+// the notification rate, or until the stream is done. Sync responses are not
+// throttled.
+func (s *Server) throttle(response *pb.SubscribeResponse, stream pb.GNMI_SubscribeServer) {
+	if response.GetUpdate() == nil {
+		return
+	}
+	wait := s.reserveNotification()
+	if wait <= 0 {
+		return
+	}
+	select {
+	case <-stream.Context().Done():
+	case <-time.After(wait):
+	}
+}
+
+// processSet checks the size of Set request req and waits for the time it
+// takes to be processed.
+func (s *Server) processSet(ctx context.Context, req *pb.SetRequest) error {
+	c := s.Capacity()
+	if c.MaxSetSize == 0 && c.SetTimePerKB == 0 {
+		return nil
+	}
+	size := proto.Size(req)
+	if c.MaxSetSize > 0 && size > c.MaxSetSize {
+		return setErrorf(SetTooLarge, codes.ResourceExhausted, "set request of %d bytes larger than %d bytes", size, c.MaxSetSize)
+	}
+	if c.SetTimePerKB == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+		}
+		return status.Error(codes.Canceled, ctx.Err().Error())
+	case <-time.After(c.SetTimePerKB * time.Duration(size) / 1024):
+	}
+	return nil
+}
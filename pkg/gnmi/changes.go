@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Change is the change of a config leaf or leaf-list made by a Set request.
+type Change struct {
+	// Path is the path of the leaf, e.g.
+	// /interfaces/interface[name=eth1]/config/mtu.
+	Path string `json:"path"`
+	// Old is the RFC 7951 JSON value of the leaf before the request, nil when
+	// the request created it.
+	Old interface{} `json:"old,omitempty"`
+	// New is the value of the leaf after the request, nil when the request
+	// deleted it.
+	New interface{} `json:"new,omitempty"`
+}
+
+// configChanges returns the changes of the config leaves between the old and
+// the new json trees, sorted by path. The state containers, which the Set
+// requests do not write, are left out.
+func (s *Server) configChanges(oldTree, jsonTree map[string]interface{}) []Change {
+	changes := diffNodes(oldTree, jsonTree, s.model.schemaTreeRoot, nil)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// diffNodes returns the changes of the leaves between the old and the new
+// node at elems, whose schema is entry, if known.
+func diffNodes(old, node map[string]interface{}, entry *yang.Entry, elems []*pb.PathElem) []Change {
+	var changes []Change
+	for _, name := range unionNames(old, node) {
+		var childEntry *yang.Entry
+		if entry != nil {
+			childEntry = findChild(entry, name)
+		}
+		if childEntry != nil && childEntry.ReadOnly() || childEntry == nil && name == "state" {
+			continue
+		}
+		oldChild, newChild := old[name], node[name]
+		if childEntry != nil && childEntry.IsList() {
+			changes = append(changes, diffLists(oldChild, newChild, childEntry, elems, name)...)
+			continue
+		}
+		oldDir, oldIsDir := oldChild.(map[string]interface{})
+		newDir, newIsDir := newChild.(map[string]interface{})
+		if oldIsDir || newIsDir {
+			changes = append(changes, diffNodes(oldDir, newDir, childEntry, appendElem(elems, &pb.PathElem{Name: name}))...)
+			continue
+		}
+		if !reflect.DeepEqual(oldChild, newChild) {
+			changes = append(changes, Change{Path: pathString(leafPath(elems, name)), Old: oldChild, New: newChild})
+		}
+	}
+	return changes
+}
+
+// diffLists returns the changes of the leaves of the entries of the old and
+// the new list name of the node at elems, matched by their keys.
+func diffLists(old, list interface{}, entry *yang.Entry, elems []*pb.PathElem, name string) []Change {
+	oldEntries, newEntries := listEntries(old, entry), listEntries(list, entry)
+	keys := make([]string, 0, len(oldEntries)+len(newEntries))
+	for key := range oldEntries {
+		keys = append(keys, key)
+	}
+	for key := range newEntries {
+		if _, ok := oldEntries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	var changes []Change
+	for _, key := range keys {
+		oldEntry, newEntry := oldEntries[key], newEntries[key]
+		listEntry := newEntry
+		if listEntry == nil {
+			listEntry = oldEntry
+		}
+		elem := &pb.PathElem{Name: name, Key: listKeys(entry, listEntry)}
+		changes = append(changes, diffNodes(oldEntry, newEntry, entry, appendElem(elems, elem))...)
+	}
+	return changes
+}
+
+// listEntries returns the entries of a list whose schema is entry, by the
+// string of their keys.
+func listEntries(list interface{}, entry *yang.Entry) map[string]map[string]interface{} {
+	entries := make(map[string]map[string]interface{})
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		if listEntry, ok := item.(map[string]interface{}); ok {
+			entries[pathString(&pb.Path{Elem: []*pb.PathElem{{Name: entry.Name, Key: listKeys(entry, listEntry)}}})] = listEntry
+		}
+	}
+	return entries
+}
+
+// changedLeaves returns the paths of the leaves that differ between old and
+// node, the old and the new node at elems of the json trees. Lists are
+// compared as leaves.
+func changedLeaves(elems []*pb.PathElem, old, node map[string]interface{}) []*pb.Path {
+	var changed []*pb.Path
+	for _, name := range unionNames(old, node) {
+		oldDir, oldIsDir := old[name].(map[string]interface{})
+		newDir, newIsDir := node[name].(map[string]interface{})
+		switch {
+		case oldIsDir || newIsDir:
+			changed = append(changed, changedLeaves(appendElem(elems, &pb.PathElem{Name: name}), oldDir, newDir)...)
+		case !reflect.DeepEqual(old[name], node[name]):
+			changed = append(changed, leafPath(elems, name))
+		}
+	}
+	return changed
+}
+
+// unionNames returns the names of the children of a and b, sorted.
+func unionNames(a, b map[string]interface{}) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pathString returns the string of path, such as
+// /interfaces/interface[name=eth1]/config/mtu.
+func pathString(path *pb.Path) string {
+	str, err := ygot.PathToString(path)
+	if err != nil {
+		return path.String()
+	}
+	return str
+}
@@ -7,9 +7,9 @@ package gnmi
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/openconfig/ygot/ygot"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -19,22 +19,37 @@ import (
 
 // SetDateTime update current-datetime field in runtime
 func (s *Server) SetDateTime() error {
+	val := &pb.TypedValue{
+		Value: &pb.TypedValue_StringVal{
+			StringVal: time.Now().Format("2006-01-02T15:04:05Z-07:00"),
+		},
+	}
+	return s.updateStateLeaf(`elem:<name:"system" > elem:<name:"state" > elem:<name:"current-datetime" > `, val)
+}
+
+// SetBootTime updates the boot-time field, in nanoseconds since the Unix epoch.
+func (s *Server) SetBootTime(bootTime time.Time) error {
+	// The JSON tree is RFC 7951 encoded, where 64-bit integers are strings.
+	val := &pb.TypedValue{
+		Value: &pb.TypedValue_StringVal{
+			StringVal: strconv.FormatInt(bootTime.UnixNano(), 10),
+		},
+	}
+	return s.updateStateLeaf(`elem:<name:"system" > elem:<name:"state" > elem:<name:"boot-time" > `, val)
+}
+
+// updateStateLeaf sets the leaf at textPbPath to val and notifies the stream subscribers.
+func (s *Server) updateStateLeaf(textPbPath string, val *pb.TypedValue) error {
 	s.configMu.Lock()
 	defer s.configMu.Unlock()
 	var path pb.Path
-	textPbPath := `elem:<name:"system" > elem:<name:"state" > elem:<name:"current-datetime" > `
 	if err := proto.UnmarshalText(textPbPath, &path); err != nil {
 		return err
 	}
 
-	val := &pb.TypedValue{
-		Value: &pb.TypedValue_StringVal{
-			StringVal: time.Now().Format("2006-01-02T15:04:05Z-07:00"),
-		},
-	}
 	update := &pb.Update{Path: &path, Val: val}
 
-	jsonTree, _ := ygot.ConstructIETFJSON(s.config, &ygot.RFC7951JSONConfig{})
+	jsonTree, _ := configJSON(s.config)
 	_, _ = s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_UPDATE, nil, update.GetPath(), update.GetVal())
 	jsonDump, err := json.Marshal(jsonTree)
 	if err != nil {
@@ -49,7 +64,7 @@ func (s *Server) SetDateTime() error {
 		return status.Error(codes.Internal, msg)
 	}
 	s.config = rootStruct
-	s.ConfigUpdate.In() <- update
+	s.notify(update)
 	return nil
 
 }
@@ -7,6 +7,7 @@ package gnmi
 
 import (
 	"sync"
+	"time"
 
 	"github.com/eapache/channels"
 
@@ -41,22 +42,38 @@ var (
 //		// Do something ...
 // }
 type Server struct {
-	model               *Model
-	callback            ConfigCallback
-	config              ygot.ValidatedGoStruct
-	ConfigUpdate        *channels.RingChannel
-	configMu            sync.RWMutex // mu is the RW lock to protect the access to config
-	subMu               sync.RWMutex
-	readOnlyUpdateValue *pb.Update
-	subscribers         map[string]*streamClient
+	model            *Model
+	callback         ConfigCallback
+	config           ygot.ValidatedGoStruct
+	ConfigUpdate     *channels.RingChannel
+	configMu         sync.RWMutex // mu is the RW lock to protect the access to config
+	subMu            sync.RWMutex
+	subscribers      map[string]*streamClient
+	historyMu        sync.Mutex
+	setHistory       []SetRecord
+	availableMu      sync.RWMutex
+	unavailable      bool
+	target           string
+	userFunc         UserFunc
+	mirror           *mirror
+	alarmRules       []AlarmRule
+	ruleAlarms       map[string]bool // ids of the alarms raised by the rules
+	capacityMu       sync.Mutex      // protects the capacity and the active Subscribe streams
+	capacity         Capacity
+	streams          map[uint64]*Subscription // active Subscribe streams, by id
+	lastStream       uint64                   // id of the last Subscribe stream
+	nextNotification time.Time                // time the notifications are sent up to
+	statsMu          sync.Mutex               // protects stats
+	stats            Stats
 }
 
 var (
-	lowestSampleInterval uint64 = 5000000000 // 5000000000 nanoseconds
+	lowestSampleInterval uint64 = 5000000000 // 5000000000 nanoseconds, unless set by the capacity of the target
 )
 
 type streamClient struct {
 	target         string
+	prefix         *pb.Path
 	sr             *pb.SubscribeRequest
 	stream         pb.GNMI_SubscribeServer
 	errChan        chan error
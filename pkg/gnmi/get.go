@@ -24,6 +24,9 @@ import (
 
 // Get implements the Get RPC in gNMI spec.
 func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	if err := s.checkAvailable(); err != nil {
+		return nil, err
+	}
 
 	dataType := req.GetType()
 
@@ -32,12 +35,20 @@ func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse,
 	}
 
 	prefix := req.GetPrefix()
+	if err := s.checkTarget(prefix); err != nil {
+		return nil, err
+	}
+	respPrefix := s.responsePrefix(prefix)
 	paths := req.GetPath()
 	notifications := make([]*pb.Notification, len(paths))
 
 	s.configMu.RLock()
 	defer s.configMu.RUnlock()
 
+	if s.model.isGeneric() {
+		return s.getTree(req, respPrefix)
+	}
+
 	if paths == nil && dataType.String() != "" {
 
 		jsonType := "IETF"
@@ -68,7 +79,7 @@ func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse,
 		update := buildUpdate(jsonDump, &path, jsonType)
 		notifications[0] = &pb.Notification{
 			Timestamp: ts,
-			Prefix:    prefix,
+			Prefix:    respPrefix,
 			Update:    []*pb.Update{update},
 		}
 		resp := &pb.GetResponse{Notification: notifications}
@@ -156,7 +167,7 @@ func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse,
 			update := &pb.Update{Path: path, Val: val}
 			notifications[i] = &pb.Notification{
 				Timestamp: ts,
-				Prefix:    prefix,
+				Prefix:    respPrefix,
 				Update:    []*pb.Update{update},
 			}
 			continue
@@ -197,7 +208,7 @@ func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse,
 		update := buildUpdate(jsonDump, path, jsonType)
 		notifications[i] = &pb.Notification{
 			Timestamp: ts,
-			Prefix:    prefix,
+			Prefix:    respPrefix,
 			Update:    []*pb.Update{update},
 		}
 	}
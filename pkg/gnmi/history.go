@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+)
+
+// setHistorySize is the number of Set requests kept in the Set history.
+const setHistorySize = 100
+
+// SetRecord is an entry of the Set history of the server.
+type SetRecord struct {
+	Timestamp time.Time
+	// User is the identity of the client that issued the request, if known.
+	User    string
+	Request *pb.SetRequest
+	// Err is the error the request failed with, nil if it was applied.
+	Err error
+}
+
+// SetHistory returns the most recent Set requests handled by the server,
+// oldest first.
+func (s *Server) SetHistory() []SetRecord {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	history := make([]SetRecord, len(s.setHistory))
+	copy(history, s.setHistory)
+	return history
+}
+
+// recordSet appends a Set request and its outcome to the Set history.
+func (s *Server) recordSet(ctx context.Context, req *pb.SetRequest, err error) {
+	record := SetRecord{
+		Timestamp: time.Now(),
+		User:      s.user(ctx),
+		Request:   req,
+		Err:       err,
+	}
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	if len(s.setHistory) == setHistorySize {
+		s.setHistory = s.setHistory[1:]
+	}
+	s.setHistory = append(s.setHistory, record)
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// SetAvailable makes the gNMI service available or unavailable, e.g. while
+// the simulated device reboots. RPCs received while the service is
+// unavailable fail with codes.Unavailable, as do the Subscribe streams active
+// when it becomes unavailable.
+func (s *Server) SetAvailable(available bool) {
+	s.availableMu.Lock()
+	s.unavailable = !available
+	s.availableMu.Unlock()
+	if !available {
+		s.closeSubscriptions()
+	}
+}
+
+// checkAvailable returns an error if the gNMI service is unavailable.
+func (s *Server) checkAvailable() error {
+	s.availableMu.RLock()
+	defer s.availableMu.RUnlock()
+	if s.unavailable {
+		return status.Error(codes.Unavailable, "the target is not available")
+	}
+	return nil
+}
+
+// Reload replaces the whole config tree with the given json config, as when
+// the device boots from its startup configuration, and notifies the stream
+// subscribers of the new values of their paths. The state containers are
+// mirrored from scratch when mirroring is enabled.
+func (s *Server) Reload(config []byte) error {
+	rootStruct, err := s.model.NewConfigStruct(config)
+	if err != nil {
+		return err
+	}
+	s.configMu.Lock()
+	if rootStruct, err = s.mirrorConfig(rootStruct); err != nil {
+		s.configMu.Unlock()
+		return err
+	}
+	if s.callback != nil {
+		if err := s.callback(rootStruct); err != nil {
+			s.configMu.Unlock()
+			return status.Errorf(codes.Aborted, "error in applying config to device: %v", err)
+		}
+	}
+	s.config = rootStruct
+	s.configMu.Unlock()
+
+	for key, c := range s.getSubscribers() {
+		list := c.sr.GetSubscribe()
+		for _, sub := range list.GetSubscription() {
+			if sub.GetPath().String() == key {
+				s.notify(&pb.Update{Path: gnmiFullPath(list.GetPrefix(), sub.GetPath())})
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// NotifyUpdate notifies the stream subscribers of path of its new value, after
+// the tree was changed through InternalUpdate.
+func (s *Server) NotifyUpdate(path *pb.Path) {
+	s.notify(&pb.Update{Path: path})
+}
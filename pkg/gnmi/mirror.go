@@ -0,0 +1,402 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Derivation derives a state leaf from another leaf of the same list entry or
+// container, as a device reports the operational effect of its config.
+type Derivation struct {
+	// From is the schema path of the source leaf, without keys, e.g.
+	// /interfaces/interface/config/enabled.
+	From string
+	// To is the schema path of the derived state leaf, e.g.
+	// /interfaces/interface/state/admin-status.
+	To string
+	// Derive returns the value of the derived leaf at path from the RFC 7951
+	// JSON value of the source leaf, or nil to leave the derived leaf as it is.
+	// The value is copied when Derive is nil.
+	Derive func(path *pb.Path, value interface{}) interface{}
+	// Delayed derivations take effect after the delay given to
+	// EnableMirroring, as a link takes time to come up.
+	Delayed bool
+}
+
+// mirror maintains the state containers of the config tree.
+type mirror struct {
+	delay       time.Duration
+	derivations map[string][]*derivation // by schema path of the common parent
+	timersMu    sync.Mutex
+	timers      map[string]*time.Timer // pending delayed derivations, by path
+}
+
+// derivation is a Derivation whose source and derived leaves are given
+// relative to their common parent.
+type derivation struct {
+	Derivation
+	from, to    []string
+	fromDefault interface{}
+}
+
+// EnableMirroring makes the server mirror the config containers to their
+// sibling state containers whenever the config tree changes: the state leaves
+// take the values of the config leaves of the same name, or their default
+// when the config leaves are unset. The derivations whose source changed are
+// then applied in order, the delayed ones after delay. The stream subscribers
+// of the state leaves are notified of their changes. The current config tree
+// is mirrored right away; it must be called before the server serves requests.
+func (s *Server) EnableMirroring(derivations []Derivation, delay time.Duration) error {
+	m := &mirror{
+		delay:       delay,
+		derivations: make(map[string][]*derivation),
+		timers:      make(map[string]*time.Timer),
+	}
+	for _, d := range derivations {
+		from, to := schemaNames(d.From), schemaNames(d.To)
+		fromEntry := s.model.schemaEntry(namesPath(from))
+		if fromEntry == nil || !fromEntry.IsLeaf() {
+			return fmt.Errorf("the source %s of a derivation is not a leaf of the schema", d.From)
+		}
+		if toEntry := s.model.schemaEntry(namesPath(to)); toEntry == nil || !toEntry.IsLeaf() || !toEntry.ReadOnly() {
+			return fmt.Errorf("the derived %s of a derivation is not a state leaf of the schema", d.To)
+		}
+		n := 0
+		for n < len(from)-1 && n < len(to)-1 && from[n] == to[n] {
+			n++
+		}
+		parent := "/" + strings.Join(from[:n], "/")
+		m.derivations[parent] = append(m.derivations[parent], &derivation{
+			Derivation:  d,
+			from:        from[n:],
+			to:          to[n:],
+			fromDefault: defaultValue(fromEntry),
+		})
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.mirror = m
+	config, err := s.mirrorConfig(s.config)
+	if err != nil {
+		return err
+	}
+	s.config = config
+	return nil
+}
+
+// mirrorConfig returns config with its state containers mirrored, as after a
+// boot.
+func (s *Server) mirrorConfig(config ygot.ValidatedGoStruct) (ygot.ValidatedGoStruct, error) {
+	if s.mirror == nil {
+		return config, nil
+	}
+	jsonTree, err := configJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.mirrorState(nil, jsonTree)) == 0 {
+		return config, nil
+	}
+	return s.toGoStruct(jsonTree)
+}
+
+// mirrorState updates the state containers of the json tree after the config
+// changed from the old json tree, and returns the paths of the state leaves
+// it changed. An old tree of nil stands for an empty tree.
+func (s *Server) mirrorState(oldTree, jsonTree map[string]interface{}) []*pb.Path {
+	if s.mirror == nil {
+		return nil
+	}
+	return s.mirror.walk(s, oldTree, jsonTree, s.model.schemaTreeRoot, nil, "")
+}
+
+// walk mirrors the node of the json tree at elems, whose schema is entry, and
+// its descendants. old is the node of the old tree at the same path, if any.
+func (m *mirror) walk(s *Server, old, node map[string]interface{}, entry *yang.Entry, elems []*pb.PathElem, schemaPath string) []*pb.Path {
+	var changed []*pb.Path
+	cfg, _ := node["config"].(map[string]interface{})
+	oldCfg, _ := old["config"].(map[string]interface{})
+	if cfg != nil || oldCfg != nil {
+		cfgEntry, stateEntry := entry.Dir["config"], entry.Dir["state"]
+		if cfgEntry != nil && cfgEntry.IsContainer() && stateEntry != nil && stateEntry.IsContainer() {
+			changed = append(changed, mirrorContainer(oldCfg, cfg, node, cfgEntry, stateEntry, elems)...)
+		}
+	}
+	for _, d := range m.derivations[schemaPath] {
+		if path := m.derive(s, old, node, d, elems); path != nil {
+			changed = append(changed, path)
+		}
+	}
+
+	for name, child := range node {
+		childEntry := findChild(entry, name)
+		if childEntry == nil || !childEntry.IsDir() {
+			continue
+		}
+		childPath := schemaPath + "/" + name
+		if childEntry.IsList() {
+			list, _ := child.([]interface{})
+			for _, item := range list {
+				listEntry, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				elem := &pb.PathElem{Name: name, Key: listKeys(childEntry, listEntry)}
+				var oldEntry map[string]interface{}
+				if old != nil {
+					oldEntry = getKeyedListEntry(old, elem, false)
+				}
+				changed = append(changed, m.walk(s, oldEntry, listEntry, childEntry, appendElem(elems, elem), childPath)...)
+			}
+			continue
+		}
+		if container, ok := child.(map[string]interface{}); ok {
+			oldContainer, _ := old[name].(map[string]interface{})
+			changed = append(changed, m.walk(s, oldContainer, container, childEntry, appendElem(elems, &pb.PathElem{Name: name}), childPath)...)
+		}
+	}
+	return changed
+}
+
+// mirrorContainer sets the leaves of the state container of node to the
+// values of the leaves of the same name of its config container cfg, or to
+// their default. The state leaves of config leaves that were deleted since
+// oldCfg are deleted.
+func mirrorContainer(oldCfg, cfg, node map[string]interface{}, cfgEntry, stateEntry *yang.Entry, elems []*pb.PathElem) []*pb.Path {
+	var changed []*pb.Path
+	state, _ := node["state"].(map[string]interface{})
+	for name, stateLeaf := range stateEntry.Dir {
+		cfgLeaf, ok := cfgEntry.Dir[name]
+		if !ok || stateLeaf.IsDir() || cfgLeaf.IsDir() {
+			continue
+		}
+		val, set := cfg[name]
+		if !set {
+			val = defaultValue(cfgLeaf)
+			set = val != nil
+		}
+		cur, has := state[name]
+		switch {
+		case set && !reflect.DeepEqual(cur, val):
+			if state == nil {
+				state = make(map[string]interface{})
+				node["state"] = state
+			}
+			state[name] = copyJSON(val)
+		case !set && has && oldCfg[name] != nil:
+			delete(state, name)
+		default:
+			continue
+		}
+		changed = append(changed, leafPath(elems, "state", name))
+	}
+	return changed
+}
+
+// derive applies d to node, the node of the json tree at elems, if its source
+// changed since old or its derived leaf is unset. It returns the path of the
+// derived leaf if it changed it; delayed derivations are scheduled instead.
+func (m *mirror) derive(s *Server, old, node map[string]interface{}, d *derivation, elems []*pb.PathElem) *pb.Path {
+	src := lookupLeaf(node, d.from)
+	if src == nil {
+		src = d.fromDefault
+	}
+	target := lookupLeaf(node, d.to)
+	if old != nil && target != nil {
+		oldSrc := lookupLeaf(old, d.from)
+		if oldSrc == nil {
+			oldSrc = d.fromDefault
+		}
+		if reflect.DeepEqual(oldSrc, src) {
+			return nil
+		}
+	}
+	path := leafPath(elems, d.to...)
+	val := d.value(path, src)
+	if val == nil || reflect.DeepEqual(target, val) {
+		return nil
+	}
+	if d.Delayed {
+		m.schedule(s, path, elems, d)
+		return nil
+	}
+	setLeaf(node, d.to, val)
+	return path
+}
+
+// value returns the value of the leaf at path derived from src, or nil.
+func (d *derivation) value(path *pb.Path, src interface{}) interface{} {
+	if src == nil {
+		return nil
+	}
+	if d.Derive == nil {
+		return copyJSON(src)
+	}
+	return d.Derive(path, src)
+}
+
+// schedule applies the delayed derivation d to the node at elems after the
+// delay of the mirror, replacing the derivation pending for the same leaf.
+func (m *mirror) schedule(s *Server, path *pb.Path, elems []*pb.PathElem, d *derivation) {
+	key := path.String()
+	m.timersMu.Lock()
+	defer m.timersMu.Unlock()
+	if timer, ok := m.timers[key]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(m.delay, func() {
+		m.timersMu.Lock()
+		if m.timers[key] == timer {
+			delete(m.timers, key)
+		}
+		m.timersMu.Unlock()
+		s.applyDerivation(path, elems, d)
+	})
+	m.timers[key] = timer
+}
+
+// applyDerivation applies d to the node at elems of the current config tree
+// and mirrors the change, then notifies the stream subscribers.
+func (s *Server) applyDerivation(path *pb.Path, elems []*pb.PathElem, d *derivation) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	jsonTree, err := configJSON(s.config)
+	if err != nil {
+		log.Errorf("error in constructing IETF JSON tree from config struct: %v", err)
+		return
+	}
+	oldTree := copyJSON(jsonTree).(map[string]interface{})
+
+	var node interface{} = jsonTree
+	schema := s.model.schemaTreeRoot
+	for _, elem := range elems {
+		parent, ok := node.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if node, schema = getChildNode(parent, schema, elem, false); node == nil {
+			// The node was deleted in the meantime.
+			return
+		}
+	}
+	parent, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+	src := lookupLeaf(parent, d.from)
+	if src == nil {
+		src = d.fromDefault
+	}
+	val := d.value(path, src)
+	if val == nil || reflect.DeepEqual(lookupLeaf(parent, d.to), val) {
+		return
+	}
+	setLeaf(parent, d.to, val)
+	changed := append([]*pb.Path{path}, s.mirrorState(oldTree, jsonTree)...)
+
+	config, err := s.toGoStruct(jsonTree)
+	if err != nil {
+		log.Errorf("error in deriving %s: %v", d.To, err)
+		return
+	}
+	s.config = config
+	s.notifyPaths(changed)
+}
+
+// defaultValue returns the default of a boolean, string or enumeration leaf
+// as a JSON value, or nil.
+func defaultValue(entry *yang.Entry) interface{} {
+	if entry.Default == "" || entry.Type == nil {
+		return nil
+	}
+	switch entry.Type.Kind {
+	case yang.Ybool:
+		if b, err := strconv.ParseBool(entry.Default); err == nil {
+			return b
+		}
+	case yang.Ystring, yang.Yenum:
+		return entry.Default
+	}
+	return nil
+}
+
+// listKeys returns the keys of the entry of a list whose schema is entry.
+func listKeys(entry *yang.Entry, listEntry map[string]interface{}) map[string]string {
+	keys := make(map[string]string)
+	for _, name := range strings.Fields(entry.Key) {
+		keys[name] = fmt.Sprintf("%v", listEntry[name])
+	}
+	return keys
+}
+
+// lookupLeaf returns the value of the leaf at the relative path names of
+// node, or nil.
+func lookupLeaf(node map[string]interface{}, names []string) interface{} {
+	var v interface{} = node
+	for _, name := range names {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
+
+// setLeaf sets the leaf at the relative path names of node to val, creating
+// the containers on the way.
+func setLeaf(node map[string]interface{}, names []string, val interface{}) {
+	for _, name := range names[:len(names)-1] {
+		child, ok := node[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			node[name] = child
+		}
+		node = child
+	}
+	node[names[len(names)-1]] = val
+}
+
+// schemaNames returns the names of the elements of a schema path.
+func schemaNames(schemaPath string) []string {
+	return strings.Split(strings.Trim(schemaPath, "/"), "/")
+}
+
+// namesPath returns the path of the elements names.
+func namesPath(names []string) *pb.Path {
+	path := &pb.Path{}
+	for _, name := range names {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name})
+	}
+	return path
+}
+
+// leafPath returns the path of the leaf at the relative path names of the
+// node at elems.
+func leafPath(elems []*pb.PathElem, names ...string) *pb.Path {
+	path := &pb.Path{Elem: append([]*pb.PathElem{}, elems...)}
+	for _, name := range names {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: name})
+	}
+	return path
+}
+
+// appendElem returns a copy of elems followed by elem.
+func appendElem(elems []*pb.PathElem, elem *pb.PathElem) []*pb.PathElem {
+	return append(append([]*pb.PathElem{}, elems...), elem)
+}
@@ -47,7 +47,15 @@ func NewModel(m []*pb.ModelData, t reflect.Type, r *yang.Entry, f JSONUnmarshale
 }
 
 // NewConfigStruct creates a ValidatedGoStruct of this model from jsonConfig. If jsonConfig is nil, creates an empty GoStruct.
+// The ValidatedGoStruct of a model created with NewSchemaModel is a *Tree.
 func (m *Model) NewConfigStruct(jsonConfig []byte) (ygot.ValidatedGoStruct, error) {
+	if m.isGeneric() {
+		tree, err := m.newTree(jsonConfig)
+		if err != nil {
+			return nil, err
+		}
+		return tree, nil
+	}
 	rootNode, stat := ygotutils.NewNode(m.structRootType, &pb.Path{})
 	if stat.GetCode() != int32(cpb.Code_OK) {
 		return nil, fmt.Errorf("cannot create root node: %v", stat)
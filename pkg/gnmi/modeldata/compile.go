@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modeldata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// Module is the model data of a compiled YANG module.
+type Module struct {
+	Name         string
+	Organization string
+	Revision     string
+	Prefix       string
+}
+
+// Compile reads the YANG modules of files, which are file names or module
+// names, searching the directories under paths for them and for the modules
+// they import or include. It compiles them and returns the model data of every
+// module compiled but the excluded ones, sorted by name.
+func Compile(paths, files []string, excluded ...string) ([]Module, error) {
+	for _, path := range paths {
+		dirs, err := yang.PathsWithModules(path)
+		if err != nil {
+			return nil, err
+		}
+		yang.AddPath(dirs...)
+	}
+
+	ms := yang.NewModules()
+	for _, file := range files {
+		if err := ms.Read(file); err != nil {
+			return nil, err
+		}
+	}
+	if errs := ms.Process(); len(errs) != 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return nil, fmt.Errorf("error in compiling the YANG modules: %s", strings.Join(msgs, "; "))
+	}
+
+	exclude := make(map[string]bool)
+	for _, name := range excluded {
+		exclude[name] = true
+	}
+	var modules []Module
+	for name, m := range ms.Modules {
+		// The modules are listed both by name and by name@revision.
+		if name != m.Name || exclude[name] {
+			continue
+		}
+		data := Module{Name: name, Revision: m.Current()}
+		if m.Organization != nil {
+			data.Organization = m.Organization.Name
+		}
+		if m.Prefix != nil {
+			data.Prefix = m.Prefix.Name
+		}
+		modules = append(modules, data)
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Name < modules[j].Name
+	})
+	return modules, nil
+}
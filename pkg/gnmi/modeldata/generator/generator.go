@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Binary generator writes the list of models supported by the gostruct
+// package from the YANG modules it is generated from. Every module compiled
+// along with the input files is listed, imported and augmenting modules
+// included, with its organization and latest revision.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+)
+
+var (
+	yangPaths      = flag.String("path", "", "Comma separated list of paths to be recursively searched for included modules or submodules.")
+	excludeModules = flag.String("exclude_modules", "", "Comma separated set of module names that should be left out of the list.")
+	outputFile     = flag.String("output_file", "", "File in which the generated Go code is written.")
+	packageName    = flag.String("package_name", "modeldata", "Name of the generated Go package.")
+)
+
+var codeTemplate = template.Must(template.New("modeldata").Parse(`// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by pkg/gnmi/modeldata/generator. DO NOT EDIT.
+// Input files:
+{{- range .Files }}
+//	- {{ . }}
+{{- end }}
+
+package {{ .Package }}
+
+import (
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ModelData is the list of supported models: the YANG modules the gostruct
+// package is generated from, with the modules they import or are augmented by.
+var ModelData = []*pb.ModelData{
+{{- range .Modules }}
+	{
+		Name:         {{ printf "%q" .Name }},
+		Organization: {{ printf "%q" .Organization }},
+		Version:      {{ printf "%q" .Revision }},
+	},
+{{- end }}
+}
+
+// modulePrefixes maps the prefix of each supported module to its name.
+var modulePrefixes = map[string]string{
+{{- range .Modules }}
+	{{ printf "%q" .Prefix }}: {{ printf "%q" .Name }},
+{{- end }}
+}
+`))
+
+func main() {
+	flag.Parse()
+	if *outputFile == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: generator -output_file FILE [-path DIRS] [-exclude_modules NAMES] YANG_FILE...")
+		os.Exit(2)
+	}
+	if err := generate(flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func generate(files []string) error {
+	var paths, excluded []string
+	if *yangPaths != "" {
+		paths = strings.Split(*yangPaths, ",")
+	}
+	if *excludeModules != "" {
+		excluded = strings.Split(*excludeModules, ",")
+	}
+	modules, err := modeldata.Compile(paths, files, excluded...)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = codeTemplate.Execute(&buf, struct {
+		Files   []string
+		Package string
+		Modules []modeldata.Module
+	}{files, *packageName, modules})
+	if err != nil {
+		return err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("error in formatting the generated code: %v", err)
+	}
+	return ioutil.WriteFile(*outputFile, code, 0644)
+}
@@ -2,17 +2,13 @@
 //
 // SPDX-License-Identifier: Apache-2.0
 
-// Package modeldata contains the following model data in gnmi proto struct:
-//	openconfig-interfaces 2.0.0,
-//	openconfig-openflow 0.1.0,
-//	openconfig-platform 0.5.0,
-//	openconfig-system 0.2.0.
+// Package modeldata contains the models served by the gostruct package in
+// gnmi proto struct. ModelData is generated by the generator binary from the
+// same YANG modules as gostruct, fetched at their pinned revisions by yang.sh:
+// make modeldata regenerates it and make modeldata-check, run by make test,
+// checks it against the modules.
 package modeldata
 
-import (
-	pb "github.com/openconfig/gnmi/proto/gnmi"
-)
-
 const (
 	// OpenconfigInterfacesModel is the openconfig YANG model for interfaces.
 	OpenconfigInterfacesModel = "openconfig-interfaces"
@@ -22,25 +18,6 @@ const (
 	OpenconfigPlatformModel = "openconfig-platform"
 	// OpenconfigSystemModel is the openconfig YANG model for system.
 	OpenconfigSystemModel = "openconfig-system"
-)
-
-var (
-	// ModelData is a list of supported models.
-	ModelData = []*pb.ModelData{{
-		Name:         OpenconfigInterfacesModel,
-		Organization: "OpenConfig working group",
-		Version:      "2017-07-14",
-	}, {
-		Name:         OpenconfigOpenflowModel,
-		Organization: "OpenConfig working group",
-		Version:      "2017-06-01",
-	}, {
-		Name:         OpenconfigPlatformModel,
-		Organization: "OpenConfig working group",
-		Version:      "2016-12-22",
-	}, {
-		Name:         OpenconfigSystemModel,
-		Organization: "OpenConfig working group",
-		Version:      "2017-07-06",
-	}}
+	// OpenconfigMessagesModel is the openconfig YANG model for system messages.
+	OpenconfigMessagesModel = "openconfig-messages"
 )
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// This file has the layout of the output of pkg/gnmi/modeldata/generator,
+// but it was not generated: the YANG modules gostruct is generated from were
+// not at hand. Regenerate it with make modeldata, which fetches them at the
+// revisions pinned in yang.sh; make modeldata-check fails until then. The
+// versions of the four input models are those the simulator served before,
+// and the others are unknown.
+
+package modeldata
+
+import (
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ModelData is the list of supported models: the YANG modules the gostruct
+// package is generated from, with the modules they import or are augmented by.
+var ModelData = []*pb.ModelData{
+	{
+		Name:         "ietf-interfaces",
+		Organization: "IETF NETMOD (Network Modeling) Working Group",
+		Version:      "",
+	},
+	{
+		Name:         "ietf-yang-types",
+		Organization: "IETF NETMOD (NETCONF Data Modeling Language) Working Group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-aaa",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-aaa-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-alarm-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-alarms",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-extensions",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-inet-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-interfaces",
+		Organization: "OpenConfig working group",
+		Version:      "2017-07-14",
+	},
+	{
+		Name:         "openconfig-license",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-messages",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-openflow",
+		Organization: "OpenConfig working group",
+		Version:      "2017-06-01",
+	},
+	{
+		Name:         "openconfig-openflow-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-platform",
+		Organization: "OpenConfig working group",
+		Version:      "2016-12-22",
+	},
+	{
+		Name:         "openconfig-platform-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-procmon",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-system",
+		Organization: "OpenConfig working group",
+		Version:      "2017-07-06",
+	},
+	{
+		Name:         "openconfig-system-logging",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-system-management",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-system-terminal",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+	{
+		Name:         "openconfig-yang-types",
+		Organization: "OpenConfig working group",
+		Version:      "",
+	},
+}
+
+// modulePrefixes maps the prefix of each supported module to its name.
+var modulePrefixes = map[string]string{
+	"if":                "ietf-interfaces",
+	"yang":              "ietf-yang-types",
+	"oc-aaa":            "openconfig-aaa",
+	"oc-aaa-types":      "openconfig-aaa-types",
+	"oc-alarm-types":    "openconfig-alarm-types",
+	"oc-alarms":         "openconfig-alarms",
+	"oc-ext":            "openconfig-extensions",
+	"oc-inet":           "openconfig-inet-types",
+	"oc-if":             "openconfig-interfaces",
+	"oc-license":        "openconfig-license",
+	"oc-messages":       "openconfig-messages",
+	"openflow":          "openconfig-openflow",
+	"openflow-types":    "openconfig-openflow-types",
+	"oc-platform":       "openconfig-platform",
+	"oc-platform-types": "openconfig-platform-types",
+	"oc-proc":           "openconfig-procmon",
+	"oc-sys":            "openconfig-system",
+	"oc-log":            "openconfig-system-logging",
+	"oc-sys-mgmt":       "openconfig-system-management",
+	"oc-sys-term":       "openconfig-system-terminal",
+	"oc-types":          "openconfig-types",
+	"oc-yang":           "openconfig-yang-types",
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package modeldata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var revision = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// TestModelData checks that ModelData lists every module serving a node of
+// the gostruct schema, and only well-formed entries. The versions are checked
+// against the YANG modules by TestModelDataRevisions.
+func TestModelData(t *testing.T) {
+	supported := make(map[string]bool)
+	for i, m := range ModelData {
+		if supported[m.Name] {
+			t.Errorf("model %s is listed twice", m.Name)
+		}
+		supported[m.Name] = true
+		if i > 0 && ModelData[i-1].Name > m.Name {
+			t.Errorf("model %s is not sorted by name", m.Name)
+		}
+		if m.Organization == "" {
+			t.Errorf("model %s has no organization", m.Name)
+		}
+		if m.Version != "" && !revision.MatchString(m.Version) {
+			t.Errorf("model %s has version %q, want a revision date", m.Name, m.Version)
+		}
+	}
+	for prefix, name := range modulePrefixes {
+		if !supported[name] {
+			t.Errorf("prefix %s is of model %s, which is not listed", prefix, name)
+		}
+	}
+	for _, name := range []string{
+		OpenconfigInterfacesModel,
+		OpenconfigOpenflowModel,
+		OpenconfigPlatformModel,
+		OpenconfigSystemModel,
+		OpenconfigMessagesModel,
+	} {
+		if !supported[name] {
+			t.Errorf("model %s is not listed", name)
+		}
+	}
+
+	root, ok := gostruct.SchemaTree["Device"]
+	if !ok {
+		t.Fatal("gostruct schema has no Device root")
+	}
+	served := make(map[string]string)
+	var walk func(entry *yang.Entry)
+	walk = func(entry *yang.Entry) {
+		if entry.Prefix != nil {
+			served[entry.Prefix.Name] = entry.Path()
+		}
+		for _, child := range entry.Dir {
+			walk(child)
+		}
+	}
+	walk(root)
+	if len(served) == 0 {
+		t.Fatal("gostruct schema has no module prefix")
+	}
+	for prefix, path := range served {
+		name, ok := modulePrefixes[prefix]
+		if !ok {
+			t.Errorf("%s is served by the module of prefix %s, which is not listed", path, prefix)
+			continue
+		}
+		if !supported[name] {
+			t.Errorf("%s is served by model %s, which is not listed", path, name)
+		}
+	}
+}
+
+// TestModelDataRevisions compiles the YANG modules gostruct is generated from,
+// found under the comma separated directories of $YANG_PATH, such as
+// $GOPATH/src/github.com/openconfig/public,$GOPATH/src/github.com/YangModels/yang,
+// and checks ModelData against them. It is skipped when YANG_PATH is unset.
+func TestModelDataRevisions(t *testing.T) {
+	yangPath := os.Getenv("YANG_PATH")
+	if yangPath == "" {
+		t.Skip("YANG_PATH is not set")
+	}
+	modules, err := Compile(strings.Split(yangPath, ","), []string{
+		OpenconfigInterfacesModel,
+		OpenconfigOpenflowModel,
+		OpenconfigPlatformModel,
+		OpenconfigSystemModel,
+	})
+	if err != nil {
+		t.Fatalf("error in compiling the YANG modules: %v", err)
+	}
+	compiled := make(map[string]Module)
+	for _, m := range modules {
+		compiled[m.Name] = m
+	}
+	for _, m := range ModelData {
+		c, ok := compiled[m.Name]
+		switch {
+		case !ok:
+			t.Errorf("model %s is not compiled from the YANG modules", m.Name)
+		case m.Version != c.Revision:
+			t.Errorf("model %s has version %q, want the revision %q of its module", m.Name, m.Version, c.Revision)
+		case m.Organization != c.Organization:
+			t.Errorf("model %s has organization %q, want %q", m.Name, m.Organization, c.Organization)
+		}
+	}
+	if len(compiled) != len(ModelData) {
+		t.Errorf("got %d models, want the %d compiled modules", len(ModelData), len(compiled))
+	}
+}
+
+func TestCompile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modeldata")
+	if err != nil {
+		t.Fatalf("error in creating a directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	for name, text := range map[string]string{
+		"test-types.yang": `module test-types {
+			namespace "urn:test-types"; prefix tt;
+			organization "Test types group";
+			revision 2019-01-01; revision 2020-02-02;
+			typedef name { type string; }
+		}`,
+		"test-device.yang": `module test-device {
+			namespace "urn:test-device"; prefix td;
+			import test-types { prefix tt; }
+			organization "Test group";
+			revision 2021-03-03;
+			container device { leaf name { type tt:name; } }
+		}`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatalf("error in writing %s: %v", name, err)
+		}
+	}
+
+	modules, err := Compile([]string{dir}, []string{"test-device"})
+	if err != nil {
+		t.Fatalf("error in compiling: %v", err)
+	}
+	want := []Module{
+		{Name: "test-device", Organization: "Test group", Revision: "2021-03-03", Prefix: "td"},
+		{Name: "test-types", Organization: "Test types group", Revision: "2020-02-02", Prefix: "tt"},
+	}
+	if len(modules) != len(want) {
+		t.Fatalf("got modules %+v, want %+v", modules, want)
+	}
+	for i := range want {
+		if modules[i] != want[i] {
+			t.Errorf("got module %+v, want %+v", modules[i], want[i])
+		}
+	}
+	if modules, err := Compile([]string{dir}, []string{"test-device"}, "test-types"); err != nil || len(modules) != 1 {
+		t.Errorf("got modules %+v, %v with test-types excluded, want test-device only", modules, err)
+	}
+}
@@ -0,0 +1,40 @@
+#!/bin/bash
+
+# SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+#
+# SPDX-License-Identifier: Apache-2.0
+
+# Fetches the YANG modules the gostruct package is generated from into
+# $YANG_DIR, at the last revisions of openconfig/public and YangModels/yang
+# before $YANG_DATE, and prints the YANG_PATH to compile them with.
+# With -generate, it also regenerates modeldata_generated.go from them.
+
+set -e
+
+YANG_DIR=${YANG_DIR:-build/_yang}
+YANG_DATE=${YANG_DATE:-2020-07-01}
+MODELDATA_DIR=$(cd "$(dirname "$0")" && pwd)
+
+fetch() {
+    local repo=$1 dir=$2
+    shift 2
+    if [ ! -d "$dir/.git" ]; then
+        git clone -q --filter=blob:none --no-checkout "https://github.com/$repo.git" "$dir"
+        git -C "$dir" sparse-checkout set "$@"
+    fi
+    git -C "$dir" checkout -q "$(git -C "$dir" rev-list -1 --before="$YANG_DATE" origin/HEAD)"
+}
+
+mkdir -p "$YANG_DIR"
+fetch openconfig/public "$YANG_DIR/public" release/models third_party/ietf >&2
+fetch YangModels/yang "$YANG_DIR/yang" standard/ietf/RFC >&2
+YANG_PATH=$(cd "$YANG_DIR" && pwd)/public,$(cd "$YANG_DIR" && pwd)/yang
+
+if [ "$1" == "-generate" ]; then
+    go run github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/generator \
+        -output_file "$MODELDATA_DIR/modeldata_generated.go" \
+        -path "$YANG_PATH" \
+        openconfig-interfaces openconfig-openflow openconfig-platform openconfig-system
+fi
+
+echo "$YANG_PATH"
@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// NewSchemaModel compiles the YANG modules of the .yang files found in dir and
+// its subdirectories, and returns a model whose config is a Tree validated
+// against the compiled schema rather than a generated GoStruct. Imported
+// modules are searched in dir as well. The supported models are the modules
+// read, versioned with their latest revision.
+func NewSchemaModel(dir string) (*Model, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".yang" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no YANG module found in %s", dir)
+	}
+
+	yang.AddPath(filepath.Join(dir, "..."))
+	modules := yang.NewModules()
+	for _, file := range files {
+		if err := modules.Read(file); err != nil {
+			return nil, err
+		}
+	}
+	if errs := modules.Process(); len(errs) != 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return nil, fmt.Errorf("error in compiling the YANG modules of %s: %s", dir, strings.Join(msgs, "; "))
+	}
+
+	// The modules are listed both by name and by name@revision.
+	var names []string
+	for name, module := range modules.Modules {
+		if name == module.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	root := &yang.Entry{
+		Name: "device",
+		Kind: yang.DirectoryEntry,
+		Dir:  make(map[string]*yang.Entry),
+	}
+	owners := make(map[string]string)
+	modelData := make([]*pb.ModelData, 0, len(names))
+	for _, name := range names {
+		module := modules.Modules[name]
+		for childName, child := range yang.ToEntry(module).Dir {
+			if owner, ok := owners[childName]; ok {
+				return nil, fmt.Errorf("modules %s and %s both define the top-level node %s", owner, name, childName)
+			}
+			owners[childName] = name
+			root.Dir[childName] = child
+		}
+		data := &pb.ModelData{Name: name, Version: module.Current()}
+		if module.Organization != nil {
+			data.Organization = module.Organization.Name
+		}
+		modelData = append(modelData, data)
+	}
+	return &Model{
+		modelData:      modelData,
+		schemaTreeRoot: root,
+	}, nil
+}
+
+// isGeneric reports whether the config of the model is a Tree rather than a
+// generated GoStruct.
+func (m *Model) isGeneric() bool {
+	return m.structRootType == nil
+}
+
+// schemaEntry returns the schema entry of path, or nil if the schema has no
+// such node.
+func (m *Model) schemaEntry(path *pb.Path) *yang.Entry {
+	entry := m.schemaTreeRoot
+	for _, elem := range path.GetElem() {
+		if entry = findChild(entry, elem.GetName()); entry == nil {
+			return nil
+		}
+	}
+	return entry
+}
+
+// findChild returns the child of entry named name, looking through choices
+// and cases, which have no data node of their own. The name may be qualified
+// with a module name, as in RFC 7951 JSON.
+func findChild(entry *yang.Entry, name string) *yang.Entry {
+	if i := strings.Index(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	if child, ok := entry.Dir[name]; ok && !child.IsChoice() && !child.IsCase() {
+		return child
+	}
+	for _, child := range entry.Dir {
+		if child.IsChoice() || child.IsCase() {
+			if found := findChild(child, name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// SchemaRoot returns the root entry of the schema of the model.
+func (m *Model) SchemaRoot() *yang.Entry {
+	return m.schemaTreeRoot
+}
+
+// SchemaEntry returns the schema entry of the node of path, whose keys are
+// ignored. It returns a NotFound error if the schema has no such node.
+func (m *Model) SchemaEntry(path *pb.Path) (*yang.Entry, error) {
+	entry := m.schemaEntry(path)
+	if entry == nil {
+		return nil, status.Errorf(codes.NotFound, "path %v is not found in the schema", path)
+	}
+	return entry, nil
+}
+
+// EnumValues returns the names of the values the enumeration or identityref
+// leaf of path can take, or nil for another kind of node. Enumeration values
+// are sorted by value and identities by name.
+// The values of a model with a generated GoStruct are those of its enum data,
+// so that they match what Get returns; the values of unions and of generic
+// models are taken from the schema.
+func (m *Model) EnumValues(path *pb.Path) ([]string, error) {
+	entry, err := m.SchemaEntry(path)
+	if err != nil {
+		return nil, err
+	}
+	if !m.isGeneric() {
+		if t := m.fieldType(path); t != nil {
+			if enum, ok := m.enumData[t.Name()]; ok {
+				return enumNames(enum), nil
+			}
+		}
+	}
+	return schemaEnumValues(entry.Type), nil
+}
+
+// fieldType returns the type of the GoStruct field of path, dereferencing
+// pointers, maps and slices, or nil if the GoStruct has no such field.
+func (m *Model) fieldType(path *pb.Path) reflect.Type {
+	t := m.structRootType
+	for elems := path.GetElem(); len(elems) > 0; {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Map || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		field, n := structField(t, elems)
+		if field == nil {
+			return nil
+		}
+		t, elems = field.Type, elems[n:]
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+// structField returns the field of the struct type t whose path tag matches
+// the first elements of elems, and the number of elements it matches.
+func structField(t reflect.Type, elems []*pb.PathElem) (*reflect.StructField, int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		for _, p := range strings.Split(field.Tag.Get("path"), "|") {
+			names := strings.Split(p, "/")
+			if p == "" || len(names) > len(elems) {
+				continue
+			}
+			match := true
+			for j, name := range names {
+				elemName := elems[j].GetName()
+				if k := strings.Index(elemName, ":"); k >= 0 {
+					elemName = elemName[k+1:]
+				}
+				if name != elemName {
+					match = false
+					break
+				}
+			}
+			if match {
+				return &field, len(names)
+			}
+		}
+	}
+	return nil, 0
+}
+
+// enumNames returns the names of the values of a GoStruct enum, sorted by
+// value for enumerations and by name for identities. The zero value, which
+// stands for an unset enum, is left out.
+func enumNames(enum map[int64]ygot.EnumDefinition) []string {
+	values := make([]int64, 0, len(enum))
+	for value := range enum {
+		if value != 0 {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	names := make([]string, len(values))
+	identities := false
+	for i, value := range values {
+		names[i] = enum[value].Name
+		identities = identities || enum[value].DefiningModule != ""
+	}
+	if identities {
+		sort.Strings(names)
+	}
+	return names
+}
+
+// schemaEnumValues returns the names of the values of an enumeration or
+// identityref type, or of the enumeration and identityref members of a union.
+func schemaEnumValues(t *yang.YangType) []string {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind {
+	case yang.Yenum:
+		var names []string
+		for _, value := range t.Enum.Values() {
+			names = append(names, t.Enum.Name(value))
+		}
+		return names
+	case yang.Yidentityref:
+		if t.IdentityBase == nil {
+			return nil
+		}
+		var names []string
+		for _, identity := range t.IdentityBase.Values {
+			names = append(names, identity.Name)
+		}
+		sort.Strings(names)
+		return names
+	case yang.Yunion:
+		var names []string
+		for _, member := range t.Type {
+			names = append(names, schemaEnumValues(member)...)
+		}
+		return names
+	}
+	return nil
+}
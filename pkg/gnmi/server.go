@@ -8,7 +8,7 @@ package gnmi
 import (
 	"github.com/eapache/channels"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
-	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
 )
 
 var log = logging.GetLogger("gnmi")
@@ -29,16 +29,32 @@ func NewServer(model *Model, config []byte, callback ConfigCallback) (*Server, e
 			return nil, err
 		}
 	}
-	// Initialize readOnlyUpdateValue variable
-
-	val := &pb.TypedValue{
-		Value: &pb.TypedValue_StringVal{
-			StringVal: "INIT_STATE",
-		},
-	}
-	s.readOnlyUpdateValue = &pb.Update{Path: nil, Val: val}
 	s.subscribers = make(map[string]*streamClient)
+	s.streams = make(map[uint64]*Subscription)
 	s.ConfigUpdate = channels.NewRingChannel(100)
 
 	return s, nil
 }
+
+// Model returns the model of the server.
+func (s *Server) Model() *Model {
+	return s.model
+}
+
+// UserFunc returns the user that issued the request of ctx, "" if unknown.
+type UserFunc func(ctx context.Context) string
+
+// SetUserFunc sets the function identifying the users recorded in the Set
+// history and the Subscribe streams, such as aaa.UsernameFromContext. It is
+// called before the server serves requests.
+func (s *Server) SetUserFunc(f UserFunc) {
+	s.userFunc = f
+}
+
+// user returns the user that issued the request of ctx, "" without a UserFunc.
+func (s *Server) user(ctx context.Context) string {
+	if s.userFunc == nil {
+		return ""
+	}
+	return s.userFunc(ctx)
+}
@@ -5,12 +5,16 @@ package gnmi
 
 import (
 	"encoding/json"
+	"io"
+	"math"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/openconfig/gnmi/value"
 	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -1095,6 +1099,89 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestSetHistory(t *testing.T) {
+	s, err := NewServer(model, []byte(`{}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating config server: %v", err)
+	}
+	type userKey struct{}
+	s.SetUserFunc(func(ctx context.Context) string {
+		if ctx == nil {
+			return ""
+		}
+		user, _ := ctx.Value(userKey{}).(string)
+		return user
+	})
+	ctx := context.WithValue(context.Background(), userKey{}, "alice")
+	var pbPath pb.Path
+	if err := proto.UnmarshalText(`elem: <name: "system" > elem: <name: "config" > elem: <name: "hostname" >`, &pbPath); err != nil {
+		t.Fatalf("error in unmarshaling path: %v", err)
+	}
+	valid := &pb.SetRequest{Update: []*pb.Update{{Path: &pbPath, Val: &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch_a"}}}}}
+	if _, err := s.Set(ctx, valid); err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	invalid := &pb.SetRequest{Update: []*pb.Update{{Path: &pb.Path{Elem: []*pb.PathElem{{Name: "foo"}}}, Val: valid.Update[0].Val}}}
+	if _, err := s.Set(nil, invalid); err == nil {
+		t.Fatal("Set of an unknown path: got nil error")
+	}
+
+	history := s.SetHistory()
+	if len(history) != 2 {
+		t.Fatalf("got %d Set records, want 2", len(history))
+	}
+	if history[0].User != "alice" || history[0].Request != valid || history[0].Err != nil {
+		t.Errorf("got first Set record %+v, want a successful request from alice", history[0])
+	}
+	if history[1].User != "" || history[1].Request != invalid || history[1].Err == nil {
+		t.Errorf("got second Set record %+v, want a failed request without user", history[1])
+	}
+}
+
+func TestSetChanges(t *testing.T) {
+	initConfig := `{
+		"openconfig-system:system": {"config": {"hostname": "switch_a"}},
+		"openconfig-interfaces:interfaces": {"interface": [
+			{"name": "eth1", "config": {"name": "eth1", "mtu": 1500}, "state": {"name": "eth1", "mtu": 1500}}
+		]}
+	}`
+	s, err := NewServer(model, []byte(initConfig), nil)
+	if err != nil {
+		t.Fatalf("error in creating config server: %v", err)
+	}
+	eth := func(name string) []*pb.PathElem {
+		return []*pb.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": name}}}
+	}
+	req := &pb.SetRequest{
+		Delete: []*pb.Path{{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}},
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: append(eth("eth1"), &pb.PathElem{Name: "config"}, &pb.PathElem{Name: "mtu"})},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_UintVal{UintVal: 9000}},
+		}, {
+			Path: &pb.Path{Elem: append(eth("eth2"), &pb.PathElem{Name: "config"})},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"name": "eth2", "description": "uplink"}`)}},
+		}},
+	}
+	_, changes, err := s.SetChanges(context.Background(), req)
+	if err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	want := []Change{
+		{Path: "/interfaces/interface[name=eth1]/config/mtu", Old: uint16(1500), New: uint16(9000)},
+		{Path: "/interfaces/interface[name=eth2]/config/description", New: "uplink"},
+		{Path: "/interfaces/interface[name=eth2]/config/name", New: "eth2"},
+		{Path: "/interfaces/interface[name=eth2]/name", New: "eth2"},
+		{Path: "/system/config/hostname", Old: "switch_a"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %+v, want %+v", changes, want)
+	}
+	invalid := &pb.SetRequest{Update: []*pb.Update{{Path: &pb.Path{Elem: []*pb.PathElem{{Name: "foo"}}}, Val: req.Update[0].Val}}}
+	if _, changes, err := s.SetChanges(context.Background(), invalid); err == nil || changes != nil {
+		t.Errorf("got changes %+v, %v of a failed Set, want an error and no changes", changes, err)
+	}
+}
+
 func runTestSet(t *testing.T, m *Model, tc gnmiSetTestCase) {
 	// Create a new server with empty config
 	s, err := NewServer(m, []byte(tc.initConfig), nil)
@@ -1146,3 +1233,652 @@ func runTestSet(t *testing.T, m *Model, tc gnmiSetTestCase) {
 		t.Fatalf("got server config %v\nwant: %v", gotConfigJSON, wantConfigJSON)
 	}
 }
+
+// subscribeStream is a Subscribe stream receiving requests from a slice.
+// Its sends fail with sendErr.
+type subscribeStream struct {
+	pb.GNMI_SubscribeServer
+	requests []*pb.SubscribeRequest
+	sendErr  error
+}
+
+func (s *subscribeStream) Send(*pb.SubscribeResponse) error {
+	return s.sendErr
+}
+
+func (s *subscribeStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *subscribeStream) Recv() (*pb.SubscribeRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func TestTarget(t *testing.T) {
+	s, err := NewServer(model, []byte(`{}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating config server: %v", err)
+	}
+	var pbPath pb.Path
+	if err := proto.UnmarshalText(`elem: <name: "system" > elem: <name: "config" > elem: <name: "hostname" >`, &pbPath); err != nil {
+		t.Fatalf("error in unmarshaling path: %v", err)
+	}
+	update := &pb.Update{Path: &pbPath, Val: &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch_a"}}}
+
+	// An unnamed server echoes the target of the requests.
+	setResp, err := s.Set(nil, &pb.SetRequest{Prefix: &pb.Path{Target: "any"}, Update: []*pb.Update{update}})
+	if err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	if target := setResp.GetPrefix().GetTarget(); target != "any" {
+		t.Errorf("got Set response target %q, want any", target)
+	}
+	getResp, err := s.Get(nil, &pb.GetRequest{Prefix: &pb.Path{Target: "any"}, Path: []*pb.Path{&pbPath}})
+	if err != nil {
+		t.Fatalf("error in Get: %v", err)
+	}
+	if target := getResp.GetNotification()[0].GetPrefix().GetTarget(); target != "any" {
+		t.Errorf("got notification target %q, want any", target)
+	}
+
+	s.SetTarget("switch-1")
+	setResp, err = s.Set(nil, &pb.SetRequest{Update: []*pb.Update{update}})
+	if err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	if target := setResp.GetPrefix().GetTarget(); target != "switch-1" {
+		t.Errorf("got Set response target %q, want switch-1", target)
+	}
+	getResp, err = s.Get(nil, &pb.GetRequest{Prefix: &pb.Path{Target: "switch-1"}, Path: []*pb.Path{&pbPath}})
+	if err != nil {
+		t.Fatalf("error in Get: %v", err)
+	}
+	if target := getResp.GetNotification()[0].GetPrefix().GetTarget(); target != "switch-1" {
+		t.Errorf("got notification target %q, want switch-1", target)
+	}
+
+	other := &pb.Path{Target: "switch-2"}
+	if _, err := s.Get(nil, &pb.GetRequest{Prefix: other, Path: []*pb.Path{&pbPath}}); status.Code(err) != codes.NotFound {
+		t.Errorf("Get for another target: got %v, want NotFound", err)
+	}
+	if _, err := s.Set(nil, &pb.SetRequest{Prefix: other, Update: []*pb.Update{update}}); status.Code(err) != codes.NotFound {
+		t.Errorf("Set for another target: got %v, want NotFound", err)
+	}
+	stream := &subscribeStream{requests: []*pb.SubscribeRequest{{Request: &pb.SubscribeRequest_Subscribe{
+		Subscribe: &pb.SubscriptionList{Prefix: other, Mode: pb.SubscriptionList_ONCE, Subscription: []*pb.Subscription{{Path: &pbPath}}},
+	}}}}
+	if err := s.Subscribe(stream); status.Code(err) != codes.NotFound {
+		t.Errorf("Subscribe for another target: got %v, want NotFound", err)
+	}
+
+	response, _ := buildSubResponse(&pb.Path{Target: "switch-1"}, update)
+	if target := response.GetUpdate().GetPrefix().GetTarget(); target != "switch-1" {
+		t.Errorf("got subscription notification target %q, want switch-1", target)
+	}
+}
+
+// recordingStream is a Subscribe stream receiving requests from a slice and
+// sending its responses to sent. Once the requests are received, it waits for
+// done to be closed before ending.
+type recordingStream struct {
+	subscribeStream
+	sent chan *pb.SubscribeResponse
+	done chan struct{}
+}
+
+func (s *recordingStream) Send(response *pb.SubscribeResponse) error {
+	s.sent <- response
+	return nil
+}
+
+func (s *recordingStream) Recv() (*pb.SubscribeRequest, error) {
+	if len(s.requests) == 0 {
+		<-s.done
+	}
+	return s.subscribeStream.Recv()
+}
+
+func TestSubscribePrefix(t *testing.T) {
+	s, err := NewServer(model, []byte(`{"openconfig-system:system": {"config": {"hostname": "switch_a"}}}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating config server: %v", err)
+	}
+	s.SetTarget("switch-1")
+	prefix := &pb.Path{Target: "switch-1", Origin: "openconfig", Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}}}
+	stream := &recordingStream{
+		subscribeStream: subscribeStream{requests: []*pb.SubscribeRequest{{Request: &pb.SubscribeRequest_Subscribe{
+			Subscribe: &pb.SubscriptionList{Prefix: prefix, Mode: pb.SubscriptionList_ONCE, Subscription: []*pb.Subscription{
+				{Path: &pb.Path{Elem: []*pb.PathElem{{Name: "hostname"}}}},
+			}},
+		}}}},
+		sent: make(chan *pb.SubscribeResponse, 10),
+		done: make(chan struct{}),
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- s.Subscribe(stream) }()
+	defer func() {
+		close(stream.done)
+		if err := <-errc; err != nil {
+			t.Errorf("error in Subscribe: %v", err)
+		}
+	}()
+
+	select {
+	case response := <-stream.sent:
+		notification := response.GetUpdate()
+		if want := (&pb.Path{Target: "switch-1"}); !proto.Equal(notification.GetPrefix(), want) {
+			t.Errorf("got notification prefix %v, want %v", notification.GetPrefix(), want)
+		}
+		want := &pb.Path{Origin: "openconfig", Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}
+		if len(notification.GetUpdate()) != 1 || !proto.Equal(notification.GetUpdate()[0].GetPath(), want) {
+			t.Fatalf("got updates %v, want an update of %v", notification.GetUpdate(), want)
+		}
+		if val := notification.GetUpdate()[0].GetVal().GetStringVal(); val != "switch_a" {
+			t.Errorf("got hostname %q, want switch_a", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no notification of the subscription")
+	}
+}
+
+func TestSchemaModel(t *testing.T) {
+	m, err := NewSchemaModel("testdata/yang")
+	if err != nil {
+		t.Fatalf("error in compiling the YANG modules: %v", err)
+	}
+	wantModels := []string{"example-lldp 2021-09-01", "example-switch 2022-01-15", "example-types 2021-06-01"}
+	if got := m.SupportedModels(); !reflect.DeepEqual(got, wantModels) {
+		t.Errorf("got supported models %v, want %v", got, wantModels)
+	}
+
+	s, err := NewServer(m, []byte(`{
+		"example-switch:switch": {
+			"config": {"hostname": "sw1", "mtu": 1500},
+			"ports": {"port": [{
+				"name": "eth0",
+				"config": {"name": "eth0", "enabled": true, "speed": "example-types:SPEED_10GB", "vlans": [10, 20], "example-lldp:lldp": true},
+				"state": {"in-octets": "18446744073709551615", "temperature": "-41.5"}
+			}]}
+		}
+	}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	if _, ok := s.config.(*Tree); !ok {
+		t.Fatalf("got config %T, want a *Tree", s.config)
+	}
+
+	portConfig := `elem: <name: "switch" > elem: <name: "ports" > elem: <name: "port" key: <key: "name" value: "eth0" > > elem: <name: "config" > `
+	getVal := func(textPbPath string) *pb.TypedValue {
+		var path pb.Path
+		if err := proto.UnmarshalText(textPbPath, &path); err != nil {
+			t.Fatalf("error in unmarshaling path: %v", err)
+		}
+		resp, err := s.Get(nil, &pb.GetRequest{Path: []*pb.Path{&path}, Encoding: pb.Encoding_JSON_IETF})
+		if err != nil {
+			t.Fatalf("error in Get of %s: %v", textPbPath, err)
+		}
+		return resp.GetNotification()[0].GetUpdate()[0].GetVal()
+	}
+	if got := getVal(`elem: <name: "switch" > elem: <name: "config" > elem: <name: "mtu" > `); got.GetUintVal() != 1500 {
+		t.Errorf("got mtu %v, want 1500", got)
+	}
+	if got := getVal(`elem: <name: "switch" > elem: <name: "ports" > elem: <name: "port" key: <key: "name" value: "eth0" > > elem: <name: "state" > elem: <name: "in-octets" > `); got.GetUintVal() != math.MaxUint64 {
+		t.Errorf("got in-octets %v, want %d", got, uint64(math.MaxUint64))
+	}
+	if got := getVal(`elem: <name: "switch" > elem: <name: "ports" > elem: <name: "port" key: <key: "name" value: "eth0" > > elem: <name: "state" > elem: <name: "temperature" > `).GetDecimalVal(); got.GetDigits() != -4150 || got.GetPrecision() != 2 {
+		t.Errorf("got temperature %v, want -4150 with precision 2", got)
+	}
+	if got := getVal(portConfig + `elem: <name: "vlans" > `); len(got.GetLeaflistVal().GetElement()) != 2 {
+		t.Errorf("got vlans %v, want 2 VLANs", got)
+	}
+	var gotConfig map[string]interface{}
+	if err := json.Unmarshal(getVal(portConfig).GetJsonIetfVal(), &gotConfig); err != nil {
+		t.Fatalf("error in unmarshaling the port config: %v", err)
+	}
+	wantConfig := map[string]interface{}{
+		"example-switch:name":    "eth0",
+		"example-switch:enabled": true,
+		"example-switch:speed":   "example-types:SPEED_10GB",
+		"example-switch:vlans":   []interface{}{10.0, 20.0},
+		"example-lldp:lldp":      true,
+	}
+	if !reflect.DeepEqual(gotConfig, wantConfig) {
+		t.Errorf("got port config %v, want %v", gotConfig, wantConfig)
+	}
+
+	set := func(textPbPath string, val *pb.TypedValue) error {
+		var path pb.Path
+		if err := proto.UnmarshalText(textPbPath, &path); err != nil {
+			t.Fatalf("error in unmarshaling path: %v", err)
+		}
+		_, err := s.Set(nil, &pb.SetRequest{Update: []*pb.Update{{Path: &path, Val: val}}})
+		return err
+	}
+	if err := set(portConfig+`elem: <name: "mode" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "TRUNK"}}); err != nil {
+		t.Errorf("error in Set of an enumeration: %v", err)
+	}
+	if err := set(portConfig+`elem: <name: "description" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "uplink"}}); err != nil {
+		t.Errorf("error in Set of a union: %v", err)
+	}
+	if err := set(`elem: <name: "switch" > elem: <name: "ports" > elem: <name: "port" key: <key: "name" value: "eth1" > > `,
+		&pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"config": {"name": "eth1", "example-lldp:lldp": false}}`)}}); err != nil {
+		t.Errorf("error in Set of a list entry: %v", err)
+	}
+	if got := getVal(`elem: <name: "switch" > elem: <name: "ports" > elem: <name: "port" key: <key: "name" value: "eth1" > > elem: <name: "config" > elem: <name: "lldp" > `); got.GetBoolVal() {
+		t.Errorf("got lldp %v, want false", got)
+	}
+
+	for _, tc := range []struct {
+		desc       string
+		textPbPath string
+		val        *pb.TypedValue
+		wantCode   codes.Code
+	}{
+		{"out of range", `elem: <name: "switch" > elem: <name: "config" > elem: <name: "mtu" > `, &pb.TypedValue{Value: &pb.TypedValue_UintVal{UintVal: 10}}, codes.InvalidArgument},
+		{"pattern mismatch", `elem: <name: "switch" > elem: <name: "config" > elem: <name: "hostname" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "sw 1"}}, codes.InvalidArgument},
+		{"unknown enumeration", portConfig + `elem: <name: "mode" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "HYBRID"}}, codes.InvalidArgument},
+		{"unknown identity", portConfig + `elem: <name: "speed" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "SPEED_1TB"}}, codes.InvalidArgument},
+		{"unknown node", `elem: <name: "switch" > elem: <name: "config" > `, &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"domain": "example.com"}`)}}, codes.InvalidArgument},
+		{"unknown path", `elem: <name: "switch" > elem: <name: "clock" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "UTC"}}, codes.NotFound},
+	} {
+		if err := set(tc.textPbPath, tc.val); status.Code(err) != tc.wantCode {
+			t.Errorf("Set with %s: got %v, want %v", tc.desc, err, tc.wantCode)
+		}
+	}
+	if got := getVal(`elem: <name: "switch" > elem: <name: "config" > elem: <name: "mtu" > `); got.GetUintVal() != 1500 {
+		t.Errorf("a failed Set changed the mtu to %v", got)
+	}
+}
+
+func TestMirroring(t *testing.T) {
+	s, err := NewServer(model, []byte(`{
+		"openconfig-system:system": {"config": {"hostname": "switch_a"}},
+		"openconfig-interfaces:interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0"}}]}
+	}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	adminStatus := func(path *pb.Path, value interface{}) interface{} {
+		if value == true {
+			return "UP"
+		}
+		return "DOWN"
+	}
+	derivations := []Derivation{
+		{From: "/interfaces/interface/config/enabled", To: "/interfaces/interface/state/admin-status", Derive: adminStatus},
+		{From: "/interfaces/interface/state/admin-status", To: "/interfaces/interface/state/oper-status", Delayed: true},
+	}
+	if err := s.EnableMirroring(derivations, 10*time.Millisecond); err != nil {
+		t.Fatalf("error in enabling mirroring: %v", err)
+	}
+
+	const (
+		hostname = `elem: <name: "system" > elem: <name: "state" > elem: <name: "hostname" > `
+		eth0     = `elem: <name: "interfaces" > elem: <name: "interface" key: <key: "name" value: "eth0" > > `
+	)
+	getVal := func(textPbPath string) *pb.TypedValue {
+		var path pb.Path
+		if err := proto.UnmarshalText(textPbPath, &path); err != nil {
+			t.Fatalf("error in unmarshaling path: %v", err)
+		}
+		resp, err := s.Get(nil, &pb.GetRequest{Path: []*pb.Path{&path}})
+		if err != nil {
+			return nil
+		}
+		return resp.GetNotification()[0].GetUpdate()[0].GetVal()
+	}
+	waitVal := func(textPbPath, want string) {
+		deadline := time.Now().Add(time.Second)
+		for getVal(textPbPath).GetStringVal() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("got %s %v, want %s", textPbPath, getVal(textPbPath), want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// The startup config is mirrored, the defaults included.
+	if got := getVal(hostname).GetStringVal(); got != "switch_a" {
+		t.Errorf("got state hostname %q, want switch_a", got)
+	}
+	if got := getVal(eth0 + `elem: <name: "state" > elem: <name: "enabled" > `); !got.GetBoolVal() {
+		t.Errorf("got state enabled %v, want the default true", got)
+	}
+	if got := getVal(eth0 + `elem: <name: "state" > elem: <name: "admin-status" > `).GetStringVal(); got != "UP" {
+		t.Errorf("got admin-status %q, want UP", got)
+	}
+	waitVal(eth0+`elem: <name: "state" > elem: <name: "oper-status" > `, "UP")
+
+	set := func(textPbPath string, val *pb.TypedValue) []string {
+		var path pb.Path
+		if err := proto.UnmarshalText(textPbPath, &path); err != nil {
+			t.Fatalf("error in unmarshaling path: %v", err)
+		}
+		if _, err := s.Set(nil, &pb.SetRequest{Update: []*pb.Update{{Path: &path, Val: val}}}); err != nil {
+			t.Fatalf("error in Set: %v", err)
+		}
+		var notified []string
+		for {
+			select {
+			case v := <-s.ConfigUpdate.Out():
+				notified = append(notified, v.(*pb.Update).GetPath().String())
+			case <-time.After(20 * time.Millisecond):
+				return notified
+			}
+		}
+	}
+	notified := set(`elem: <name: "system" > elem: <name: "config" > elem: <name: "hostname" > `, &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch_b"}})
+	if got := getVal(hostname).GetStringVal(); got != "switch_b" {
+		t.Errorf("got state hostname %q after Set, want switch_b", got)
+	}
+	var hostnamePath pb.Path
+	if err := proto.UnmarshalText(hostname, &hostnamePath); err != nil {
+		t.Fatalf("error in unmarshaling path: %v", err)
+	}
+	if !Contains(notified, hostnamePath.String()) {
+		t.Errorf("got notifications for %v, want one for the state hostname", notified)
+	}
+
+	set(eth0+`elem: <name: "config" > elem: <name: "enabled" > `, &pb.TypedValue{Value: &pb.TypedValue_BoolVal{BoolVal: false}})
+	if got := getVal(eth0 + `elem: <name: "state" > elem: <name: "admin-status" > `).GetStringVal(); got != "DOWN" {
+		t.Errorf("got admin-status %q after disabling, want DOWN", got)
+	}
+	waitVal(eth0+`elem: <name: "state" > elem: <name: "oper-status" > `, "DOWN")
+
+	if err := s.EnableMirroring([]Derivation{{From: "/interfaces/interface/config/enabled", To: "/interfaces/interface/config/name"}}, 0); err == nil {
+		t.Error("enabling a derivation of a config leaf succeeded, want an error")
+	}
+}
+
+func TestAlarms(t *testing.T) {
+	s, err := NewServer(model, []byte(`{
+		"openconfig-interfaces:interfaces": {"interface": [
+			{"name": "eth0", "config": {"name": "eth0"}, "state": {"oper-status": "UP"}},
+			{"name": "eth1", "config": {"name": "eth1"}, "state": {"oper-status": "DOWN"}}
+		]}
+	}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	err = s.SetAlarmRules([]AlarmRule{{
+		Name:     "interface-down",
+		Path:     "/interfaces/interface/state",
+		Raised:   func(node map[string]interface{}) bool { return node["oper-status"] == "DOWN" },
+		Severity: "MAJOR",
+		TypeID:   "LOS",
+		Text:     func(resource string, node map[string]interface{}) string { return resource + " is down" },
+	}})
+	if err != nil {
+		t.Fatalf("error in setting the alarm rules: %v", err)
+	}
+
+	notifications := func() []string {
+		var notified []string
+		for {
+			select {
+			case v := <-s.ConfigUpdate.Out():
+				notified = append(notified, v.(*pb.Update).GetPath().String())
+			case <-time.After(20 * time.Millisecond):
+				return notified
+			}
+		}
+	}
+	alarmEntryPath := func(id string) *pb.Path {
+		return &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "alarms"}, {Name: "alarm", Key: map[string]string{"id": id}}}}
+	}
+	alarmPath := func(id string) *pb.Path {
+		return &pb.Path{Elem: append(alarmEntryPath(id).GetElem(), &pb.PathElem{Name: "state"}, &pb.PathElem{Name: "severity"})}
+	}
+	getSeverity := func(id string) (string, error) {
+		resp, err := s.Get(nil, &pb.GetRequest{Path: []*pb.Path{alarmPath(id)}})
+		if err != nil {
+			return "", err
+		}
+		return resp.GetNotification()[0].GetUpdate()[0].GetVal().GetStringVal(), nil
+	}
+
+	if err := s.EvaluateAlarms(); err != nil {
+		t.Fatalf("error in evaluating the alarms: %v", err)
+	}
+	if got, err := getSeverity("interface-down:eth1"); err != nil || got != "MAJOR" {
+		t.Errorf("got severity %q, %v of the alarm of eth1, want MAJOR", got, err)
+	}
+	if !Contains(notifications(), alarmPath("interface-down:eth1").String()) {
+		t.Error("the alarm of eth1 was not notified")
+	}
+	alarms, err := s.Alarms()
+	if err != nil {
+		t.Fatalf("error in listing the alarms: %v", err)
+	}
+	if len(alarms) != 1 || alarms[0].Resource != "eth1" || alarms[0].TypeID != "LOS" || alarms[0].Text != "eth1 is down" || alarms[0].TimeCreated.IsZero() {
+		t.Errorf("got alarms %+v, want the alarm of eth1", alarms)
+	}
+
+	// An alarm still raised keeps the time it was created.
+	if err := s.EvaluateAlarms(); err != nil {
+		t.Fatalf("error in evaluating the alarms: %v", err)
+	}
+	if again, _ := s.Alarms(); len(again) != 1 || !again[0].TimeCreated.Equal(alarms[0].TimeCreated) {
+		t.Errorf("got alarms %+v after a second evaluation, want them unchanged", again)
+	}
+	if got := notifications(); len(got) != 0 {
+		t.Errorf("got notifications %v of unchanged alarms", got)
+	}
+
+	if err := s.RaiseAlarm(Alarm{ID: "fan", Resource: "FAN0", Severity: "CRITICAL", TypeID: "EQPT", Text: "fan failure"}); err != nil {
+		t.Fatalf("error in raising an alarm: %v", err)
+	}
+	if got, err := getSeverity("fan"); err != nil || got != "CRITICAL" {
+		t.Errorf("got severity %q, %v of the raised alarm, want CRITICAL", got, err)
+	}
+	if err := s.RaiseAlarm(Alarm{ID: "fan", Severity: "SEVERE"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("raising an alarm of unknown severity got %v, want InvalidArgument", err)
+	}
+	notifications()
+
+	// The alarm of eth1 is cleared once eth1 is up.
+	if err := s.updateStateLeaf(`elem: <name: "interfaces" > elem: <name: "interface" key: <key: "name" value: "eth1" > > elem: <name: "state" > elem: <name: "oper-status" > `,
+		&pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "UP"}}); err != nil {
+		t.Fatalf("error in updating oper-status: %v", err)
+	}
+	if err := s.EvaluateAlarms(); err != nil {
+		t.Fatalf("error in evaluating the alarms: %v", err)
+	}
+	if _, err := getSeverity("interface-down:eth1"); status.Code(err) != codes.NotFound {
+		t.Errorf("got %v for the cleared alarm, want NotFound", err)
+	}
+	if !Contains(notifications(), alarmEntryPath("interface-down:eth1").String()) {
+		t.Error("the clearing of the alarm of eth1 was not notified")
+	}
+
+	if err := s.ClearAlarm("fan"); err != nil {
+		t.Errorf("error in clearing an alarm: %v", err)
+	}
+	if err := s.ClearAlarm("fan"); status.Code(err) != codes.NotFound {
+		t.Errorf("clearing a cleared alarm got %v, want NotFound", err)
+	}
+	if alarms, _ := s.Alarms(); len(alarms) != 0 {
+		t.Errorf("got alarms %+v, want none", alarms)
+	}
+
+	if err := s.SetAlarmRules([]AlarmRule{{Name: "bad", Path: "/interfaces/interface/state/mtu", Raised: func(map[string]interface{}) bool { return true }}}); err == nil {
+		t.Error("setting a rule over a leaf succeeded, want an error")
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	s, err := NewServer(model, []byte(`{}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	if err := s.SetCapacity(Capacity{MaxSubscriptions: -1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("setting a negative capacity got %v, want InvalidArgument", err)
+	}
+	if err := s.SetCapacity(Capacity{MaxSubscriptions: 1, NotificationRate: 10, MaxSetSize: 100, SetTimePerKB: time.Second}); err != nil {
+		t.Fatalf("error in setting the capacity: %v", err)
+	}
+
+	ctx := context.Background()
+	id, down, err := s.acquireSubscription(ctx)
+	if err != nil {
+		t.Fatalf("error in acquiring a subscription: %v", err)
+	}
+	if _, _, err := s.acquireSubscription(ctx); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("acquiring a subscription beyond the maximum got %v, want ResourceExhausted", err)
+	}
+
+	// The streams end when the target goes down, and none starts until it is up.
+	s.SetAvailable(false)
+	select {
+	case <-down:
+	default:
+		t.Error("the subscription was not closed when the target went down")
+	}
+	if subscriptions := s.Subscriptions(); len(subscriptions) != 0 {
+		t.Errorf("got subscriptions %+v while the target is down, want none", subscriptions)
+	}
+	if _, _, err := s.acquireSubscription(ctx); status.Code(err) != codes.Unavailable {
+		t.Errorf("acquiring a subscription while the target is down got %v, want Unavailable", err)
+	}
+	s.releaseSubscription(id)
+	s.SetAvailable(true)
+
+	// A second of notifications is sent at once, the next ones wait.
+	for i := 0; i < 10; i++ {
+		if wait := s.reserveNotification(); wait > 0 {
+			t.Fatalf("notification %d waits %v, want no wait", i, wait)
+		}
+	}
+	if wait := s.reserveNotification(); wait <= 0 || wait > 100*time.Millisecond {
+		t.Errorf("notification beyond the burst waits %v, want up to 100ms", wait)
+	}
+	id, _, err = s.acquireSubscription(ctx)
+	if err != nil {
+		t.Fatalf("error in acquiring a subscription while throttling: %v", err)
+	}
+	s.releaseSubscription(id)
+	for i := 0; i < 10; i++ {
+		s.reserveNotification()
+	}
+	if _, _, err := s.acquireSubscription(ctx); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("acquiring a subscription with a second of notifications waiting got %v, want ResourceExhausted", err)
+	}
+
+	hostname := func(name string) *pb.SetRequest {
+		return &pb.SetRequest{Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: name}},
+		}}}
+	}
+	start := time.Now()
+	if _, err := s.Set(context.Background(), hostname("switch-1")); err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	if elapsed, want := time.Since(start), time.Second*time.Duration(proto.Size(hostname("switch-1")))/1024; elapsed < want {
+		t.Errorf("Set took %v, want at least %v", elapsed, want)
+	}
+	if _, err := s.Set(context.Background(), hostname(string(make([]byte, 100)))); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Set larger than the maximum got %v, want ResourceExhausted", err)
+	}
+
+	if interval := s.sampleInterval(); interval != lowestSampleInterval {
+		t.Errorf("lowest sample interval is %d, want %d", interval, lowestSampleInterval)
+	}
+	if err := s.SetCapacity(Capacity{LowestSampleInterval: time.Second}); err != nil {
+		t.Fatalf("error in setting the capacity: %v", err)
+	}
+	stream := &subscribeStream{requests: []*pb.SubscribeRequest{{Request: &pb.SubscribeRequest_Subscribe{
+		Subscribe: &pb.SubscriptionList{Mode: pb.SubscriptionList_STREAM, Subscription: []*pb.Subscription{{
+			Path:           &pb.Path{Elem: []*pb.PathElem{{Name: "system"}}},
+			Mode:           pb.SubscriptionMode_SAMPLE,
+			SampleInterval: uint64(time.Millisecond),
+		}}},
+	}}}}
+	if err := s.Subscribe(stream); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Subscribe below the lowest sample interval got %v, want InvalidArgument", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	s, err := NewServer(model, []byte(`{"openconfig-system:system": {"config": {"hostname": "switch1", "domain-name": "example.net"}}}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	if leaves, err := s.ConfigLeaves(); err != nil || leaves != 2 {
+		t.Errorf("tree has %d leaves, %v, want 2", leaves, err)
+	}
+
+	// The changes overflowing the ring channel are only dropped notifications
+	// while streams are active.
+	path := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}}}
+	for i := 0; i <= int(s.ConfigUpdate.Cap()); i++ {
+		s.NotifyUpdate(path)
+	}
+	id, _, err := s.acquireSubscription(context.Background())
+	if err != nil {
+		t.Fatalf("error in acquiring a subscription: %v", err)
+	}
+	s.NotifyUpdate(path)
+	s.releaseSubscription(id)
+
+	update := &pb.SubscribeResponse{Response: &pb.SubscribeResponse_Update{Update: &pb.Notification{}}}
+	s.sendResponse(update, &subscribeStream{})
+	s.sendResponse(buildSyncResponse(), &subscribeStream{})
+	s.sendResponse(update, &subscribeStream{sendErr: status.Error(codes.Unavailable, "closed")})
+
+	set := &pb.SetRequest{Update: []*pb.Update{{
+		Path: &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "unknown"}}},
+		Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "x"}},
+	}}}
+	if _, err := s.Set(context.Background(), set); status.Code(err) != codes.NotFound {
+		t.Errorf("Set of an unknown path got %v, want NotFound", err)
+	}
+	systemConfig := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}}}
+	for _, config := range []string{`{"hostname": 5}`, `{"hostname": "not a hostname!"}`, `{"hostname": "-"}`} {
+		set = &pb.SetRequest{Update: []*pb.Update{{
+			Path: systemConfig,
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(config)}},
+		}}}
+		if _, err := s.Set(context.Background(), set); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Set of %s got %v, want InvalidArgument", config, err)
+		}
+	}
+
+	want := Stats{
+		NotificationsSent:    1,
+		NotificationsDropped: map[string]uint64{DropOverflow: 1, DropSend: 1},
+		SetFailures:          map[string]uint64{SetUnknownPath: 1, SetInvalidValue: 1, SetConstraint: 2},
+	}
+	if stats := s.Stats(); !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats are %+v, want %+v", stats, want)
+	}
+}
+
+func TestHasPrefix(t *testing.T) {
+	path := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "alarms"}, {Name: "alarm", Key: map[string]string{"id": "fan"}}, {Name: "state"}, {Name: "severity"}}}
+	tests := []struct {
+		prefix *pb.Path
+		want   bool
+	}{
+		{&pb.Path{}, true},
+		{&pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "alarms"}}}, true},
+		{&pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "alarms"}, {Name: "alarm"}}}, true},
+		{&pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "alarms"}, {Name: "alarm", Key: map[string]string{"id": "*"}}}}, true},
+		{&pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "*"}, {Name: "alarm", Key: map[string]string{"id": "fan"}}}}, true},
+		{path, true},
+		{&pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "alarms"}, {Name: "alarm", Key: map[string]string{"id": "psu"}}}}, false},
+		{&pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}}}, false},
+		{&pb.Path{Elem: append(path.GetElem(), &pb.PathElem{Name: "extra"})}, false},
+	}
+	for _, tt := range tests {
+		if got := hasPrefix(path, tt.prefix); got != tt.want {
+			t.Errorf("hasPrefix(%v, %v) = %v, want %v", path, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -59,14 +59,14 @@ func (s *Server) doDelete(jsonTree map[string]interface{}, prefix, path *pb.Path
 	if pathDeleted {
 		newConfig, err := s.toGoStruct(jsonTree)
 		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
+			return nil, setErrorf(SetConstraint, codes.Internal, "%v", err)
 		}
 		if s.callback != nil {
 			if applyErr := s.callback(newConfig); applyErr != nil {
 				if rollbackErr := s.callback(s.config); rollbackErr != nil {
 					return nil, status.Errorf(codes.Internal, "error in rollback the failed operation (%v): %v", applyErr, rollbackErr)
 				}
-				return nil, status.Errorf(codes.Aborted, "error in applying operation to device: %v", applyErr)
+				return nil, setErrorf(SetRejected, codes.Aborted, "error in applying operation to device: %v", applyErr)
 			}
 		}
 	}
@@ -82,29 +82,36 @@ func (s *Server) doDelete(jsonTree map[string]interface{}, prefix, path *pb.Path
 func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.UpdateResult_Operation, prefix, path *pb.Path, val *pb.TypedValue) (*pb.UpdateResult, error) {
 	// Validate the operation.
 	fullPath := gnmiFullPath(prefix, path)
-	emptyNode, stat := ygotutils.NewNode(s.model.structRootType, fullPath)
-	if stat.GetCode() != int32(cpb.Code_OK) {
-		return nil, status.Errorf(codes.NotFound, "path %v is not found in the config structure: %v", fullPath, stat)
-	}
 	var nodeVal interface{}
-	nodeStruct, ok := emptyNode.(ygot.ValidatedGoStruct)
-	if ok {
-		if err := s.model.jsonUnmarshaler(val.GetJsonIetfVal(), nodeStruct); err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "unmarshaling json data to config struct fails: %v", err)
-		}
-		if err := nodeStruct.Validate(); err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "config data validation fails: %v", err)
-		}
+	if s.model.isGeneric() {
 		var err error
-		if nodeVal, err = ygot.ConstructIETFJSON(nodeStruct, &ygot.RFC7951JSONConfig{}); err != nil {
-			msg := fmt.Sprintf("error in constructing IETF JSON tree from config struct: %v", err)
-			log.Error(msg)
-			return nil, status.Error(codes.Internal, msg)
+		if nodeVal, err = s.model.treeValue(fullPath, val); err != nil {
+			return nil, err
 		}
 	} else {
-		var err error
-		if nodeVal, err = value.ToScalar(val); err != nil {
-			return nil, status.Errorf(codes.Internal, "cannot convert leaf node to scalar type: %v", err)
+		emptyNode, stat := ygotutils.NewNode(s.model.structRootType, fullPath)
+		if stat.GetCode() != int32(cpb.Code_OK) {
+			return nil, setErrorf(SetUnknownPath, codes.NotFound, "path %v is not found in the config structure: %v", fullPath, stat)
+		}
+		nodeStruct, ok := emptyNode.(ygot.ValidatedGoStruct)
+		if ok {
+			if err := s.model.jsonUnmarshaler(val.GetJsonIetfVal(), nodeStruct); err != nil {
+				return nil, setErrorf(SetInvalidValue, codes.InvalidArgument, "unmarshaling json data to config struct fails: %v", err)
+			}
+			if err := nodeStruct.Validate(); err != nil {
+				return nil, setErrorf(SetConstraint, codes.InvalidArgument, "config data validation fails: %v", err)
+			}
+			var err error
+			if nodeVal, err = ygot.ConstructIETFJSON(nodeStruct, &ygot.RFC7951JSONConfig{}); err != nil {
+				msg := fmt.Sprintf("error in constructing IETF JSON tree from config struct: %v", err)
+				log.Error(msg)
+				return nil, status.Error(codes.Internal, msg)
+			}
+		} else {
+			var err error
+			if nodeVal, err = value.ToScalar(val); err != nil {
+				return nil, status.Errorf(codes.Internal, "cannot convert leaf node to scalar type: %v", err)
+			}
 		}
 	}
 
@@ -129,21 +136,21 @@ func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.Update
 			}
 
 			if curNode, schema = getChildNode(node, schema, elem, true); curNode == nil {
-				return nil, status.Errorf(codes.NotFound, "path elem not found: %v", elem)
+				return nil, setErrorf(SetUnknownPath, codes.NotFound, "path elem not found: %v", elem)
 			}
 		case []interface{}:
-			return nil, status.Errorf(codes.NotFound, "incompatible path elem: %v", elem)
+			return nil, setErrorf(SetUnknownPath, codes.NotFound, "incompatible path elem: %v", elem)
 		default:
 			return nil, status.Errorf(codes.Internal, "wrong node type: %T", curNode)
 		}
 	}
 	if reflect.DeepEqual(fullPath, pbRootPath) { // Replace/Update root.
 		if op == pb.UpdateResult_UPDATE {
-			return nil, status.Error(codes.Unimplemented, "update the root of config tree is unsupported")
+			return nil, setErrorf(SetUnsupported, codes.Unimplemented, "update the root of config tree is unsupported")
 		}
 		nodeValAsTree, ok := nodeVal.(map[string]interface{})
 		if !ok {
-			return nil, status.Errorf(codes.InvalidArgument, "expect a tree to replace the root, got a scalar value: %T", nodeVal)
+			return nil, setErrorf(SetInvalidValue, codes.InvalidArgument, "expect a tree to replace the root, got a scalar value: %T", nodeVal)
 		}
 		for k := range jsonTree {
 			delete(jsonTree, k)
@@ -154,7 +161,7 @@ func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.Update
 	}
 	newConfig, err := s.toGoStruct(jsonTree)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, setErrorf(SetConstraint, codes.Internal, "%v", err)
 	}
 
 	// Apply the validated operation to the device.
@@ -163,7 +170,7 @@ func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.Update
 			if rollbackErr := s.callback(s.config); rollbackErr != nil {
 				return nil, status.Errorf(codes.Internal, "error in rollback the failed operation (%v): %v", applyErr, rollbackErr)
 			}
-			return nil, status.Errorf(codes.Aborted, "error in applying operation to device: %v", applyErr)
+			return nil, setErrorf(SetRejected, codes.Aborted, "error in applying operation to device: %v", applyErr)
 		}
 	}
 	return &pb.UpdateResult{
@@ -174,15 +181,36 @@ func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.Update
 
 // Set implements the Set RPC in gNMI spec.
 func (s *Server) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse, error) {
+	setResponse, _, err := s.SetChanges(ctx, req)
+	return setResponse, err
+}
+
+// SetChanges handles a Set request like Set, and also returns the changes of
+// the config leaves the request made, sorted by path, if it was applied.
+func (s *Server) SetChanges(ctx context.Context, req *pb.SetRequest) (setResponse *pb.SetResponse, changes []Change, err error) {
+	defer func() {
+		s.recordSet(ctx, req, err)
+		s.countSet(err)
+	}()
+	if err := s.checkAvailable(); err != nil {
+		return nil, nil, setFailure(SetUnavailable, err)
+	}
+	if err := s.checkTarget(req.GetPrefix()); err != nil {
+		return nil, nil, setFailure(SetUnknownTarget, err)
+	}
+	if err := s.processSet(ctx, req); err != nil {
+		return nil, nil, err
+	}
 	s.configMu.Lock()
 	defer s.configMu.Unlock()
 
-	jsonTree, err := ygot.ConstructIETFJSON(s.config, &ygot.RFC7951JSONConfig{})
+	jsonTree, err := configJSON(s.config)
 	if err != nil {
 		msg := fmt.Sprintf("error in constructing IETF JSON tree from config struct: %v", err)
 		log.Error(msg)
-		return nil, status.Error(codes.Internal, msg)
+		return nil, nil, status.Error(codes.Internal, msg)
 	}
+	oldTree := copyJSON(jsonTree).(map[string]interface{})
 
 	prefix := req.GetPrefix()
 	var results []*pb.UpdateResult
@@ -190,50 +218,60 @@ func (s *Server) Set(ctx context.Context, req *pb.SetRequest) (*pb.SetResponse,
 	for _, path := range req.GetDelete() {
 		res, grpcStatusError := s.doDelete(jsonTree, prefix, path)
 		if grpcStatusError != nil {
-			return nil, grpcStatusError
+			return nil, nil, grpcStatusError
 		}
 		results = append(results, res)
 	}
 	for _, upd := range req.GetReplace() {
 		res, grpcStatusError := s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_REPLACE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
-			return nil, grpcStatusError
+			return nil, nil, grpcStatusError
 		}
 		results = append(results, res)
 	}
 	for _, upd := range req.GetUpdate() {
 		res, grpcStatusError := s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_UPDATE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
-			return nil, grpcStatusError
+			return nil, nil, grpcStatusError
 		}
 		results = append(results, res)
 	}
 
+	mirrored := s.mirrorState(oldTree, jsonTree)
+
 	jsonDump, err := json.Marshal(jsonTree)
 	if err != nil {
 		msg := fmt.Sprintf("error in marshaling IETF JSON tree to bytes: %v", err)
 		log.Error(msg)
-		return nil, status.Error(codes.Internal, msg)
+		return nil, nil, status.Error(codes.Internal, msg)
 	}
 	rootStruct, err := s.model.NewConfigStruct(jsonDump)
 	if err != nil {
 		msg := fmt.Sprintf("error in creating config struct from IETF JSON data: %v", err)
 		log.Error(msg)
-		return nil, status.Error(codes.Internal, msg)
+		return nil, nil, status.Error(codes.Internal, msg)
 	}
-	log.Infof("Json tree: %v", jsonTree)
 
 	s.config = rootStruct
-	setResponse := &pb.SetResponse{
-		Prefix:   req.GetPrefix(),
+	// The tree of the new config struct holds the values with their schema
+	// types, unlike the scalars of the updates in the json tree.
+	if newTree, err := configJSON(rootStruct); err == nil {
+		changes = s.configChanges(oldTree, newTree)
+	} else {
+		log.Errorf("error in constructing IETF JSON tree from config struct: %v", err)
+	}
+	log.Debugf("Config changes: %v", changes)
+	setResponse = &pb.SetResponse{
+		Prefix:   s.responsePrefix(req.GetPrefix()),
 		Response: results,
 	}
 
 	for _, response := range setResponse.GetResponse() {
 		update := &pb.Update{
-			Path: response.GetPath(),
+			Path: gnmiFullPath(prefix, response.GetPath()),
 		}
-		s.ConfigUpdate.In() <- update
+		s.notify(update)
 	}
-	return setResponse, nil
+	s.notifyPaths(mirrored)
+	return setResponse, changes, nil
 }
@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"errors"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reasons for which notifications are dropped.
+const (
+	// DropOverflow is a change dropped by the ConfigUpdate ring channel, full
+	// of changes not yet notified to the ON_CHANGE subscribers.
+	DropOverflow = "overflow"
+	// DropSend is a notification that could not be sent on its stream.
+	DropSend = "send"
+)
+
+// Reasons for which Set requests fail.
+const (
+	// SetUnavailable is a request to a target that is not available, e.g.
+	// while it reboots.
+	SetUnavailable = "unavailable"
+	// SetUnknownTarget is a request whose prefix names another target.
+	SetUnknownTarget = "unknown_target"
+	// SetTooLarge is a request larger than the capacity of the target.
+	SetTooLarge = "too_large"
+	// SetUnknownPath is a path that is neither in the schema nor in the tree.
+	SetUnknownPath = "unknown_path"
+	// SetInvalidValue is a value that does not decode into the type of its
+	// path.
+	SetInvalidValue = "invalid_value"
+	// SetConstraint is a value, or a resulting tree, failing a constraint of
+	// the schema, e.g. a range, a pattern or a leafref.
+	SetConstraint = "constraint"
+	// SetUnsupported is an operation the server does not implement.
+	SetUnsupported = "unsupported"
+	// SetRejected is an operation the device callback rejected.
+	SetRejected = "rejected"
+	// SetOther is a request failing for any other reason, e.g. an internal
+	// error or a cancelled request.
+	SetOther = "other"
+)
+
+// Stats are the counters of a server since it was created.
+type Stats struct {
+	// NotificationsSent is the number of notifications sent to the
+	// subscribers, not counting the sync responses.
+	NotificationsSent uint64
+	// NotificationsDropped is the number of notifications dropped, by reason.
+	NotificationsDropped map[string]uint64
+	// SetFailures is the number of failed Set requests, by reason.
+	SetFailures map[string]uint64
+}
+
+// Stats returns the counters of the server.
+func (s *Server) Stats() Stats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	stats := Stats{
+		NotificationsSent:    s.stats.NotificationsSent,
+		NotificationsDropped: make(map[string]uint64),
+		SetFailures:          make(map[string]uint64),
+	}
+	for reason, n := range s.stats.NotificationsDropped {
+		stats.NotificationsDropped[reason] = n
+	}
+	for reason, n := range s.stats.SetFailures {
+		stats.SetFailures[reason] = n
+	}
+	return stats
+}
+
+// ConfigLeaves returns the number of leaves and leaf-list values of the tree.
+func (s *Server) ConfigLeaves() (int, error) {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	jsonTree, err := configJSON(s.config)
+	if err != nil {
+		return 0, err
+	}
+	return countLeaves(jsonTree), nil
+}
+
+// notify queues update for the ON_CHANGE subscribers, counting the change
+// the ring channel drops when it is full while streams are active: without
+// subscribers, nothing reads the channel.
+func (s *Server) notify(update *pb.Update) {
+	if s.ConfigUpdate.Len() >= int(s.ConfigUpdate.Cap()) && s.subscribed() {
+		s.countDrop(DropOverflow)
+	}
+	s.ConfigUpdate.In() <- update
+}
+
+// notifyPaths queues the changes of paths for the ON_CHANGE subscribers,
+// each path once.
+func (s *Server) notifyPaths(paths []*pb.Path) {
+	notified := make(map[string]bool)
+	for _, path := range paths {
+		if key := path.String(); !notified[key] {
+			notified[key] = true
+			s.notify(&pb.Update{Path: path})
+		}
+	}
+}
+
+// countSent counts a notification sent, or dropped when err is not nil.
+func (s *Server) countSent(err error) {
+	if err != nil {
+		s.countDrop(DropSend)
+		return
+	}
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.stats.NotificationsSent++
+}
+
+// countDrop counts a notification dropped for reason.
+func (s *Server) countDrop(reason string) {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if s.stats.NotificationsDropped == nil {
+		s.stats.NotificationsDropped = make(map[string]uint64)
+	}
+	s.stats.NotificationsDropped[reason]++
+}
+
+// countSet counts a Set request that failed with err, if not nil.
+func (s *Server) countSet(err error) {
+	if err == nil {
+		return
+	}
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	if s.stats.SetFailures == nil {
+		s.stats.SetFailures = make(map[string]uint64)
+	}
+	reason := SetOther
+	var setErr *setError
+	if errors.As(err, &setErr) {
+		reason = setErr.reason
+	}
+	s.stats.SetFailures[reason]++
+}
+
+// setError is the status error of a failed Set request, with the reason it is
+// counted under.
+type setError struct {
+	reason string
+	status *status.Status
+}
+
+func (e *setError) Error() string {
+	return e.status.Err().Error()
+}
+
+// GRPCStatus returns the status of the error to gRPC.
+func (e *setError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// setErrorf returns a status error of code for a Set request failing for
+// reason.
+func setErrorf(reason string, c codes.Code, format string, a ...interface{}) error {
+	return &setError{reason: reason, status: status.Newf(c, format, a...)}
+}
+
+// setFailure returns err, a status error, as the error of a Set request
+// failing for reason.
+func setFailure(reason string, err error) error {
+	return &setError{reason: reason, status: status.Convert(err)}
+}
+
+// countLeaves returns the number of leaves and leaf-list values of JSON
+// tree v.
+func countLeaves(v interface{}) int {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		n := 0
+		for _, child := range v {
+			n += countLeaves(child)
+		}
+		return n
+	case []interface{}:
+		n := 0
+		for _, child := range v {
+			n += countLeaves(child)
+		}
+		return n
+	}
+	return 1
+}
@@ -32,7 +32,7 @@ func (s *Server) processSubscribePoll(c *streamClient, request *pb.SubscriptionL
 
 // processSubStreamOnChange processes subscribe stream requests for on_change subscription mode.
 func (s *Server) processSubStreamOnChange(c *streamClient, request *pb.SubscriptionList) {
-	go s.listenToConfigEvents(request)
+	go s.listenToConfigEvents()
 
 }
 
@@ -48,18 +48,39 @@ func (s *Server) processSubStreamSample(c *streamClient, request *pb.Subscriptio
 
 }
 
-// Subscribe handle subscribe requests including POLL, STREAM, ONCE subscribe requests
+// Subscribe handle subscribe requests including POLL, STREAM, ONCE subscribe requests.
+// The stream fails with codes.Unavailable when the target goes down.
 func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
+	id, down, err := s.acquireSubscription(stream.Context())
+	if err != nil {
+		return err
+	}
+	defer s.releaseSubscription(id)
 
-	c := streamClient{stream: stream}
-	var err error
+	c := &streamClient{stream: stream}
 	c.UpdateChan = make(chan *pb.Update, 100)
+	defer s.removeSubscriber(c)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.serveSubscribe(id, c)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-down:
+		return status.Error(codes.Unavailable, "the target went down")
+	}
+}
 
+// serveSubscribe serves the subscribe requests of Subscribe stream id to c
+// until the stream ends.
+func (s *Server) serveSubscribe(id uint64, c *streamClient) error {
+	var err error
 	var subscribe *pb.SubscriptionList
 	var mode gnmi.SubscriptionList_Mode
-
 	for {
-		c.sr, err = stream.Recv()
+		c.sr, err = c.stream.Recv()
 
 		switch {
 		case err == io.EOF:
@@ -72,42 +93,48 @@ func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
 			mode = gnmi.SubscriptionList_POLL
 		} else {
 			subscribe = c.sr.GetSubscribe()
+			if err := s.checkTarget(subscribe.GetPrefix()); err != nil {
+				return err
+			}
+			s.describeSubscription(id, subscribe)
+			c.target, c.prefix = s.notificationPrefix(subscribe.GetPrefix())
 			mode = subscribe.Mode
 		}
 
 		switch mode {
 		case pb.SubscriptionList_ONCE:
-			go s.processSubscribeOnce(&c, subscribe)
+			go s.processSubscribeOnce(c, subscribe)
 		case pb.SubscriptionList_POLL:
-			go s.processSubscribePoll(&c, subscribe)
+			go s.processSubscribePoll(c, subscribe)
 		case pb.SubscriptionList_STREAM:
 			// Adds streamClient to the list of subscribers
 			for _, sub := range subscribe.Subscription {
-				s.addSubscriber(sub.GetPath().String(), &c)
+				s.addSubscriber(sub.GetPath().String(), c)
 			}
 
 			for _, sub := range subscribe.Subscription {
 				switch sub.GetMode() {
 				case pb.SubscriptionMode_ON_CHANGE:
-					go s.processSubStreamOnChange(&c, subscribe)
+					go s.processSubStreamOnChange(c, subscribe)
 				case pb.SubscriptionMode_SAMPLE:
 					subSampleInterval := sub.GetSampleInterval()
+					lowest := s.sampleInterval()
 					//If the sample_interval is set to 0,
 					// the target MUST create the subscription and send the data with the
 					// lowest interval possible for the target.
 					if subSampleInterval == 0 {
-						c.sampleInterval = lowestSampleInterval
+						c.sampleInterval = lowest
 					} else {
 						// We assume that the target cannot support
 						// the sample interval less than the lowest
 						// sample interval which is defined in the target
-						if subSampleInterval < lowestSampleInterval {
-							return status.Error(codes.InvalidArgument, fmt.Sprintf("%s%d", "The sample interval must be higher than ", lowestSampleInterval))
+						if subSampleInterval < lowest {
+							return status.Error(codes.InvalidArgument, fmt.Sprintf("%s%d", "The sample interval must be higher than ", lowest))
 						}
 						c.sampleInterval = subSampleInterval
 
 					}
-					go s.processSubStreamSample(&c, subscribe)
+					go s.processSubStreamSample(c, subscribe)
 				case pb.SubscriptionMode_TARGET_DEFINED:
 					// TODO: when a client creates a
 					// subscription specifying the target defined mode,
@@ -120,7 +147,7 @@ func (s *Server) Subscribe(stream pb.GNMI_SubscribeServer) error {
 					//  then an ON_CHANGE subscription may be created,
 					// whereas if other data represents counter values,
 					// a SAMPLE subscription may be created.
-					go s.processSubStreamOnChange(&c, subscribe)
+					go s.processSubStreamOnChange(c, subscribe)
 
 				}
 
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Subscription is an active Subscribe stream.
+type Subscription struct {
+	ID uint64
+	// Peer is the address of the client, and User its username when
+	// authenticated.
+	Peer string
+	User string
+	// Started is the time the stream started.
+	Started time.Time
+	// Request is the subscription list of the stream, nil until the client
+	// sends it.
+	Request *pb.SubscriptionList
+
+	down chan struct{} // closed when the target goes down
+}
+
+// Subscriptions returns the active Subscribe streams, in the order they
+// started.
+func (s *Server) Subscriptions() []Subscription {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	subscriptions := make([]Subscription, 0, len(s.streams))
+	for _, sub := range s.streams {
+		subscriptions = append(subscriptions, *sub)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+	return subscriptions
+}
+
+// subscribed returns whether Subscribe streams are active.
+func (s *Server) subscribed() bool {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	return len(s.streams) > 0
+}
+
+// acquireSubscription records a new Subscribe stream with context ctx, unless
+// the target is unavailable or has no capacity left for it, and returns its id
+// and a channel closed when the target goes down. releaseSubscription must be
+// called when the stream ends.
+func (s *Server) acquireSubscription(ctx context.Context) (uint64, <-chan struct{}, error) {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	if err := s.checkAvailable(); err != nil {
+		return 0, nil, err
+	}
+	if max := s.capacity.MaxSubscriptions; max > 0 && len(s.streams) >= max {
+		return 0, nil, status.Errorf(codes.ResourceExhausted, "the target serves its maximum of %d subscriptions", max)
+	}
+	if s.throttling() {
+		return 0, nil, status.Error(codes.ResourceExhausted, "the target is throttling its notifications")
+	}
+	s.lastStream++
+	sub := &Subscription{ID: s.lastStream, User: s.user(ctx), Started: time.Now(), down: make(chan struct{})}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		sub.Peer = p.Addr.String()
+	}
+	s.streams[sub.ID] = sub
+	return sub.ID, sub.down, nil
+}
+
+// describeSubscription records the subscription list of Subscribe stream id.
+func (s *Server) describeSubscription(id uint64, request *pb.SubscriptionList) {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	if sub, ok := s.streams[id]; ok {
+		sub.Request = proto.Clone(request).(*pb.SubscriptionList)
+	}
+}
+
+// releaseSubscription removes Subscribe stream id.
+func (s *Server) releaseSubscription(id uint64) {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	delete(s.streams, id)
+}
+
+// closeSubscriptions ends the active Subscribe streams, as the target went
+// down.
+func (s *Server) closeSubscriptions() {
+	s.capacityMu.Lock()
+	defer s.capacityMu.Unlock()
+	for id, sub := range s.streams {
+		close(sub.down)
+		delete(s.streams, id)
+	}
+}
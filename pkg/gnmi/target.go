@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// SetTarget sets the name of the target. Requests whose prefix names another
+// target are rejected and the responses name the target in their prefix. A
+// server without a name accepts any target and echoes the target of the
+// requests. It must be called before the server serves requests.
+func (s *Server) SetTarget(name string) {
+	s.target = name
+}
+
+// Target returns the name of the target.
+func (s *Server) Target() string {
+	return s.target
+}
+
+// checkTarget returns an error if prefix names another target.
+func (s *Server) checkTarget(prefix *pb.Path) error {
+	if target := prefix.GetTarget(); target != "" && s.target != "" && target != s.target {
+		return status.Errorf(codes.NotFound, "unknown target %q, this is target %q", target, s.target)
+	}
+	return nil
+}
+
+// responsePrefix returns the prefix of the responses to a request with
+// prefix: the request prefix naming the target.
+func (s *Server) responsePrefix(prefix *pb.Path) *pb.Path {
+	if s.target == "" || prefix.GetTarget() == s.target {
+		return prefix
+	}
+	if prefix == nil {
+		return &pb.Path{Target: s.target}
+	}
+	prefix = proto.Clone(prefix).(*pb.Path)
+	prefix.Target = s.target
+	return prefix
+}
+
+// notificationPrefix returns the target of a subscription with prefix and
+// the prefix of its notifications, which only names the target since the
+// paths of the notifications are complete.
+func (s *Server) notificationPrefix(prefix *pb.Path) (string, *pb.Path) {
+	target := s.target
+	if target == "" {
+		target = prefix.GetTarget()
+	}
+	if target == "" {
+		return "", nil
+	}
+	return target, &pb.Path{Target: target}
+}
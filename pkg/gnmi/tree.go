@@ -0,0 +1,604 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gnmi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/value"
+)
+
+// Tree is the config of a model created with NewSchemaModel: an RFC 7951 JSON
+// tree, without module names, validated against the schema of the model. It
+// stands for the GoStruct of the generated models, so that it is handed to the
+// ConfigCallback and to InternalUpdate, but the ygot functions reflecting on
+// GoStructs do not apply to it.
+type Tree struct {
+	root   map[string]interface{}
+	schema *yang.Entry
+}
+
+// IsYANGGoStruct implements ygot.GoStruct.
+func (t *Tree) IsYANGGoStruct() {}
+
+// Validate validates the tree against the schema.
+func (t *Tree) Validate(...ygot.ValidationOption) error {
+	_, err := decodeDir(t.schema, t.root)
+	return err
+}
+
+// ΛEnumTypeMap implements ygot.ValidatedGoStruct. A tree has no Go enumerated
+// types, enumerations are kept as their names.
+func (t *Tree) ΛEnumTypeMap() map[string][]reflect.Type {
+	return nil
+}
+
+// JSON returns a copy of the RFC 7951 JSON tree, without module names.
+func (t *Tree) JSON() map[string]interface{} {
+	return copyJSON(t.root).(map[string]interface{})
+}
+
+// newTree creates a Tree of this model from jsonConfig. If jsonConfig is nil,
+// creates an empty tree.
+func (m *Model) newTree(jsonConfig []byte) (*Tree, error) {
+	root := make(map[string]interface{})
+	if jsonConfig != nil {
+		data, err := decodeJSON(jsonConfig)
+		if err != nil {
+			return nil, err
+		}
+		node, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the config is not a JSON object")
+		}
+		if root, err = decodeDir(m.schemaTreeRoot, node); err != nil {
+			return nil, err
+		}
+	}
+	return &Tree{root: root, schema: m.schemaTreeRoot}, nil
+}
+
+// configJSON returns the RFC 7951 JSON tree of config, without module names.
+func configJSON(config ygot.ValidatedGoStruct) (map[string]interface{}, error) {
+	if tree, ok := config.(*Tree); ok {
+		return tree.JSON(), nil
+	}
+	return ygot.ConstructIETFJSON(config, &ygot.RFC7951JSONConfig{})
+}
+
+// decodeJSON decodes JSON data, keeping the numbers as they are written so
+// that 64-bit values do not lose precision.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// copyJSON returns a deep copy of a JSON value.
+func copyJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = copyJSON(value)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, value := range v {
+			l[i] = copyJSON(value)
+		}
+		return l
+	}
+	return v
+}
+
+// treeValue validates the value of a replace or update of path against the
+// schema and returns it as a node of the tree.
+func (m *Model) treeValue(path *pb.Path, val *pb.TypedValue) (interface{}, error) {
+	entry := m.schemaEntry(path)
+	if entry == nil {
+		return nil, setErrorf(SetUnknownPath, codes.NotFound, "path %v is not found in the schema", path)
+	}
+	var data interface{}
+	var err error
+	switch {
+	case val.GetJsonIetfVal() != nil:
+		data, err = decodeJSON(val.GetJsonIetfVal())
+	case val.GetJsonVal() != nil:
+		data, err = decodeJSON(val.GetJsonVal())
+	default:
+		if data, err = value.ToScalar(val); err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot convert leaf node to scalar type: %v", err)
+		}
+	}
+	if err != nil {
+		return nil, setErrorf(SetInvalidValue, codes.InvalidArgument, "invalid json data: %v", err)
+	}
+
+	var node interface{}
+	if elems := path.GetElem(); entry.IsList() && len(elems) > 0 && elems[len(elems)-1].GetKey() != nil {
+		// The value of a list entry holds the members of the entry.
+		dir, ok := data.(map[string]interface{})
+		if !ok {
+			return nil, setErrorf(SetInvalidValue, codes.InvalidArgument, "expect a tree for the list entry %v, got %T", path, data)
+		}
+		node, err = decodeDir(entry, dir)
+	} else {
+		node, err = decodeNode(entry, data)
+	}
+	if err != nil {
+		return nil, setErrorf(SetConstraint, codes.InvalidArgument, "config data validation fails: %v", err)
+	}
+	return node, nil
+}
+
+// decodeNode validates the JSON value of a schema entry and returns it in the
+// form kept in the tree: members named without their module, integers of up
+// to 32 bits as float64, 64-bit integers and decimals as strings.
+func decodeNode(entry *yang.Entry, v interface{}) (interface{}, error) {
+	switch {
+	case entry.IsList():
+		entries, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is a list, got %T", entry.Path(), v)
+		}
+		keys := make(map[string]bool)
+		list := make([]interface{}, len(entries))
+		for i, e := range entries {
+			dir, ok := e.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("an entry of list %s is not an object: %T", entry.Path(), e)
+			}
+			decoded, err := decodeDir(entry, dir)
+			if err != nil {
+				return nil, err
+			}
+			var key []string
+			for _, name := range strings.Fields(entry.Key) {
+				keyValue, ok := decoded[name]
+				if !ok {
+					return nil, fmt.Errorf("an entry of list %s has no key %s", entry.Path(), name)
+				}
+				key = append(key, fmt.Sprintf("%v", keyValue))
+			}
+			if k := strings.Join(key, " "); keys[k] {
+				return nil, fmt.Errorf("duplicate key %q in list %s", k, entry.Path())
+			} else if k != "" {
+				keys[k] = true
+			}
+			list[i] = decoded
+		}
+		return list, nil
+	case entry.IsDir():
+		dir, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is a container, got %T", entry.Path(), v)
+		}
+		return decodeDir(entry, dir)
+	case entry.IsLeafList():
+		values, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s is a leaf-list, got %T", entry.Path(), v)
+		}
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			decoded, err := decodeValue(entry.Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %v", entry.Path(), err)
+			}
+			list[i] = decoded
+		}
+		return list, nil
+	}
+	decoded, err := decodeValue(entry.Type, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of %s: %v", entry.Path(), err)
+	}
+	return decoded, nil
+}
+
+// decodeDir validates the members of a container or list entry.
+func decodeDir(entry *yang.Entry, dir map[string]interface{}) (map[string]interface{}, error) {
+	decoded := make(map[string]interface{}, len(dir))
+	for name, v := range dir {
+		child := findChild(entry, name)
+		if child == nil {
+			return nil, fmt.Errorf("unknown node %s in %s", name, entry.Path())
+		}
+		value, err := decodeNode(child, v)
+		if err != nil {
+			return nil, err
+		}
+		decoded[child.Name] = value
+	}
+	return decoded, nil
+}
+
+// decodeValue validates a scalar value of type t.
+func decodeValue(t *yang.YangType, v interface{}) (interface{}, error) {
+	if t == nil {
+		return nil, fmt.Errorf("no type")
+	}
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		n, err := toNumber(v, false)
+		if err != nil {
+			return nil, err
+		}
+		if !inRange(t.Range, n) {
+			return nil, fmt.Errorf("%v is out of the range %v", v, t.Range)
+		}
+		f, _ := strconv.ParseFloat(n.String(), 64)
+		return f, nil
+	case yang.Yint64, yang.Yuint64:
+		// RFC 7951 writes 64-bit integers as strings.
+		n, err := toNumber(v, true)
+		if err != nil {
+			return nil, err
+		}
+		if !inRange(t.Range, n) {
+			return nil, fmt.Errorf("%v is out of the range %v", v, t.Range)
+		}
+		return n.String(), nil
+	case yang.Ydecimal64:
+		s, ok := numberString(v)
+		if !ok {
+			return nil, fmt.Errorf("expect a decimal number, got %T", v)
+		}
+		n, err := yang.ParseDecimal(s, uint8(t.FractionDigits))
+		if err != nil {
+			return nil, err
+		}
+		if !inRange(t.Range, n) {
+			return nil, fmt.Errorf("%v is out of the range %v", v, t.Range)
+		}
+		return n.String(), nil
+	case yang.Ystring:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expect a string, got %T", v)
+		}
+		if !inRange(t.Length, yang.FromInt(int64(utf8.RuneCountInString(s)))) {
+			return nil, fmt.Errorf("the length of %q is out of the range %v", s, t.Length)
+		}
+		for _, pattern := range t.Pattern {
+			// XSD regular expressions Go does not support are not checked.
+			if re, err := regexp.Compile("^(?:" + pattern + ")$"); err == nil && !re.MatchString(s) {
+				return nil, fmt.Errorf("%q does not match the pattern %s", s, pattern)
+			}
+		}
+		return s, nil
+	case yang.Ybool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expect a boolean, got %T", v)
+		}
+		return b, nil
+	case yang.Yempty:
+		// RFC 7951 writes an empty leaf as [null].
+		if l, ok := v.([]interface{}); ok && len(l) == 1 && l[0] == nil {
+			return v, nil
+		}
+		if b, ok := v.(bool); ok && b {
+			return []interface{}{nil}, nil
+		}
+		return nil, fmt.Errorf("expect [null] for an empty leaf, got %v", v)
+	case yang.Yenum:
+		s, ok := v.(string)
+		if !ok || !t.Enum.IsDefined(s) {
+			return nil, fmt.Errorf("%v is not one of %v", v, t.Enum.Names())
+		}
+		return s, nil
+	case yang.Yidentityref:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expect an identity, got %T", v)
+		}
+		name := s
+		if i := strings.Index(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+		if t.IdentityBase == nil {
+			return nil, fmt.Errorf("the identityref %s has no base", t.Name)
+		}
+		for _, identity := range t.IdentityBase.Values {
+			if identity.Name == name {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%s is not an identity derived from %s", s, t.IdentityBase.Name)
+	case yang.Ybinary:
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expect a base64 string, got %T", v)
+		}
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case yang.Yunion:
+		for _, member := range t.Type {
+			if decoded, err := decodeValue(member, v); err == nil {
+				return decoded, nil
+			}
+		}
+		return nil, fmt.Errorf("%v matches none of the types of the union %s", v, t.Name)
+	}
+	// Leafrefs and the other types are kept as they are.
+	switch n := v.(type) {
+	case json.Number:
+		return strconv.ParseFloat(n.String(), 64)
+	case map[string]interface{}, []interface{}:
+		return nil, fmt.Errorf("expect a scalar value, got %T", v)
+	}
+	return v, nil
+}
+
+// numberString returns the decimal representation of a number, given as a
+// JSON number, a Go number or a string.
+func numberString(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.String(), true
+	case string:
+		return n, true
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32), true
+	case int64:
+		return strconv.FormatInt(n, 10), true
+	case uint64:
+		return strconv.FormatUint(n, 10), true
+	case int:
+		return strconv.Itoa(n), true
+	}
+	return "", false
+}
+
+// toNumber returns the integer v. Strings are only accepted if quoted is set.
+func toNumber(v interface{}, quoted bool) (yang.Number, error) {
+	if _, ok := v.(string); ok && !quoted {
+		return yang.Number{}, fmt.Errorf("expect a number, got the string %q", v)
+	}
+	s, ok := numberString(v)
+	if !ok {
+		return yang.Number{}, fmt.Errorf("expect an integer, got %T", v)
+	}
+	return yang.ParseInt(s)
+}
+
+// inRange reports whether n is in r. An empty range holds every number.
+func inRange(r yang.YangRange, n yang.Number) bool {
+	return len(r) == 0 || r.Contains(yang.YangRange{{Min: n, Max: n}})
+}
+
+// treeNode returns the node of path in the tree and its schema entry, or false
+// if the tree has no such node.
+func treeNode(tree *Tree, path *pb.Path) (interface{}, *yang.Entry, bool) {
+	var node interface{} = tree.root
+	entry := tree.schema
+	for _, elem := range path.GetElem() {
+		dir, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, nil, false
+		}
+		if entry = findChild(entry, elem.GetName()); entry == nil {
+			return nil, nil, false
+		}
+		if elem.GetKey() == nil {
+			if node, ok = dir[entry.Name]; !ok {
+				return nil, nil, false
+			}
+			continue
+		}
+		listEntry := getKeyedListEntry(dir, &pb.PathElem{Name: entry.Name, Key: elem.GetKey()}, false)
+		if listEntry == nil {
+			return nil, nil, false
+		}
+		node = listEntry
+	}
+	return node, entry, true
+}
+
+// withModuleNames returns a copy of the node of entry whose members are named
+// with their module when it differs from the module of their parent, as
+// RFC 7951 requires.
+func withModuleNames(entry *yang.Entry, node interface{}, parentModule string) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		named := make(map[string]interface{}, len(n))
+		for name, v := range n {
+			child := findChild(entry, name)
+			if child == nil {
+				named[name] = v
+				continue
+			}
+			module, _ := child.InstantiatingModule()
+			if module != parentModule {
+				name = module + ":" + name
+			}
+			named[name] = withModuleNames(child, v, module)
+		}
+		return named
+	case []interface{}:
+		list := make([]interface{}, len(n))
+		for i, v := range n {
+			list[i] = withModuleNames(entry, v, parentModule)
+		}
+		return list
+	}
+	return node
+}
+
+// treeUpdate builds the update of path for the node of entry: a scalar value
+// for a leaf or a leaf-list, otherwise a JSON value of type jsonType pruned to
+// dataType.
+func treeUpdate(path *pb.Path, entry *yang.Entry, node interface{}, dataType, jsonType string) (*pb.Update, error) {
+	if !entry.IsDir() {
+		val, err := leafValue(entry, node)
+		if err != nil {
+			msg := fmt.Sprintf("leaf node %v does not contain a scalar type value: %v", path, err)
+			log.Error(msg)
+			return nil, status.Error(codes.Internal, msg)
+		}
+		return &pb.Update{Path: path, Val: val}, nil
+	}
+	var jsonTree interface{} = node
+	if jsonType == "IETF" {
+		jsonTree = withModuleNames(entry, node, "")
+	}
+	jsonTree = pruneConfigData(jsonTree, dataType, path)
+	jsonDump, err := json.Marshal(jsonTree)
+	if err != nil {
+		msg := fmt.Sprintf("error in marshaling %s JSON tree to bytes: %v", jsonType, err)
+		log.Error(msg)
+		return nil, status.Error(codes.Internal, msg)
+	}
+	return buildUpdate(jsonDump, path, jsonType), nil
+}
+
+// leafValue returns the typed value of a leaf or leaf-list of the tree.
+func leafValue(entry *yang.Entry, node interface{}) (*pb.TypedValue, error) {
+	if !entry.IsLeafList() {
+		return scalarValue(entry.Type, node)
+	}
+	values, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expect a leaf-list, got %T", node)
+	}
+	elements := make([]*pb.TypedValue, len(values))
+	for i, v := range values {
+		element, err := scalarValue(entry.Type, v)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = element
+	}
+	return &pb.TypedValue{Value: &pb.TypedValue_LeaflistVal{LeaflistVal: &pb.ScalarArray{Element: elements}}}, nil
+}
+
+// scalarValue returns the typed value of a scalar of type t kept in the tree.
+func scalarValue(t *yang.YangType, v interface{}) (*pb.TypedValue, error) {
+	switch t.Kind {
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64:
+		s, _ := numberString(v)
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.TypedValue{Value: &pb.TypedValue_IntVal{IntVal: i}}, nil
+	case yang.Yuint8, yang.Yuint16, yang.Yuint32, yang.Yuint64:
+		s, _ := numberString(v)
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.TypedValue{Value: &pb.TypedValue_UintVal{UintVal: u}}, nil
+	case yang.Ydecimal64:
+		s, _ := numberString(v)
+		n, err := yang.ParseDecimal(s, uint8(t.FractionDigits))
+		if err != nil {
+			return nil, err
+		}
+		digits := int64(n.Value)
+		if n.Kind == yang.Negative {
+			digits = -digits
+		}
+		return &pb.TypedValue{Value: &pb.TypedValue_DecimalVal{DecimalVal: &pb.Decimal64{Digits: digits, Precision: uint32(n.FractionDigits)}}}, nil
+	case yang.Yempty:
+		return &pb.TypedValue{Value: &pb.TypedValue_BoolVal{BoolVal: true}}, nil
+	case yang.Yunion:
+		for _, member := range t.Type {
+			if _, err := decodeValue(member, v); err == nil {
+				return scalarValue(member, v)
+			}
+		}
+	}
+	if f, ok := v.(float64); ok && f == math.Trunc(f) {
+		return &pb.TypedValue{Value: &pb.TypedValue_IntVal{IntVal: int64(f)}}, nil
+	}
+	return value.FromScalar(v)
+}
+
+// getTree implements Get for the models whose config is a Tree.
+func (s *Server) getTree(req *pb.GetRequest, respPrefix *pb.Path) (*pb.GetResponse, error) {
+	tree, ok := s.config.(*Tree)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "the config is not a tree: %T", s.config)
+	}
+	if req.GetUseModels() != nil {
+		return nil, status.Errorf(codes.Unimplemented, "filtering Get using use_models is unsupported, got: %v", req.GetUseModels())
+	}
+	jsonType := "IETF"
+	if req.GetEncoding() == pb.Encoding_JSON {
+		jsonType = "Internal"
+	}
+	dataType := strings.ToLower(req.GetType().String())
+
+	paths := req.GetPath()
+	if len(paths) == 0 {
+		paths = []*pb.Path{{}}
+	}
+	notifications := make([]*pb.Notification, len(paths))
+	for i, path := range paths {
+		fullPath := gnmiFullPath(req.GetPrefix(), path)
+		if fullPath.GetElem() == nil && fullPath.GetElement() != nil {
+			return nil, status.Error(codes.Unimplemented, "deprecated path element type is unsupported")
+		}
+		node, entry, ok := treeNode(tree, fullPath)
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "path %v not found", path)
+		}
+		if !entry.IsDir() && !checkPathContainType(fullPath, dataType) {
+			return nil, status.Error(codes.Internal, "The requested dataType is not valid")
+		}
+		update, err := treeUpdate(path, entry, node, dataType, jsonType)
+		if err != nil {
+			return nil, err
+		}
+		notifications[i] = &pb.Notification{
+			Timestamp: time.Now().UnixNano(),
+			Prefix:    respPrefix,
+			Update:    []*pb.Update{update},
+		}
+	}
+	return &pb.GetResponse{Notification: notifications}, nil
+}
+
+// getTreeUpdate builds the update of the full path, for the subscriptions to
+// the models whose config is a Tree.
+func (s *Server) getTreeUpdate(path *pb.Path) (*pb.Update, error) {
+	tree, ok := s.config.(*Tree)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "the config is not a tree: %T", s.config)
+	}
+	node, entry, ok := treeNode(tree, path)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "path %v not found", path)
+	}
+	return treeUpdate(path, entry, node, "all", "IETF")
+}
@@ -117,14 +117,18 @@ func getKeyedListEntry(node map[string]interface{}, elem *pb.PathElem, createIfN
 	return m
 }
 
-// gnmiFullPath builds the full path from the prefix and path.
+// gnmiFullPath builds the full path from the prefix and path. The origin of
+// the prefix applies to a path without one.
 func gnmiFullPath(prefix, path *pb.Path) *pb.Path {
-	fullPath := &pb.Path{Origin: path.Origin}
+	fullPath := &pb.Path{Origin: path.GetOrigin()}
+	if fullPath.Origin == "" {
+		fullPath.Origin = prefix.GetOrigin()
+	}
 	if path.GetElement() != nil {
-		fullPath.Element = append(prefix.GetElement(), path.GetElement()...)
+		fullPath.Element = append(append([]string(nil), prefix.GetElement()...), path.GetElement()...)
 	}
 	if path.GetElem() != nil {
-		fullPath.Elem = append(prefix.GetElem(), path.GetElem()...)
+		fullPath.Elem = append(append([]*pb.PathElem(nil), prefix.GetElem()...), path.GetElem()...)
 	}
 	return fullPath
 }
@@ -189,6 +193,14 @@ func (s *Server) InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) er
 	return fp(s.config)
 }
 
+// InternalRead lets fp read the internal states of the server under a read
+// lock, like InternalUpdate. fp must not change config.
+func (s *Server) InternalRead(fp func(config ygot.ValidatedGoStruct) error) error {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return fp(s.config)
+}
+
 // GetConfig returns the config store
 func (s *Server) GetConfig() (ygot.ValidatedGoStruct, error) {
 	return s.config, nil
@@ -245,11 +257,11 @@ func deleteKeyedListEntry(node map[string]interface{}, elem *pb.PathElem) bool {
 func setPathWithAttribute(op pb.UpdateResult_Operation, curNode map[string]interface{}, pathElem *pb.PathElem, nodeVal interface{}) error {
 	nodeValAsTree, ok := nodeVal.(map[string]interface{})
 	if !ok {
-		return status.Errorf(codes.InvalidArgument, "expect nodeVal is a json node of map[string]interface{}, received %T", nodeVal)
+		return setErrorf(SetInvalidValue, codes.InvalidArgument, "expect nodeVal is a json node of map[string]interface{}, received %T", nodeVal)
 	}
 	m := getKeyedListEntry(curNode, pathElem, true)
 	if m == nil {
-		return status.Errorf(codes.NotFound, "path elem not found: %v", pathElem)
+		return setErrorf(SetUnknownPath, codes.NotFound, "path elem not found: %v", pathElem)
 	}
 	if op == pb.UpdateResult_REPLACE {
 		for k := range m {
@@ -263,7 +275,7 @@ func setPathWithAttribute(op pb.UpdateResult_Operation, curNode map[string]inter
 		}
 		for k, v := range nodeValAsTree {
 			if k == attrKey && fmt.Sprintf("%v", v) != attrVal {
-				return status.Errorf(codes.InvalidArgument, "invalid config data: %v is a path attribute", k)
+				return setErrorf(SetInvalidValue, codes.InvalidArgument, "invalid config data: %v is a path attribute", k)
 			}
 		}
 	}
@@ -293,10 +305,15 @@ func setPathWithoutAttribute(op pb.UpdateResult_Operation, curNode map[string]in
 	return nil
 }
 
-// sendResponse sends an SubscribeResponse to a gNMI client.
+// sendResponse sends an SubscribeResponse to a gNMI client, within the
+// notification rate of the capacity of the target.
 func (s *Server) sendResponse(response *pb.SubscribeResponse, stream pb.GNMI_SubscribeServer) {
+	s.throttle(response, stream)
 	log.Info("Sending SubscribeResponse out to gNMI client: ", response)
 	err := stream.Send(response)
+	if response.GetUpdate() != nil {
+		s.countSent(err)
+	}
 	if err != nil {
 		//TODO remove channel registrations
 		log.Errorf("Error in sending response to client %v", err)
@@ -370,18 +387,16 @@ func (s *Server) getUpdateForPath(fullPath *pb.Path) (*pb.Update, error) {
 
 }
 
-// getUpdate finds the node in the tree, build the update message and return it back to the collector
-func (s *Server) getUpdate(c *streamClient, subList *pb.SubscriptionList, path *pb.Path) (*pb.Update, error) {
+// getUpdate finds the node of the full path in the tree, build the update message and return it back to the collector
+func (s *Server) getUpdate(path *pb.Path) (*pb.Update, error) {
 
-	fullPath := path
-	prefix := subList.GetPrefix()
-	if prefix != nil {
-		fullPath = gnmiFullPath(prefix, path)
-	}
-	if fullPath.GetElem() == nil && fullPath.GetElement() != nil {
+	if path.GetElem() == nil && path.GetElement() != nil {
 		return nil, status.Error(codes.Unimplemented, "deprecated path element type is unsupported")
 	}
-	node, err := ytypes.GetNode(s.model.schemaTreeRoot, s.config, fullPath, nil)
+	if s.model.isGeneric() {
+		return s.getTreeUpdate(path)
+	}
+	node, err := ytypes.GetNode(s.model.schemaTreeRoot, s.config, path, nil)
 	if isNil(node) || err != nil {
 		return nil, err
 	}
@@ -470,11 +485,15 @@ func (s *Server) getUpdate(c *streamClient, subList *pb.SubscriptionList, path *
 
 }
 
-// collector collects the latest update from the config.
+// collector collects the latest update from the config. The updates have
+// full paths, since the prefix of the notifications only names the target.
 func (s *Server) collector(c *streamClient, request *pb.SubscriptionList) {
 	for _, sub := range request.Subscription {
 		path := sub.GetPath()
-		update, err := s.getUpdate(c, request, path)
+		if prefix := request.GetPrefix(); prefix != nil {
+			path = gnmiFullPath(prefix, path)
+		}
+		update, err := s.getUpdate(path)
 
 		if err != nil {
 			log.Info("Error while collecting data for subscribe once or poll", err)
@@ -495,13 +514,13 @@ func (s *Server) collector(c *streamClient, request *pb.SubscriptionList) {
 func (s *Server) listenForUpdates(c *streamClient) {
 	for update := range c.UpdateChan {
 		if update.Val == nil {
-			deleteResponse := buildDeleteResponse(update.GetPath())
+			deleteResponse := buildDeleteResponse(c.prefix, update.GetPath())
 			s.sendResponse(deleteResponse, c.stream)
 			syncResponse := buildSyncResponse()
 			s.sendResponse(syncResponse, c.stream)
 
 		} else {
-			response, _ := buildSubResponse(update)
+			response, _ := buildSubResponse(c.prefix, update)
 			s.sendResponse(response, c.stream)
 			syncResponse := buildSyncResponse()
 			s.sendResponse(syncResponse, c.stream)
@@ -509,35 +528,65 @@ func (s *Server) listenForUpdates(c *streamClient) {
 	}
 }
 
-// configEventProducer produces update events for stream subscribers.
-func (s *Server) listenToConfigEvents(request *pb.SubscriptionList) {
+// listenToConfigEvents sends the changes queued for the ON_CHANGE subscribers
+// to the streams subscribed to a prefix of their paths. A stream subscribed to
+// several prefixes of a path is sent its change once.
+func (s *Server) listenToConfigEvents() {
 	for v := range s.ConfigUpdate.Out() {
 		update := v.(*pb.Update)
-		subscribers := s.getSubscribers()
-		for key, c := range subscribers {
-			if key == update.GetPath().String() {
-				newUpdateValue, err := s.getUpdate(c, request, update.GetPath())
-
-				if err != nil {
-					deleteResponse := buildDeleteResponse(update.GetPath())
-					s.sendResponse(deleteResponse, c.stream)
-					syncResponse := buildSyncResponse()
-					s.sendResponse(syncResponse, c.stream)
-
-				} else {
-					update.Val = newUpdateValue.Val
+		sent := make(map[*streamClient]bool)
+		for key, c := range s.getSubscribers() {
+			if sent[c] || !subscribedTo(c, key, update.GetPath()) {
+				continue
+			}
+			sent[c] = true
+			s.configMu.RLock()
+			newUpdate, err := s.getUpdate(update.GetPath())
+			s.configMu.RUnlock()
+			if err != nil || newUpdate == nil {
+				deleteResponse := buildDeleteResponse(c.prefix, update.GetPath())
+				s.sendResponse(deleteResponse, c.stream)
+			} else {
+				response, _ := buildSubResponse(c.prefix, newUpdate)
+				s.sendResponse(response, c.stream)
+			}
+			syncResponse := buildSyncResponse()
+			s.sendResponse(syncResponse, c.stream)
+		}
+	}
+}
 
-					// builds subscription response
-					response, _ := buildSubResponse(update)
+// subscribedTo returns whether the subscription of c to the path of key is to
+// a prefix of path.
+func subscribedTo(c *streamClient, key string, path *pb.Path) bool {
+	list := c.sr.GetSubscribe()
+	for _, sub := range list.GetSubscription() {
+		if sub.GetPath().String() == key && hasPrefix(path, gnmiFullPath(list.GetPrefix(), sub.GetPath())) {
+			return true
+		}
+	}
+	return false
+}
 
-					s.sendResponse(response, c.stream)
-					// builds Sync response
-					syncResponse := buildSyncResponse()
-					s.sendResponse(syncResponse, c.stream)
-				}
+// hasPrefix returns whether prefix is a prefix of path. The names of the
+// elements of prefix may be "*", and their keys may be "*" or missing, to
+// match any name or key.
+func hasPrefix(path, prefix *pb.Path) bool {
+	if len(prefix.GetElem()) > len(path.GetElem()) {
+		return false
+	}
+	for i, elem := range prefix.GetElem() {
+		pathElem := path.GetElem()[i]
+		if elem.GetName() != "*" && elem.GetName() != pathElem.GetName() {
+			return false
+		}
+		for k, v := range elem.GetKey() {
+			if v != "*" && pathElem.GetKey()[k] != v {
+				return false
 			}
 		}
 	}
+	return true
 }
 
 func (s *Server) getSubscribers() map[string]*streamClient {
@@ -556,12 +605,24 @@ func (s *Server) addSubscriber(key string, c *streamClient) {
 	s.subMu.Unlock()
 }
 
+// removeSubscriber removes the subscriptions of c, whose stream ended.
+func (s *Server) removeSubscriber(c *streamClient) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for key, subscriber := range s.subscribers {
+		if subscriber == c {
+			delete(s.subscribers, key)
+		}
+	}
+}
+
 // buildSubResponse builds a subscribeResponse based on the given Update message.
-func buildSubResponse(update *pb.Update) (*pb.SubscribeResponse, error) {
+func buildSubResponse(prefix *pb.Path, update *pb.Update) (*pb.SubscribeResponse, error) {
 	updateArray := make([]*pb.Update, 0)
 	updateArray = append(updateArray, update)
 	notification := &pb.Notification{
 		Timestamp: time.Now().Unix(),
+		Prefix:    prefix,
 		Update:    updateArray,
 	}
 	responseUpdate := &pb.SubscribeResponse_Update{
@@ -575,10 +636,11 @@ func buildSubResponse(update *pb.Update) (*pb.SubscribeResponse, error) {
 }
 
 // buildDeleteResponse builds a subscribe response for the given deleted path.
-func buildDeleteResponse(delete *pb.Path) *gnmi.SubscribeResponse {
+func buildDeleteResponse(prefix *pb.Path, delete *pb.Path) *gnmi.SubscribeResponse {
 	deleteArray := []*gnmi.Path{delete}
 	notification := &gnmi.Notification{
 		Timestamp: time.Now().Unix(),
+		Prefix:    prefix,
 		Delete:    deleteArray,
 	}
 	responseUpdate := &gnmi.SubscribeResponse_Update{
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cert implements the gNOI CertificateManagement service of the
+// simulated device on top of the certificate manager of github.com/google/gnxi.
+// The TLS listener of the target takes its certificates from the manager on
+// every handshake, so that installed and rotated certificates are served right
+// away, and a rotation whose stream is aborted before its finalization is
+// rolled back.
+package cert
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"sort"
+
+	gnxicert "github.com/google/gnxi/gnoi/cert"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var log = logging.GetLogger("gnoi", "cert")
+
+// DefaultCertID is the ID of the certificate the target starts with.
+const DefaultCertID = "default"
+
+// Server implements the gNOI CertificateManagement service.
+type Server struct {
+	*gnxicert.Server
+	manager    *gnxicert.Manager
+	clientAuth tls.ClientAuthType
+}
+
+// NewServer creates a CertificateManagement service starting with certificate
+// as DefaultCertID and the PEM encoded certificates of caBundle as CA bundle.
+// The private key of certificate is the key of every CSR the service
+// generates, and so of every certificate it installs. Client certificates are
+// checked against the CA bundle according to clientAuth.
+func NewServer(certificate tls.Certificate, caBundle []byte, clientAuth tls.ClientAuthType) (*Server, error) {
+	if len(certificate.Certificate) == 0 {
+		return nil, fmt.Errorf("no certificate given")
+	}
+	var caCerts [][]byte
+	for rest := caBundle; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		caCerts = append(caCerts, pem.EncodeToMemory(block))
+	}
+	if len(caCerts) == 0 {
+		return nil, fmt.Errorf("no CA certificate given")
+	}
+
+	manager := gnxicert.NewManager(certificate.PrivateKey)
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]})
+	if err := manager.Install(DefaultCertID, pemCert, caCerts); err != nil {
+		return nil, err
+	}
+	manager.RegisterNotifier(func(certs, caCerts int) {
+		log.Infof("The target now serves %d certificates with %d CA certificates", certs, caCerts)
+	})
+	return &Server{
+		Server:     gnxicert.NewServer(manager),
+		manager:    manager,
+		clientAuth: clientAuth,
+	}, nil
+}
+
+// TLSConfig returns the TLS configuration of the target, which takes the
+// certificates and the CA bundle from the service on every handshake.
+func (s *Server) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			certificates, caPool := s.manager.TLSCertificates()
+			if len(certificates) == 0 {
+				return nil, fmt.Errorf("no certificate is installed")
+			}
+			// Serve the most recent certificate to the clients that do not
+			// tell which one they expect.
+			sort.Slice(certificates, func(i, j int) bool {
+				return certificates[i].Leaf.NotBefore.After(certificates[j].Leaf.NotBefore)
+			})
+			return &tls.Config{
+				ClientAuth:   s.clientAuth,
+				Certificates: certificates,
+				ClientCAs:    caPool,
+			}, nil
+		},
+	}
+}
+
+// ServerCredentials returns the gRPC ServerOptions serving TLSConfig.
+func (s *Server) ServerCredentials() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(s.TLSConfig()))}
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cert
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gnxi/gnoi/cert/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// newTestServer serves a CertificateManagement service over a loopback TLS
+// connection, starting with a certificate named "target" signed by ca.
+func newTestServer(t *testing.T) (pb.CertificateManagementClient, *testCA, string, func()) {
+	ca := newTestCA(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error in generating key: %v", err)
+	}
+	target := tls.Certificate{
+		Certificate: [][]byte{ca.issue(t, "target", key.Public())},
+		PrivateKey:  key,
+	}
+	s, err := NewServer(target, encodeCert(ca.cert), tls.VerifyClientCertIfGiven)
+	if err != nil {
+		t.Fatalf("error in creating cert server: %v", err)
+	}
+
+	g := grpc.NewServer(s.ServerCredentials()...)
+	s.Register(g)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	conn, err := grpc.Dial(listen.Addr().String(),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	return pb.NewCertificateManagementClient(conn), ca, listen.Addr().String(), func() {
+		conn.Close()
+		g.Stop()
+	}
+}
+
+// testCA is a self-signed CA issuing the certificates of the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error in generating key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("error in creating CA: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("error in parsing CA: %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns the DER encoded certificate of pub for commonName.
+func (ca *testCA) issue(t *testing.T, commonName string, pub interface{}) []byte {
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatalf("error in generating serial number: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatalf("error in issuing certificate: %v", err)
+	}
+	return der
+}
+
+func encodeCert(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+// servedCommonName returns the common name of the certificate served to a new connection.
+func servedCommonName(t *testing.T, addr string) string {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+	if err != nil {
+		t.Fatalf("error in the TLS handshake: %v", err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+// waitCommonName waits for new connections to be served a certificate with commonName.
+func waitCommonName(t *testing.T, addr string, commonName string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cn := servedCommonName(t, addr)
+		if cn == commonName {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the target serves %q, want %q", cn, commonName)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// sign signs a PEM encoded CSR with ca.
+func sign(t *testing.T, ca *testCA, pemCSR []byte) []byte {
+	block, _ := pem.Decode(pemCSR)
+	if block == nil {
+		t.Fatalf("the CSR is not PEM encoded")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("error in parsing the CSR: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.issue(t, csr.Subject.CommonName, csr.PublicKey)})
+}
+
+func csrParams(commonName string) *pb.CSRParams {
+	return &pb.CSRParams{
+		Type:       pb.CertificateType_CT_X509,
+		MinKeySize: 2048,
+		KeyType:    pb.KeyType_KT_RSA,
+		CommonName: commonName,
+	}
+}
+
+// loadRotated rotates the default certificate up to, but not including, the finalization.
+func loadRotated(ctx context.Context, t *testing.T, client pb.CertificateManagementClient, ca *testCA, commonName string) pb.CertificateManagement_RotateClient {
+	stream, err := client.Rotate(ctx)
+	if err != nil {
+		t.Fatalf("error in Rotate: %v", err)
+	}
+	if err := stream.Send(&pb.RotateCertificateRequest{RotateRequest: &pb.RotateCertificateRequest_GenerateCsr{
+		GenerateCsr: &pb.GenerateCSRRequest{CsrParams: csrParams(commonName), CertificateId: DefaultCertID},
+	}}); err != nil {
+		t.Fatalf("error in sending GenerateCSRRequest: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("error in receiving GenerateCSRResponse: %v", err)
+	}
+	if err := stream.Send(&pb.RotateCertificateRequest{RotateRequest: &pb.RotateCertificateRequest_LoadCertificate{
+		LoadCertificate: &pb.LoadCertificateRequest{
+			Certificate:   &pb.Certificate{Type: pb.CertificateType_CT_X509, Certificate: sign(t, ca, resp.GetGeneratedCsr().GetCsr().GetCsr())},
+			CertificateId: DefaultCertID,
+		},
+	}}); err != nil {
+		t.Fatalf("error in sending LoadCertificateRequest: %v", err)
+	}
+	if resp, err = stream.Recv(); err != nil || resp.GetLoadCertificate() == nil {
+		t.Fatalf("error in receiving LoadCertificateResponse: %v %v", resp, err)
+	}
+	return stream
+}
+
+func TestRotate(t *testing.T) {
+	client, ca, addr, stop := newTestServer(t)
+	defer stop()
+
+	if cn := servedCommonName(t, addr); cn != "target" {
+		t.Fatalf("the target serves %q, want the startup certificate", cn)
+	}
+
+	// An aborted rotation is rolled back.
+	ctx, cancel := context.WithCancel(context.Background())
+	loadRotated(ctx, t, client, ca, "aborted")
+	if cn := servedCommonName(t, addr); cn != "aborted" {
+		t.Errorf("the target serves %q during the rotation, want the rotated certificate", cn)
+	}
+	cancel()
+	waitCommonName(t, addr, "target")
+
+	// A finalized rotation is kept.
+	stream := loadRotated(context.Background(), t, client, ca, "rotated")
+	if err := stream.Send(&pb.RotateCertificateRequest{RotateRequest: &pb.RotateCertificateRequest_FinalizeRotation{
+		FinalizeRotation: &pb.FinalizeRequest{},
+	}}); err != nil {
+		t.Fatalf("error in sending FinalizeRequest: %v", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Rotate ended with %v", err)
+	}
+	if cn := servedCommonName(t, addr); cn != "rotated" {
+		t.Errorf("the target serves %q after the rotation, want the rotated certificate", cn)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	client, ca, _, stop := newTestServer(t)
+	defer stop()
+
+	stream, err := client.Install(context.Background())
+	if err != nil {
+		t.Fatalf("error in Install: %v", err)
+	}
+	if err := stream.Send(&pb.InstallCertificateRequest{InstallRequest: &pb.InstallCertificateRequest_GenerateCsr{
+		GenerateCsr: &pb.GenerateCSRRequest{CsrParams: csrParams("installed"), CertificateId: "installed"},
+	}}); err != nil {
+		t.Fatalf("error in sending GenerateCSRRequest: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("error in receiving GenerateCSRResponse: %v", err)
+	}
+	if err := stream.Send(&pb.InstallCertificateRequest{InstallRequest: &pb.InstallCertificateRequest_LoadCertificate{
+		LoadCertificate: &pb.LoadCertificateRequest{
+			Certificate:   &pb.Certificate{Type: pb.CertificateType_CT_X509, Certificate: sign(t, ca, resp.GetGeneratedCsr().GetCsr().GetCsr())},
+			CertificateId: "installed",
+		},
+	}}); err != nil {
+		t.Fatalf("error in sending LoadCertificateRequest: %v", err)
+	}
+	if resp, err = stream.Recv(); err != nil || resp.GetLoadCertificate() == nil {
+		t.Fatalf("error in receiving LoadCertificateResponse: %v %v", resp, err)
+	}
+
+	certificates, err := client.GetCertificates(context.Background(), &pb.GetCertificatesRequest{})
+	if err != nil {
+		t.Fatalf("error in GetCertificates: %v", err)
+	}
+	ids := map[string]bool{}
+	for _, info := range certificates.GetCertificateInfo() {
+		ids[info.GetCertificateId()] = true
+	}
+	if len(ids) != 2 || !ids[DefaultCertID] || !ids["installed"] {
+		t.Errorf("GetCertificates returned %v, want %s and installed", ids, DefaultCertID)
+	}
+}
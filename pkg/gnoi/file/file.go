@@ -0,0 +1,335 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package file implements the gNOI File service of the simulated device. The
+// remote paths of the requests are rooted at a sandbox directory of the host,
+// out of which no request can read or write.
+package file
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	fpb "github.com/openconfig/gnoi/file"
+	tpb "github.com/openconfig/gnoi/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var log = logging.GetLogger("gnoi", "file")
+
+const (
+	// DefaultMaxSize is the size of the largest file Put accepts by default.
+	DefaultMaxSize = 64 << 20
+	// chunkSize is the size of the contents sent in a GetResponse.
+	chunkSize = 64 << 10
+	// defaultPermissions are the permissions of a file put without permissions.
+	defaultPermissions = 0644
+	// umask is the umask reported by Stat.
+	umask = 0022
+	// getHashMethod is the method of the hash ending the Get stream.
+	getHashMethod = tpb.HashType_SHA256
+)
+
+// Server implements the gNOI File service.
+type Server struct {
+	fpb.UnimplementedFileServer
+
+	root    string
+	maxSize int64
+}
+
+// NewServer creates a File service rooted at the sandbox directory root,
+// accepting files of at most maxSize bytes.
+func NewServer(root string, maxSize int64) (*Server, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid maximum file size %d", maxSize)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	// Resolve the root so that paths can be checked against it once their
+	// symbolic links are resolved.
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return nil, err
+	}
+	return &Server{root: resolved, maxSize: maxSize}, nil
+}
+
+// Root returns the sandbox directory of the service.
+func (s *Server) Root() string {
+	return s.root
+}
+
+// Get streams the contents of a file, then its hash.
+func (s *Server) Get(req *fpb.GetRequest, stream fpb.File_GetServer) error {
+	local, err := s.localPath(req.GetRemoteFile())
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return fileError(req.GetRemoteFile(), err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fileError(req.GetRemoteFile(), err)
+	}
+	if info.IsDir() {
+		return status.Errorf(codes.InvalidArgument, "%s is a directory", req.GetRemoteFile())
+	}
+
+	h := newHash(getHashMethod)
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			if err := stream.Send(&fpb.GetResponse{Response: &fpb.GetResponse_Contents{Contents: buf[:n]}}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "cannot read %s: %v", req.GetRemoteFile(), err)
+		}
+	}
+	log.Infof("Sent %s (%d bytes)", req.GetRemoteFile(), info.Size())
+	return stream.Send(&fpb.GetResponse{Response: &fpb.GetResponse_Hash{Hash: &tpb.HashType{
+		Method: getHashMethod,
+		Hash:   h.Sum(nil),
+	}}})
+}
+
+// Put writes a file from its details, its contents and its hash. The file
+// is only written once its hash is verified.
+func (s *Server) Put(stream fpb.File_PutServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	open := req.GetOpen()
+	if open == nil {
+		return status.Error(codes.InvalidArgument, "expected the file details first")
+	}
+	remote := open.GetRemoteFile()
+	local, err := s.localPath(remote)
+	if err != nil {
+		return err
+	}
+	if local == s.root {
+		return status.Errorf(codes.InvalidArgument, "%s is a directory", remote)
+	}
+	perm := os.FileMode(defaultPermissions)
+	if open.GetPermissions() != 0 {
+		if perm, err = parsePermissions(open.GetPermissions()); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+	if info, err := os.Stat(local); err == nil && info.IsDir() {
+		return status.Errorf(codes.InvalidArgument, "%s is a directory", remote)
+	}
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return fileError(path.Dir(remote), err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(local), ".put-")
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot create %s: %v", remote, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var received int64
+	var hash *tpb.HashType
+	for hash == nil {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return status.Errorf(codes.Aborted, "the transfer of %s ended without a hash", remote)
+		}
+		if err != nil {
+			return err
+		}
+		switch r := req.GetRequest().(type) {
+		case *fpb.PutRequest_Contents:
+			received += int64(len(r.Contents))
+			if received > s.maxSize {
+				return status.Errorf(codes.ResourceExhausted, "%s exceeds %d bytes", remote, s.maxSize)
+			}
+			if _, err := tmp.Write(r.Contents); err != nil {
+				return status.Errorf(codes.Internal, "cannot write %s: %v", remote, err)
+			}
+		case *fpb.PutRequest_Hash:
+			hash = r.Hash
+		default:
+			return status.Errorf(codes.InvalidArgument, "expected contents or a hash, got %v", req)
+		}
+	}
+
+	h := newHash(hash.GetMethod())
+	if h == nil {
+		return status.Errorf(codes.InvalidArgument, "unsupported hash method %s", hash.GetMethod())
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return status.Errorf(codes.Internal, "cannot read %s: %v", remote, err)
+	}
+	if _, err := io.Copy(h, tmp); err != nil {
+		return status.Errorf(codes.Internal, "cannot read %s: %v", remote, err)
+	}
+	if !bytes.Equal(h.Sum(nil), hash.GetHash()) {
+		log.Warnf("The %s hash of %s does not match", hash.GetMethod(), remote)
+		return status.Errorf(codes.DataLoss, "the %s hash of %s does not match", hash.GetMethod(), remote)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return status.Errorf(codes.Internal, "cannot set the permissions of %s: %v", remote, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return status.Errorf(codes.Internal, "cannot write %s: %v", remote, err)
+	}
+	if err := os.Rename(tmp.Name(), local); err != nil {
+		return status.Errorf(codes.Internal, "cannot write %s: %v", remote, err)
+	}
+	log.Infof("Received %s (%d bytes)", remote, received)
+	return stream.SendAndClose(&fpb.PutResponse{})
+}
+
+// Stat returns the metadata of a file, or of the files in a directory.
+func (s *Server) Stat(ctx context.Context, req *fpb.StatRequest) (*fpb.StatResponse, error) {
+	local, err := s.localPath(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		return nil, fileError(req.GetPath(), err)
+	}
+	remote := path.Clean("/" + req.GetPath())
+	if !info.IsDir() {
+		return &fpb.StatResponse{Stats: []*fpb.StatInfo{statInfo(remote, info)}}, nil
+	}
+	infos, err := ioutil.ReadDir(local)
+	if err != nil {
+		return nil, fileError(req.GetPath(), err)
+	}
+	resp := &fpb.StatResponse{}
+	for _, info := range infos {
+		resp.Stats = append(resp.Stats, statInfo(path.Join(remote, info.Name()), info))
+	}
+	return resp, nil
+}
+
+// Remove removes a file. Directories cannot be removed.
+func (s *Server) Remove(ctx context.Context, req *fpb.RemoveRequest) (*fpb.RemoveResponse, error) {
+	local, err := s.localPath(req.GetRemoteFile())
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Lstat(local)
+	if err != nil {
+		return nil, fileError(req.GetRemoteFile(), err)
+	}
+	if info.IsDir() {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is a directory", req.GetRemoteFile())
+	}
+	if err := os.Remove(local); err != nil {
+		return nil, fileError(req.GetRemoteFile(), err)
+	}
+	log.Infof("Removed %s", req.GetRemoteFile())
+	return &fpb.RemoveResponse{}, nil
+}
+
+// localPath maps a remote path to its path in the sandbox. The remote path is
+// cleaned so that ".." cannot climb above the root, and its deepest existing
+// ancestor must resolve into the sandbox so that no symbolic link leads out.
+func (s *Server) localPath(remote string) (string, error) {
+	if remote == "" {
+		return "", status.Error(codes.InvalidArgument, "no path given")
+	}
+	local := filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+remote)))
+	for existing := local; ; existing = filepath.Dir(existing) {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fileError(remote, err)
+		}
+		if resolved != s.root && !strings.HasPrefix(resolved, s.root+string(filepath.Separator)) {
+			return "", status.Errorf(codes.PermissionDenied, "%s is outside of the sandbox", remote)
+		}
+		return local, nil
+	}
+}
+
+// statInfo returns the StatInfo of a file.
+func statInfo(remote string, info os.FileInfo) *fpb.StatInfo {
+	return &fpb.StatInfo{
+		Path:         remote,
+		LastModified: uint64(info.ModTime().UnixNano()),
+		Permissions:  formatPermissions(info.Mode().Perm()),
+		Size:         uint64(info.Size()),
+		Umask:        formatPermissions(umask),
+	}
+}
+
+// parsePermissions parses permissions written as octal digits in decimal,
+// e.g. 644 for rw-r--r--.
+func parsePermissions(permissions uint32) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(strconv.FormatUint(uint64(permissions), 10), 8, 32)
+	if err != nil || perm > 0777 {
+		return 0, fmt.Errorf("invalid permissions %d", permissions)
+	}
+	return os.FileMode(perm), nil
+}
+
+// formatPermissions writes permissions as octal digits in decimal.
+func formatPermissions(perm os.FileMode) uint32 {
+	permissions, _ := strconv.ParseUint(strconv.FormatUint(uint64(perm.Perm()), 8), 10, 32)
+	return uint32(permissions)
+}
+
+// newHash returns a hash computing method, or nil if the method is
+// unsupported.
+func newHash(method tpb.HashType_HashMethod) hash.Hash {
+	switch method {
+	case tpb.HashType_SHA256:
+		return sha256.New()
+	case tpb.HashType_SHA512:
+		return sha512.New()
+	case tpb.HashType_MD5:
+		return md5.New()
+	}
+	return nil
+}
+
+// fileError converts a file system error into a status error.
+func fileError(remote string, err error) error {
+	switch {
+	case os.IsNotExist(err):
+		return status.Errorf(codes.NotFound, "%s does not exist", remote)
+	case os.IsPermission(err):
+		return status.Errorf(codes.PermissionDenied, "%s cannot be accessed", remote)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", remote, err)
+}
@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package file
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fpb "github.com/openconfig/gnoi/file"
+	tpb "github.com/openconfig/gnoi/types"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestServer serves a File service over a loopback gRPC connection.
+func newTestServer(t *testing.T, maxSize int64) (*Server, *grpc.ClientConn, func()) {
+	dir, err := ioutil.TempDir("", "file-test-")
+	if err != nil {
+		t.Fatalf("error in creating sandbox directory: %v", err)
+	}
+	s, err := NewServer(filepath.Join(dir, "sandbox"), maxSize)
+	if err != nil {
+		t.Fatalf("error in creating file server: %v", err)
+	}
+
+	g := grpc.NewServer()
+	fpb.RegisterFileServer(g, s)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	return s, conn, func() {
+		conn.Close()
+		g.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// put sends a file with its hash.
+func put(conn *grpc.ClientConn, remote string, permissions uint32, contents []byte, hash *tpb.HashType) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := fpb.NewFileClient(conn).Put(ctx)
+	if err != nil {
+		return err
+	}
+	requests := []*fpb.PutRequest{
+		{Request: &fpb.PutRequest_Open{Open: &fpb.PutRequest_Details{RemoteFile: remote, Permissions: permissions}}},
+		{Request: &fpb.PutRequest_Contents{Contents: contents}},
+		{Request: &fpb.PutRequest_Hash{Hash: hash}},
+	}
+	for _, req := range requests {
+		if err := stream.Send(req); err != nil {
+			break
+		}
+	}
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// get returns the contents and the hash of a file.
+func get(conn *grpc.ClientConn, remote string) ([]byte, *tpb.HashType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := fpb.NewFileClient(conn).Get(ctx, &fpb.GetRequest{RemoteFile: remote})
+	if err != nil {
+		return nil, nil, err
+	}
+	var contents []byte
+	var hash *tpb.HashType
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return contents, hash, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		contents = append(contents, resp.GetContents()...)
+		if resp.GetHash() != nil {
+			hash = resp.GetHash()
+		}
+	}
+}
+
+func sha256Hash(contents []byte) *tpb.HashType {
+	sum := sha256.Sum256(contents)
+	return &tpb.HashType{Method: tpb.HashType_SHA256, Hash: sum[:]}
+}
+
+func TestPutGet(t *testing.T) {
+	s, conn, stop := newTestServer(t, DefaultMaxSize)
+	defer stop()
+
+	contents := bytes.Repeat([]byte("interface eth0\n"), 10000)
+	sum := md5.Sum(contents)
+	if err := put(conn, "/config/snippet.cfg", 600, contents, &tpb.HashType{Method: tpb.HashType_MD5, Hash: sum[:]}); err != nil {
+		t.Fatalf("error in Put: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(s.Root(), "config", "snippet.cfg"))
+	if err != nil {
+		t.Fatalf("the file was not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("the file was written with permissions %v, want -rw-------", info.Mode().Perm())
+	}
+
+	got, hash, err := get(conn, "/config/snippet.cfg")
+	if err != nil {
+		t.Fatalf("error in Get: %v", err)
+	}
+	if !bytes.Equal(got, contents) {
+		t.Errorf("Get returned %d bytes, want %d", len(got), len(contents))
+	}
+	if !bytes.Equal(hash.GetHash(), sha256Hash(contents).Hash) || hash.GetMethod() != tpb.HashType_SHA256 {
+		t.Errorf("Get returned the hash %v, want the SHA256 of the contents", hash)
+	}
+
+	if _, _, err := get(conn, "/config/missing.cfg"); status.Code(err) != codes.NotFound {
+		t.Errorf("Get of a missing file returned %v, want NotFound", err)
+	}
+	if _, _, err := get(conn, "/config"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Get of a directory returned %v, want InvalidArgument", err)
+	}
+}
+
+func TestPutFailures(t *testing.T) {
+	s, conn, stop := newTestServer(t, 16)
+	defer stop()
+
+	contents := []byte("0123456789")
+	if err := put(conn, "/bad-hash", 0, contents, sha256Hash([]byte("something else"))); status.Code(err) != codes.DataLoss {
+		t.Errorf("Put with a wrong hash returned %v, want DataLoss", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Root(), "bad-hash")); !os.IsNotExist(err) {
+		t.Errorf("a file with a wrong hash was written")
+	}
+	if err := put(conn, "/no-method", 0, contents, &tpb.HashType{Hash: []byte{0}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Put without a hash method returned %v, want InvalidArgument", err)
+	}
+	if err := put(conn, "/too-large", 0, bytes.Repeat(contents, 2), sha256Hash(bytes.Repeat(contents, 2))); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Put of a file exceeding the size limit returned %v, want ResourceExhausted", err)
+	}
+	if err := put(conn, "/bad-permissions", 999, contents, sha256Hash(contents)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Put with invalid permissions returned %v, want InvalidArgument", err)
+	}
+	files, err := ioutil.ReadDir(s.Root())
+	if err != nil {
+		t.Fatalf("error in reading the sandbox: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("failed transfers left %d files in the sandbox", len(files))
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	s, conn, stop := newTestServer(t, DefaultMaxSize)
+	defer stop()
+
+	contents := []byte("escaped")
+	if err := put(conn, "../../escaped", 0, contents, sha256Hash(contents)); err != nil {
+		t.Fatalf("error in Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Root(), "escaped")); err != nil {
+		t.Errorf("a path climbing above the root was not kept in the sandbox: %v", err)
+	}
+
+	if err := os.Symlink(filepath.Dir(s.Root()), filepath.Join(s.Root(), "link")); err != nil {
+		t.Fatalf("error in creating a symbolic link: %v", err)
+	}
+	if err := put(conn, "/link/outside", 0, contents, sha256Hash(contents)); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Put through a symbolic link out of the sandbox returned %v, want PermissionDenied", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(filepath.Dir(s.Root()), "secret"), contents, 0644); err != nil {
+		t.Fatalf("error in writing a file out of the sandbox: %v", err)
+	}
+	if _, _, err := get(conn, "/link/secret"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Get through a symbolic link out of the sandbox returned %v, want PermissionDenied", err)
+	}
+}
+
+func TestStatRemove(t *testing.T) {
+	_, conn, stop := newTestServer(t, DefaultMaxSize)
+	defer stop()
+
+	for _, name := range []string{"/debug/a.log", "/debug/b.log"} {
+		contents := []byte(name)
+		if err := put(conn, name, 640, contents, sha256Hash(contents)); err != nil {
+			t.Fatalf("error in Put: %v", err)
+		}
+	}
+
+	client := fpb.NewFileClient(conn)
+	ctx := context.Background()
+	resp, err := client.Stat(ctx, &fpb.StatRequest{Path: "/debug"})
+	if err != nil {
+		t.Fatalf("error in Stat: %v", err)
+	}
+	if len(resp.GetStats()) != 2 {
+		t.Fatalf("Stat of a directory returned %v, want 2 files", resp)
+	}
+	stat := resp.GetStats()[0]
+	if stat.GetPath() != "/debug/a.log" || stat.GetSize() != uint64(len("/debug/a.log")) ||
+		stat.GetPermissions() != 640 || stat.GetUmask() != 22 || stat.GetLastModified() == 0 {
+		t.Errorf("Stat returned %v", stat)
+	}
+
+	if _, err := client.Remove(ctx, &fpb.RemoveRequest{RemoteFile: "/debug/a.log"}); err != nil {
+		t.Fatalf("error in Remove: %v", err)
+	}
+	_, err = client.Stat(ctx, &fpb.StatRequest{Path: "/debug/a.log"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Stat of a removed file returned %v, want NotFound", err)
+	}
+	_, err = client.Remove(ctx, &fpb.RemoveRequest{RemoteFile: "/debug/a.log"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Remove of a removed file returned %v, want NotFound", err)
+	}
+	_, err = client.Remove(ctx, &fpb.RemoveRequest{RemoteFile: "/debug"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Remove of a directory returned %v, want InvalidArgument", err)
+	}
+}
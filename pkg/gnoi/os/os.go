@@ -0,0 +1,363 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package os implements the gNOI OS service of the simulated device. Installed
+// images are kept in a scratch directory and the running version is reported
+// by an OPERATING_SYSTEM component of the platform model.
+package os
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	ospb "github.com/openconfig/gnoi/os"
+	spb "github.com/openconfig/gnoi/system"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var log = logging.GetLogger("gnoi", "os")
+
+const (
+	// ComponentName is the name of the platform component reporting the running version.
+	ComponentName = "os"
+	// MaxImageSize is the size of the largest image Install accepts.
+	MaxImageSize = 1 << 30
+	// progressInterval is the number of bytes received between two TransferProgress responses.
+	progressInterval = 1 << 20
+)
+
+// Fault names accepted by ParseFaults.
+const (
+	FaultCorruptImage      = "corrupt-image"
+	FaultActivationFailure = "activation-failure"
+)
+
+// Faults are the failures injected into the OS service.
+type Faults struct {
+	// CorruptImage makes every transferred image fail the integrity check.
+	CorruptImage bool
+	// ActivationFailure makes the target boot back into the running version
+	// after activating another one.
+	ActivationFailure bool
+}
+
+// ParseFaults parses a comma separated list of fault names.
+func ParseFaults(names string) (Faults, error) {
+	var faults Faults
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case FaultCorruptImage:
+			faults.CorruptImage = true
+		case FaultActivationFailure:
+			faults.ActivationFailure = true
+		default:
+			return faults, fmt.Errorf("unknown OS fault %q, expect %s or %s", name, FaultCorruptImage, FaultActivationFailure)
+		}
+	}
+	return faults, nil
+}
+
+// Target is the simulated device whose operating system the OS service
+// manages. It is implemented by gnmi.Server.
+type Target interface {
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+	NotifyUpdate(path *pb.Path)
+}
+
+// Rebooter reboots the target into an activated version. It is implemented
+// by system.Server.
+type Rebooter interface {
+	ScheduleReboot(method spb.RebootMethod, delay time.Duration, message string) error
+	AddBootHandler(handler func())
+}
+
+// Server implements the gNOI OS service.
+type Server struct {
+	ospb.UnimplementedOSServer
+
+	target     Target
+	rebooter   Rebooter
+	scratchDir string
+
+	mu                    sync.Mutex
+	running               string
+	activated             string
+	activationFailMessage string
+	installing            bool
+	faults                Faults
+}
+
+// NewServer creates an OS service for target running version, with the
+// images installed in scratchDir.
+func NewServer(target Target, rebooter Rebooter, scratchDir, version string) (*Server, error) {
+	if err := os.MkdirAll(scratchDir, 0755); err != nil {
+		return nil, err
+	}
+	s := &Server{
+		target:     target,
+		rebooter:   rebooter,
+		scratchDir: scratchDir,
+		running:    version,
+	}
+	if err := s.updateComponent(); err != nil {
+		return nil, err
+	}
+	rebooter.AddBootHandler(s.boot)
+	return s, nil
+}
+
+// SetFaults sets the failures injected into the service.
+func (s *Server) SetFaults(faults Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = faults
+}
+
+// Faults returns the failures injected into the service.
+func (s *Server) Faults() Faults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.faults
+}
+
+// Install receives an image into the scratch directory.
+func (s *Server) Install(stream ospb.OS_InstallServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	transferRequest := req.GetTransferRequest()
+	if transferRequest == nil {
+		return status.Error(codes.InvalidArgument, "expected a TransferRequest")
+	}
+	version := transferRequest.GetVersion()
+	if version == "" || filepath.Base(version) != version || strings.HasPrefix(version, ".") {
+		return status.Errorf(codes.InvalidArgument, "invalid version %q", version)
+	}
+	if transferRequest.GetStandbySupervisor() {
+		return sendInstallError(stream, ospb.InstallError_INCOMPATIBLE, "the target has no standby supervisor")
+	}
+
+	s.mu.Lock()
+	if s.installing {
+		s.mu.Unlock()
+		return sendInstallError(stream, ospb.InstallError_INSTALL_IN_PROGRESS, "another install is in progress")
+	}
+	running, corrupt := s.running, s.faults.CorruptImage
+	s.installing = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.installing = false
+		s.mu.Unlock()
+	}()
+
+	if _, err := os.Stat(s.imagePath(version)); version == running || err == nil {
+		log.Infof("Version %s is already installed", version)
+		return stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_Validated{Validated: &ospb.Validated{Version: version}}})
+	}
+	if err := stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_TransferReady{TransferReady: &ospb.TransferReady{}}}); err != nil {
+		return err
+	}
+
+	image, err := ioutil.TempFile(s.scratchDir, ".transfer-")
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot create image file: %v", err)
+	}
+	defer os.Remove(image.Name())
+	defer image.Close()
+
+	var received uint64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return status.Error(codes.Aborted, "the transfer ended without a TransferEnd")
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetTransferEnd() != nil {
+			break
+		}
+		content, ok := req.GetRequest().(*ospb.InstallRequest_TransferContent)
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "expected TransferContent or TransferEnd, got %v", req)
+		}
+		if received+uint64(len(content.TransferContent)) > MaxImageSize {
+			return sendInstallError(stream, ospb.InstallError_TOO_LARGE, fmt.Sprintf("the image exceeds %d bytes", MaxImageSize))
+		}
+		if _, err := image.Write(content.TransferContent); err != nil {
+			return status.Errorf(codes.Internal, "cannot write image file: %v", err)
+		}
+		previous := received
+		received += uint64(len(content.TransferContent))
+		if received/progressInterval > previous/progressInterval {
+			if err := stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_TransferProgress{TransferProgress: &ospb.TransferProgress{BytesReceived: received}}}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if received == 0 {
+		return sendInstallError(stream, ospb.InstallError_PARSE_FAIL, "the image is empty")
+	}
+	if corrupt {
+		return sendInstallError(stream, ospb.InstallError_INTEGRITY_FAIL, "the image failed the integrity check")
+	}
+	if err := image.Close(); err != nil {
+		return status.Errorf(codes.Internal, "cannot write image file: %v", err)
+	}
+	if err := os.Rename(image.Name(), s.imagePath(version)); err != nil {
+		return status.Errorf(codes.Internal, "cannot install image file: %v", err)
+	}
+	log.Infof("Installed version %s (%d bytes)", version, received)
+	return stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_Validated{Validated: &ospb.Validated{
+		Version:     version,
+		Description: fmt.Sprintf("%d bytes", received),
+	}}})
+}
+
+// Activate sets the version the target boots into and, unless NoReboot is
+// set, reboots the target.
+func (s *Server) Activate(ctx context.Context, req *ospb.ActivateRequest) (*ospb.ActivateResponse, error) {
+	version := req.GetVersion()
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	if version != running {
+		if _, err := os.Stat(s.imagePath(version)); version == "" || filepath.Base(version) != version || err != nil {
+			return activateError(ospb.ActivateError_NON_EXISTENT_VERSION, fmt.Sprintf("version %q is not installed", version)), nil
+		}
+	}
+	if req.GetStandbySupervisor() {
+		return activateError(ospb.ActivateError_UNSPECIFIED, "the target has no standby supervisor"), nil
+	}
+
+	// The lock keeps the reboot from booting before the version is recorded.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version != running && !req.GetNoReboot() {
+		if err := s.rebooter.ScheduleReboot(spb.RebootMethod_COLD, 0, fmt.Sprintf("activating version %s", version)); err != nil {
+			return activateError(ospb.ActivateError_UNSPECIFIED, err.Error()), nil
+		}
+	}
+	s.activated = version
+	s.activationFailMessage = ""
+	log.Infof("Activated version %s", version)
+	return &ospb.ActivateResponse{Response: &ospb.ActivateResponse_ActivateOk{ActivateOk: &ospb.ActivateOK{}}}, nil
+}
+
+// Verify returns the running version.
+func (s *Server) Verify(ctx context.Context, req *ospb.VerifyRequest) (*ospb.VerifyResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &ospb.VerifyResponse{
+		Version:               s.running,
+		ActivationFailMessage: s.activationFailMessage,
+	}, nil
+}
+
+// boot switches to the activated version when the target reboots.
+func (s *Server) boot() {
+	s.mu.Lock()
+	if s.activated != "" && s.activated != s.running {
+		if s.faults.ActivationFailure {
+			s.activationFailMessage = fmt.Sprintf("failed to boot version %s, booted back into %s", s.activated, s.running)
+			log.Warn(s.activationFailMessage)
+		} else {
+			s.running = s.activated
+			log.Infof("Booted into version %s", s.running)
+		}
+	}
+	s.activated = ""
+	s.mu.Unlock()
+	if err := s.updateComponent(); err != nil {
+		log.Errorf("Error in updating the %s component: %v", ComponentName, err)
+	}
+}
+
+// updateComponent reports the running version in the OPERATING_SYSTEM
+// component. A target serving other models than the built-in openconfig ones
+// does not report it.
+func (s *Server) updateComponent() error {
+	s.mu.Lock()
+	version := s.running
+	s.mu.Unlock()
+	reported := false
+	err := s.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return nil
+		}
+		reported = true
+		if device.Components == nil {
+			device.Components = &gostruct.OpenconfigPlatform_Components{}
+		}
+		component, ok := device.Components.Component[ComponentName]
+		if !ok {
+			var err error
+			if component, err = device.Components.NewComponent(ComponentName); err != nil {
+				return err
+			}
+		}
+		component.Config = &gostruct.OpenconfigPlatform_Components_Component_Config{Name: ygot.String(ComponentName)}
+		if component.State == nil {
+			component.State = &gostruct.OpenconfigPlatform_Components_Component_State{}
+		}
+		component.State.Name = ygot.String(ComponentName)
+		component.State.Type = &gostruct.OpenconfigPlatform_Components_Component_State_Type_Union_E_OpenconfigPlatformTypes_OPENCONFIG_SOFTWARE_COMPONENT{
+			E_OpenconfigPlatformTypes_OPENCONFIG_SOFTWARE_COMPONENT: gostruct.OpenconfigPlatformTypes_OPENCONFIG_SOFTWARE_COMPONENT_OPERATING_SYSTEM,
+		}
+		component.State.OperStatus = gostruct.OpenconfigPlatformTypes_COMPONENT_OPER_STATUS_ACTIVE
+		component.State.SoftwareVersion = ygot.String(version)
+		return nil
+	})
+	if err != nil || !reported {
+		return err
+	}
+	s.target.NotifyUpdate(&pb.Path{Elem: []*pb.PathElem{
+		{Name: "components"},
+		{Name: "component", Key: map[string]string{"name": ComponentName}},
+		{Name: "state"},
+		{Name: "software-version"},
+	}})
+	return nil
+}
+
+// imagePath returns the path of the image of version in the scratch directory.
+func (s *Server) imagePath(version string) string {
+	return filepath.Join(s.scratchDir, version)
+}
+
+// sendInstallError ends an install with an InstallError.
+func sendInstallError(stream ospb.OS_InstallServer, errorType ospb.InstallError_Type, detail string) error {
+	log.Warnf("Install failed with %s: %s", errorType, detail)
+	return stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: &ospb.InstallError{
+		Type:   errorType,
+		Detail: detail,
+	}}})
+}
+
+// activateError returns an ActivateResponse with an ActivateError.
+func activateError(errorType ospb.ActivateError_Type, detail string) *ospb.ActivateResponse {
+	log.Warnf("Activate failed with %s: %s", errorType, detail)
+	return &ospb.ActivateResponse{Response: &ospb.ActivateResponse_ActivateError{ActivateError: &ospb.ActivateError{
+		Type:   errorType,
+		Detail: detail,
+	}}}
+}
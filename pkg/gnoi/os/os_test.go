@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package os
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	ospb "github.com/openconfig/gnoi/os"
+	spb "github.com/openconfig/gnoi/system"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/gnoi/system"
+)
+
+// newTestServer serves an OS service over a loopback gRPC connection.
+func newTestServer(t *testing.T) (*Server, *gnmi.Server, *grpc.ClientConn, func()) {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	target, err := gnmi.NewServer(model, []byte(`{}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating gnmi server: %v", err)
+	}
+	systemServer, err := system.NewServer(target, []byte(`{}`), 0)
+	if err != nil {
+		t.Fatalf("error in creating system server: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "os-test-")
+	if err != nil {
+		t.Fatalf("error in creating scratch directory: %v", err)
+	}
+	s, err := NewServer(target, systemServer, dir, "1.0.0")
+	if err != nil {
+		t.Fatalf("error in creating os server: %v", err)
+	}
+
+	g := grpc.NewServer()
+	ospb.RegisterOSServer(g, s)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	return s, target, conn, func() {
+		conn.Close()
+		g.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// install sends an image and returns the last InstallResponse.
+func install(t *testing.T, conn *grpc.ClientConn, version string, image []byte) *ospb.InstallResponse {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := ospb.NewOSClient(conn).Install(ctx)
+	if err != nil {
+		t.Fatalf("error in Install: %v", err)
+	}
+	requests := []*ospb.InstallRequest{
+		{Request: &ospb.InstallRequest_TransferRequest{TransferRequest: &ospb.TransferRequest{Version: version}}},
+		{Request: &ospb.InstallRequest_TransferContent{TransferContent: image}},
+		{Request: &ospb.InstallRequest_TransferEnd{TransferEnd: &ospb.TransferEnd{}}},
+	}
+	for i, req := range requests {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("error in sending InstallRequest: %v", err)
+		}
+		if i == 0 {
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("error in receiving InstallResponse: %v", err)
+			}
+			if resp.GetTransferReady() == nil {
+				return resp
+			}
+		}
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("error in receiving InstallResponse: %v", err)
+	}
+	return resp
+}
+
+func verify(t *testing.T, conn *grpc.ClientConn) *ospb.VerifyResponse {
+	resp, err := ospb.NewOSClient(conn).Verify(context.Background(), &ospb.VerifyRequest{})
+	if err != nil {
+		t.Fatalf("error in Verify: %v", err)
+	}
+	return resp
+}
+
+func activate(t *testing.T, conn *grpc.ClientConn, version string) *ospb.ActivateResponse {
+	resp, err := ospb.NewOSClient(conn).Activate(context.Background(), &ospb.ActivateRequest{Version: version})
+	if err != nil {
+		t.Fatalf("error in Activate: %v", err)
+	}
+	return resp
+}
+
+func componentVersion(target *gnmi.Server) string {
+	var version string
+	_ = target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device := config.(*gostruct.Device)
+		if device.Components != nil && device.Components.Component[ComponentName] != nil {
+			version = *device.Components.Component[ComponentName].State.SoftwareVersion
+		}
+		return nil
+	})
+	return version
+}
+
+func TestUpgrade(t *testing.T) {
+	s, target, conn, stop := newTestServer(t)
+	defer stop()
+
+	if got := componentVersion(target); got != "1.0.0" {
+		t.Errorf("got component version %q, want 1.0.0", got)
+	}
+	if resp := activate(t, conn, "2.0.0"); resp.GetActivateError().GetType() != ospb.ActivateError_NON_EXISTENT_VERSION {
+		t.Errorf("activate a version not installed: got %v", resp)
+	}
+	if resp := install(t, conn, "2.0.0", []byte("image")); resp.GetValidated().GetVersion() != "2.0.0" {
+		t.Fatalf("install: got %v, want Validated", resp)
+	}
+	if resp := install(t, conn, "2.0.0", nil); resp.GetValidated().GetVersion() != "2.0.0" {
+		t.Errorf("install an installed version: got %v, want Validated", resp)
+	}
+
+	// A version is not activated when its reboot cannot be scheduled.
+	rebooter := s.rebooter.(*system.Server)
+	if err := rebooter.ScheduleReboot(spb.RebootMethod_COLD, time.Hour, "pending"); err != nil {
+		t.Fatalf("error in scheduling a reboot: %v", err)
+	}
+	if resp := activate(t, conn, "2.0.0"); resp.GetActivateError() == nil {
+		t.Errorf("activate with a reboot pending: got %v, want an error", resp)
+	}
+	s.mu.Lock()
+	activated := s.activated
+	s.mu.Unlock()
+	if activated != "" {
+		t.Errorf("version %q was activated with a reboot pending, want none", activated)
+	}
+	if _, err := rebooter.CancelReboot(context.Background(), &spb.CancelRebootRequest{}); err != nil {
+		t.Fatalf("error in cancelling the reboot: %v", err)
+	}
+
+	if resp := activate(t, conn, "2.0.0"); resp.GetActivateOk() == nil {
+		t.Fatalf("activate: got %v", resp)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if resp := verify(t, conn); resp.GetVersion() != "2.0.0" || resp.GetActivationFailMessage() != "" {
+		t.Errorf("verify after activation: got %v, want version 2.0.0", resp)
+	}
+	if got := componentVersion(target); got != "2.0.0" {
+		t.Errorf("got component version %q after activation, want 2.0.0", got)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s, target, conn, stop := newTestServer(t)
+	defer stop()
+
+	faults, err := ParseFaults("corrupt-image, activation-failure")
+	if err != nil {
+		t.Fatalf("error in parsing faults: %v", err)
+	}
+	s.SetFaults(faults)
+	if resp := install(t, conn, "2.0.0", []byte("image")); resp.GetInstallError().GetType() != ospb.InstallError_INTEGRITY_FAIL {
+		t.Errorf("install a corrupt image: got %v, want INTEGRITY_FAIL", resp)
+	}
+
+	s.SetFaults(Faults{ActivationFailure: true})
+	if resp := install(t, conn, "2.0.0", []byte("image")); resp.GetValidated() == nil {
+		t.Fatalf("install: got %v, want Validated", resp)
+	}
+	if resp := activate(t, conn, "2.0.0"); resp.GetActivateOk() == nil {
+		t.Fatalf("activate: got %v", resp)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if resp := verify(t, conn); resp.GetVersion() != "1.0.0" || resp.GetActivationFailMessage() == "" {
+		t.Errorf("verify after a failed activation: got %v, want version 1.0.0 with a failure message", resp)
+	}
+	if got := componentVersion(target); got != "1.0.0" {
+		t.Errorf("got component version %q after a failed activation, want 1.0.0", got)
+	}
+
+	if _, err := ParseFaults("disk-full"); err == nil {
+		t.Error("unknown fault: got nil error")
+	}
+}
@@ -0,0 +1,356 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package system implements the gNOI System service of the simulated device,
+// acting on the config and state tree of the gNMI server it is registered with.
+package system
+
+import (
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	spb "github.com/openconfig/gnoi/system"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var log = logging.GetLogger("gnoi", "system")
+
+const (
+	defaultPingCount    = 5
+	defaultPingInterval = time.Second
+	defaultPingSize     = 56
+	defaultMaxTTL       = 30
+	pingTTL             = 64
+	icmpHeaderSize      = 8
+	simulatedRTT        = time.Millisecond
+)
+
+// Target is the simulated device the System service acts upon. It is
+// implemented by gnmi.Server.
+type Target interface {
+	SetAvailable(available bool)
+	Reload(config []byte) error
+	SetBootTime(bootTime time.Time) error
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+}
+
+// Server implements the gNOI System service. A reboot makes the gNMI service
+// of the target unavailable for the reboot duration, reloads the startup
+// config and updates system/state/boot-time.
+type Server struct {
+	spb.UnimplementedSystemServer
+
+	target         Target
+	startupConfig  []byte
+	rebootDuration time.Duration
+
+	mu        sync.Mutex
+	bootTime  time.Time
+	pending   *time.Timer
+	rebooting bool
+	when      time.Time
+	reason    string
+	method    spb.RebootMethod
+	count     uint32
+
+	bootHandlers []func()
+}
+
+// NewServer creates a System service for target, booted now from startupConfig.
+func NewServer(target Target, startupConfig []byte, rebootDuration time.Duration) (*Server, error) {
+	s := &Server{
+		target:         target,
+		startupConfig:  startupConfig,
+		rebootDuration: rebootDuration,
+		bootTime:       time.Now(),
+	}
+	if err := target.SetBootTime(s.bootTime); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Time returns the current time on the target.
+func (s *Server) Time(ctx context.Context, req *spb.TimeRequest) (*spb.TimeResponse, error) {
+	return &spb.TimeResponse{Time: uint64(time.Now().UnixNano())}, nil
+}
+
+// Reboot schedules a reboot of the target after the requested delay.
+func (s *Server) Reboot(ctx context.Context, req *spb.RebootRequest) (*spb.RebootResponse, error) {
+	switch req.GetMethod() {
+	case spb.RebootMethod_COLD, spb.RebootMethod_WARM:
+	case spb.RebootMethod_UNKNOWN:
+		return nil, status.Error(codes.InvalidArgument, "reboot method is not set")
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "reboot method %s is not supported", req.GetMethod())
+	}
+	if len(req.GetSubcomponents()) > 0 {
+		return nil, status.Error(codes.Unimplemented, "reboot of subcomponents is not supported")
+	}
+
+	if err := s.ScheduleReboot(req.GetMethod(), time.Duration(req.GetDelay()), req.GetMessage()); err != nil {
+		return nil, err
+	}
+	return &spb.RebootResponse{}, nil
+}
+
+// ScheduleReboot schedules a reboot of the target after delay. It fails if a
+// reboot is already pending.
+func (s *Server) ScheduleReboot(method spb.RebootMethod, delay time.Duration, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending != nil || s.rebooting {
+		return status.Error(codes.FailedPrecondition, "a reboot is already pending")
+	}
+	s.when = time.Now().Add(delay)
+	s.reason = message
+	s.method = method
+	s.pending = time.AfterFunc(delay, s.reboot)
+	log.Infof("Scheduled a %s reboot in %v: %s", s.method, delay, s.reason)
+	return nil
+}
+
+// AddBootHandler adds a handler called on every reboot once the startup config
+// is reloaded, for the services that keep state across reboots to restore it
+// in the tree.
+func (s *Server) AddBootHandler(handler func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bootHandlers = append(s.bootHandlers, handler)
+}
+
+// reboot takes the gNMI service down, reloads the startup config and brings
+// the service back up.
+func (s *Server) reboot() {
+	s.mu.Lock()
+	s.pending = nil
+	s.rebooting = true
+	s.count++
+	bootHandlers := s.bootHandlers
+	s.mu.Unlock()
+
+	log.Infof("Rebooting the target for %v", s.rebootDuration)
+	s.target.SetAvailable(false)
+	time.Sleep(s.rebootDuration)
+	if err := s.target.Reload(s.startupConfig); err != nil {
+		log.Errorf("Error in reloading the startup config: %v", err)
+	}
+	for _, handler := range bootHandlers {
+		handler()
+	}
+	bootTime := time.Now()
+	if err := s.target.SetBootTime(bootTime); err != nil {
+		log.Errorf("Error in updating the boot time: %v", err)
+	}
+	s.target.SetAvailable(true)
+
+	s.mu.Lock()
+	s.rebooting = false
+	s.bootTime = bootTime
+	s.mu.Unlock()
+	log.Info("The target has rebooted")
+}
+
+// RebootStatus returns the status of the pending or ongoing reboot.
+func (s *Server) RebootStatus(ctx context.Context, req *spb.RebootStatusRequest) (*spb.RebootStatusResponse, error) {
+	if len(req.GetSubcomponents()) > 0 {
+		return nil, status.Error(codes.Unimplemented, "reboot of subcomponents is not supported")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &spb.RebootStatusResponse{
+		Active: s.pending != nil || s.rebooting,
+		Reason: s.reason,
+		Count:  s.count,
+	}
+	if !s.when.IsZero() {
+		resp.When = uint64(s.when.UnixNano())
+	}
+	if wait := time.Until(s.when); s.pending != nil && wait > 0 {
+		resp.Wait = uint64(wait)
+	}
+	return resp, nil
+}
+
+// CancelReboot cancels the pending reboot, if any.
+func (s *Server) CancelReboot(ctx context.Context, req *spb.CancelRebootRequest) (*spb.CancelRebootResponse, error) {
+	if len(req.GetSubcomponents()) > 0 {
+		return nil, status.Error(codes.Unimplemented, "reboot of subcomponents is not supported")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rebooting {
+		return nil, status.Error(codes.FailedPrecondition, "the reboot is already in progress")
+	}
+	if s.pending != nil && s.pending.Stop() {
+		s.pending = nil
+		log.Infof("Cancelled the pending reboot: %s", req.GetMessage())
+	}
+	return &spb.CancelRebootResponse{}, nil
+}
+
+// SwitchControlProcessor switches to the control processor at the given
+// components/component path. The simulated device has a single control plane,
+// so the switchover takes effect immediately.
+func (s *Server) SwitchControlProcessor(ctx context.Context, req *spb.SwitchControlProcessorRequest) (*spb.SwitchControlProcessorResponse, error) {
+	elems := req.GetControlProcessor().GetElem()
+	if len(elems) != 2 || elems[0].GetName() != "components" || elems[1].GetName() != "component" || elems[1].GetKey()["name"] == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "control processor must be a components/component[name] path, got %v", req.GetControlProcessor())
+	}
+	name := elems[1].GetKey()["name"]
+	var version string
+	err := s.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return status.Errorf(codes.Internal, "config tree is not an openconfig device: %T", config)
+		}
+		if device.Components == nil || device.Components.Component[name] == nil {
+			return status.Errorf(codes.NotFound, "component %s not found", name)
+		}
+		if componentState := device.Components.Component[name].State; componentState != nil && componentState.SoftwareVersion != nil {
+			version = *componentState.SoftwareVersion
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &spb.SwitchControlProcessorResponse{
+		ControlProcessor: req.GetControlProcessor(),
+		Version:          version,
+		Uptime:           int64(time.Since(s.bootTime)),
+	}, nil
+}
+
+// Ping stands in for a ping from the target: every destination that resolves
+// is reachable with a round trip time of about a millisecond.
+func (s *Server) Ping(req *spb.PingRequest, stream spb.System_PingServer) error {
+	address, err := resolve(stream.Context(), req.GetDestination(), req.GetDoNotResolve())
+	if err != nil {
+		return err
+	}
+	count := req.GetCount()
+	if count <= 0 {
+		count = defaultPingCount
+	}
+	interval := time.Duration(req.GetInterval())
+	if interval <= 0 {
+		interval = defaultPingInterval
+	}
+	size := req.GetSize()
+	if size <= 0 {
+		size = defaultPingSize
+	}
+
+	var rtts []time.Duration
+	for seq := int32(1); seq <= count; seq++ {
+		if seq > 1 {
+			select {
+			case <-stream.Context().Done():
+				return stream.Context().Err()
+			case <-time.After(interval):
+			}
+		}
+		rtt := roundTripTime()
+		rtts = append(rtts, rtt)
+		if err := stream.Send(&spb.PingResponse{
+			Source:   address,
+			Time:     int64(rtt),
+			Bytes:    size + icmpHeaderSize,
+			Sequence: seq,
+			Ttl:      pingTTL,
+		}); err != nil {
+			return err
+		}
+	}
+
+	summary := &spb.PingResponse{
+		Source:   address,
+		Sent:     count,
+		Received: count,
+		MinTime:  int64(rtts[0]),
+		MaxTime:  int64(rtts[0]),
+	}
+	var total float64
+	for _, rtt := range rtts {
+		total += float64(rtt)
+		if int64(rtt) < summary.MinTime {
+			summary.MinTime = int64(rtt)
+		}
+		if int64(rtt) > summary.MaxTime {
+			summary.MaxTime = int64(rtt)
+		}
+	}
+	avg := total / float64(len(rtts))
+	var variance float64
+	for _, rtt := range rtts {
+		variance += (float64(rtt) - avg) * (float64(rtt) - avg)
+	}
+	summary.AvgTime = int64(avg)
+	summary.StdDev = int64(math.Sqrt(variance / float64(len(rtts))))
+	summary.Time = int64(time.Duration(count-1) * interval)
+	return stream.Send(summary)
+}
+
+// Traceroute stands in for a traceroute from the target: every destination
+// that resolves is a single hop away.
+func (s *Server) Traceroute(req *spb.TracerouteRequest, stream spb.System_TracerouteServer) error {
+	address, err := resolve(stream.Context(), req.GetDestination(), req.GetDoNotResolve())
+	if err != nil {
+		return err
+	}
+	maxTTL := req.GetMaxTtl()
+	if maxTTL <= 0 {
+		maxTTL = defaultMaxTTL
+	}
+	if err := stream.Send(&spb.TracerouteResponse{
+		DestinationName:    req.GetDestination(),
+		DestinationAddress: address,
+		Hops:               maxTTL,
+		PacketSize:         defaultPingSize + icmpHeaderSize,
+	}); err != nil {
+		return err
+	}
+	hop := &spb.TracerouteResponse{
+		Hop:     1,
+		Address: address,
+		Rtt:     int64(roundTripTime()),
+	}
+	if !req.GetDoNotResolve() {
+		hop.Name = req.GetDestination()
+	}
+	return stream.Send(hop)
+}
+
+// resolve returns the address of destination.
+func resolve(ctx context.Context, destination string, doNotResolve bool) (string, error) {
+	if destination == "" {
+		return "", status.Error(codes.InvalidArgument, "destination is not set")
+	}
+	if ip := net.ParseIP(destination); ip != nil || doNotResolve {
+		return destination, nil
+	}
+	addresses, err := net.DefaultResolver.LookupHost(ctx, destination)
+	if err != nil || len(addresses) == 0 {
+		return "", status.Errorf(codes.NotFound, "unknown host %s", destination)
+	}
+	return addresses[0], nil
+}
+
+// roundTripTime returns a simulated round trip time.
+func roundTripTime() time.Duration {
+	return simulatedRTT + time.Duration(rand.Int63n(int64(simulatedRTT/2)))
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package system
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	spb "github.com/openconfig/gnoi/system"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+const startupConfig = `{
+	"openconfig-system:system": {
+		"config": {"hostname": "switch_a"}
+	}
+}`
+
+func newTarget(t *testing.T) *gnmi.Server {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	target, err := gnmi.NewServer(model, []byte(startupConfig), nil)
+	if err != nil {
+		t.Fatalf("error in creating gnmi server: %v", err)
+	}
+	return target
+}
+
+func bootTime(t *testing.T, target *gnmi.Server) uint64 {
+	var bootTime uint64
+	_ = target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device := config.(*gostruct.Device)
+		if device.System.State != nil && device.System.State.BootTime != nil {
+			bootTime = *device.System.State.BootTime
+		}
+		return nil
+	})
+	return bootTime
+}
+
+func TestReboot(t *testing.T) {
+	target := newTarget(t)
+	s, err := NewServer(target, []byte(startupConfig), 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error in creating system server: %v", err)
+	}
+	firstBoot := bootTime(t, target)
+	if firstBoot == 0 {
+		t.Fatal("boot-time is not set")
+	}
+
+	// Change the config; the reboot restores the startup config.
+	hostname := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}
+	req := &pb.SetRequest{Update: []*pb.Update{{Path: hostname, Val: &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch_b"}}}}}
+	if _, err := target.Set(context.Background(), req); err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := s.Reboot(ctx, &spb.RebootRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("reboot without method: got %v, want InvalidArgument", err)
+	}
+	if _, err := s.Reboot(ctx, &spb.RebootRequest{Method: spb.RebootMethod_COLD, Delay: uint64(time.Minute)}); err != nil {
+		t.Fatalf("error in Reboot: %v", err)
+	}
+	if _, err := s.Reboot(ctx, &spb.RebootRequest{Method: spb.RebootMethod_COLD}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("second reboot: got %v, want FailedPrecondition", err)
+	}
+	resp, _ := s.RebootStatus(ctx, &spb.RebootStatusRequest{})
+	if !resp.GetActive() || resp.GetWait() == 0 {
+		t.Errorf("got reboot status %v, want an active reboot with a wait time", resp)
+	}
+	if _, err := s.CancelReboot(ctx, &spb.CancelRebootRequest{}); err != nil {
+		t.Fatalf("error in CancelReboot: %v", err)
+	}
+	if resp, _ := s.RebootStatus(ctx, &spb.RebootStatusRequest{}); resp.GetActive() {
+		t.Errorf("got reboot status %v after cancel, want inactive", resp)
+	}
+
+	if _, err := s.Reboot(ctx, &spb.RebootRequest{Method: spb.RebootMethod_WARM, Message: "upgrade"}); err != nil {
+		t.Fatalf("error in Reboot: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := target.Capabilities(ctx, &pb.CapabilityRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Capabilities during reboot: got %v, want Unavailable", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, err := target.Capabilities(ctx, &pb.CapabilityRequest{}); err != nil {
+		t.Errorf("Capabilities after reboot: got %v", err)
+	}
+	resp, _ = s.RebootStatus(ctx, &spb.RebootStatusRequest{})
+	if resp.GetActive() || resp.GetCount() != 1 || resp.GetReason() != "upgrade" {
+		t.Errorf("got reboot status %v, want one completed reboot", resp)
+	}
+	if bootTime(t, target) <= firstBoot {
+		t.Error("boot-time is not updated by the reboot")
+	}
+	getResp, err := target.Get(ctx, &pb.GetRequest{Path: []*pb.Path{hostname}, Encoding: pb.Encoding_JSON_IETF})
+	if err != nil {
+		t.Fatalf("error in Get: %v", err)
+	}
+	if got := getResp.GetNotification()[0].GetUpdate()[0].GetVal().GetStringVal(); got != "switch_a" {
+		t.Errorf("got hostname %q after reboot, want the startup hostname switch_a", got)
+	}
+}
+
+func TestService(t *testing.T) {
+	s, err := NewServer(newTarget(t), []byte(startupConfig), 0)
+	if err != nil {
+		t.Fatalf("error in creating system server: %v", err)
+	}
+	g := grpc.NewServer()
+	spb.RegisterSystemServer(g, s)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	defer g.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, listen.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	defer conn.Close()
+
+	client := spb.NewSystemClient(conn)
+	timeResp, err := client.Time(ctx, &spb.TimeRequest{})
+	if err != nil {
+		t.Fatalf("error in Time: %v", err)
+	}
+	if since := time.Since(time.Unix(0, int64(timeResp.GetTime()))); since < 0 || since > time.Minute {
+		t.Errorf("got time %v, want about now", timeResp.GetTime())
+	}
+
+	stream, err := client.Ping(ctx, &spb.PingRequest{Destination: "127.0.0.1", Count: 3, Interval: int64(time.Millisecond)})
+	if err != nil {
+		t.Fatalf("error in Ping: %v", err)
+	}
+	var responses []*spb.PingResponse
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("got %d ping responses, want 3 replies and a summary", len(responses))
+	}
+	summary := responses[3]
+	if summary.GetSent() != 3 || summary.GetReceived() != 3 || summary.GetMinTime() > summary.GetAvgTime() || summary.GetAvgTime() > summary.GetMaxTime() {
+		t.Errorf("got ping summary %v", summary)
+	}
+}
@@ -0,0 +1,348 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+// Categories of the utilization of a CPU, in the order of utilization.
+const (
+	user = iota
+	kernel
+	nice
+	idle
+	wait
+	hardwareInterrupt
+	softwareInterrupt
+	categories
+)
+
+// clockTicks is the USER_HZ of Linux, the unit of the CPU times of /proc.
+const clockTicks = 100
+
+// utilization is the utilization of a CPU in percent of each category,
+// adding up to 100.
+type utilization [categories]float64
+
+// cpuSource samples the utilization of the CPUs.
+type cpuSource interface {
+	// sample returns the utilization of count CPUs at now.
+	sample(random *rand.Rand, now time.Time, count int) []utilization
+}
+
+// simulatedCPUs is the source of CPUs whose busy categories drift around
+// their nominal utilization, the rest of the time being idle.
+type simulatedCPUs struct {
+	busy [][]*sensor // by CPU and category, nil for idle
+}
+
+// nominalUtilization is the nominal utilization of the busy categories of
+// the simulated CPUs, busy about 20% of the time.
+var nominalUtilization = utilization{
+	user:              12,
+	kernel:            5,
+	nice:              0.5,
+	wait:              1.5,
+	hardwareInterrupt: 0.3,
+	softwareInterrupt: 0.7,
+}
+
+func (c *simulatedCPUs) sample(random *rand.Rand, now time.Time, count int) []utilization {
+	for len(c.busy) < count {
+		sensors := make([]*sensor, categories)
+		for category, nominal := range nominalUtilization {
+			if category != idle {
+				sensors[category] = newSensor(nominal, nominal/4, 0, 100)
+			}
+		}
+		c.busy = append(c.busy, sensors)
+	}
+	samples := make([]utilization, count)
+	for i := range samples {
+		busy := 0.0
+		for category, sn := range c.busy[i] {
+			if sn == nil {
+				continue
+			}
+			sn.step(random, now, 0)
+			samples[i][category] = sn.value
+			busy += sn.value
+		}
+		if busy > 100 {
+			for category := range samples[i] {
+				samples[i][category] *= 100 / busy
+			}
+			busy = 100
+		}
+		samples[i][idle] = 100 - busy
+	}
+	return samples
+}
+
+// processCPUs is the source of CPUs whose utilization is the CPU usage of the
+// simulator process on Linux, read from stat, spread over the CPUs of the
+// host.
+type processCPUs struct {
+	stat  string
+	last  time.Time
+	times processTimes
+}
+
+// processTimes are the CPU times of a process, in clock ticks.
+type processTimes struct {
+	user, kernel, wait uint64
+	nice               int64
+}
+
+// newProcessCPUs returns the source of the CPU usage of the simulator
+// process. It fails when /proc/self/stat cannot be read, as on other systems
+// than Linux.
+func newProcessCPUs() (*processCPUs, error) {
+	c := &processCPUs{stat: "/proc/self/stat", last: time.Now()}
+	times, err := readProcessTimes(c.stat)
+	if err != nil {
+		return nil, err
+	}
+	c.times = times
+	return c, nil
+}
+
+func (c *processCPUs) sample(random *rand.Rand, now time.Time, count int) []utilization {
+	var u utilization
+	u[idle] = 100
+	times, err := readProcessTimes(c.stat)
+	if err != nil {
+		log.Warnf("error in reading the CPU usage of the process: %v", err)
+	} else if elapsed := now.Sub(c.last).Seconds(); elapsed > 0 {
+		// Percent of the time of all the CPUs of the host.
+		percent := func(ticks uint64) float64 {
+			return 100 * float64(ticks) / clockTicks / elapsed / float64(runtime.NumCPU())
+		}
+		userCategory := user
+		if times.nice > 0 {
+			userCategory = nice
+		}
+		u[userCategory] = percent(times.user - c.times.user)
+		u[kernel] = percent(times.kernel - c.times.kernel)
+		u[wait] = percent(times.wait - c.times.wait)
+		busy := u[userCategory] + u[kernel] + u[wait]
+		if busy > 100 {
+			for category := range u {
+				u[category] *= 100 / busy
+			}
+			busy = 100
+		}
+		u[idle] = 100 - busy
+		c.times, c.last = times, now
+	}
+	samples := make([]utilization, count)
+	for i := range samples {
+		samples[i] = u
+	}
+	return samples
+}
+
+// readProcessTimes reads the user, system and block I/O delay times and the
+// nice value of a process from its stat file.
+func readProcessTimes(stat string) (processTimes, error) {
+	data, err := ioutil.ReadFile(stat)
+	if err != nil {
+		return processTimes{}, err
+	}
+	// The fields follow the command name, in parentheses, from the state,
+	// the third field.
+	end := strings.LastIndexByte(string(data), ')')
+	fields := strings.Fields(string(data)[end+1:])
+	const utime, stime, niceValue, blkioTicks = 14 - 3, 15 - 3, 19 - 3, 42 - 3
+	if end < 0 || len(fields) <= blkioTicks {
+		return processTimes{}, fmt.Errorf("invalid stat file %s", stat)
+	}
+	var times processTimes
+	for _, f := range []struct {
+		index int
+		value *uint64
+	}{{utime, &times.user}, {stime, &times.kernel}, {blkioTicks, &times.wait}} {
+		if *f.value, err = strconv.ParseUint(fields[f.index], 10, 64); err != nil {
+			return processTimes{}, fmt.Errorf("invalid stat file %s: %v", stat, err)
+		}
+	}
+	if times.nice, err = strconv.ParseInt(fields[niceValue], 10, 64); err != nil {
+		return processTimes{}, fmt.Errorf("invalid stat file %s: %v", stat, err)
+	}
+	return times, nil
+}
+
+// cpuWindow keeps the samples of the utilization of a CPU of the last
+// window.
+type cpuWindow struct {
+	samples []cpuSample
+}
+
+type cpuSample struct {
+	at time.Time
+	u  utilization
+}
+
+// add adds the sample u at now, dropping the samples older than window.
+func (w *cpuWindow) add(now time.Time, u utilization, window time.Duration) {
+	w.samples = append(w.samples, cpuSample{at: now, u: u})
+	i := 0
+	for i < len(w.samples)-1 && now.Sub(w.samples[i].at) > window {
+		i++
+	}
+	w.samples = w.samples[i:]
+}
+
+// cpuStatistics has the fields of the interval statistics containers of
+// system/cpus/cpu/state, to which it converts.
+type cpuStatistics struct {
+	Avg      *uint8
+	Instant  *uint8
+	Interval *uint64
+	Max      *uint8
+	MaxTime  *uint64
+	Min      *uint8
+	MinTime  *uint64
+}
+
+// statistics returns the statistics of the categories of the samples of w,
+// and of the total utilization, the time not idle. The instant and average
+// percentages of the categories add up to 100, and the minimum and maximum of
+// every category bound its instant and average.
+func (w *cpuWindow) statistics(window time.Duration) ([categories]cpuStatistics, cpuStatistics) {
+	last := w.samples[len(w.samples)-1].u
+	var avg utilization
+	for _, s := range w.samples {
+		for category, v := range s.u {
+			avg[category] += v / float64(len(w.samples))
+		}
+	}
+	instants, avgs := percentages(last), percentages(avg)
+
+	var stats [categories]cpuStatistics
+	for category := range stats {
+		min, max := w.samples[0], w.samples[0]
+		for _, s := range w.samples {
+			if s.u[category] < min.u[category] {
+				min = s
+			}
+			if s.u[category] > max.u[category] {
+				max = s
+			}
+		}
+		lo := math.Min(math.Round(min.u[category]), math.Min(float64(instants[category]), float64(avgs[category])))
+		hi := math.Max(math.Round(max.u[category]), math.Max(float64(instants[category]), float64(avgs[category])))
+		stats[category] = cpuStatistics{
+			Avg:      ygot.Uint8(avgs[category]),
+			Instant:  ygot.Uint8(instants[category]),
+			Interval: ygot.Uint64(uint64(window)),
+			Max:      ygot.Uint8(uint8(hi)),
+			MaxTime:  ygot.Uint64(uint64(max.at.UnixNano())),
+			Min:      ygot.Uint8(uint8(lo)),
+			MinTime:  ygot.Uint64(uint64(min.at.UnixNano())),
+		}
+	}
+	idleStats := stats[idle]
+	total := cpuStatistics{
+		Avg:      ygot.Uint8(100 - *idleStats.Avg),
+		Instant:  ygot.Uint8(100 - *idleStats.Instant),
+		Interval: idleStats.Interval,
+		Max:      ygot.Uint8(100 - *idleStats.Min),
+		MaxTime:  idleStats.MinTime,
+		Min:      ygot.Uint8(100 - *idleStats.Max),
+		MinTime:  idleStats.MaxTime,
+	}
+	return stats, total
+}
+
+// percentages rounds the percentages of u to integers adding up to 100, by
+// rounding down and then up those with the largest remainders.
+func percentages(u utilization) [categories]uint8 {
+	var rounded [categories]uint8
+	order := make([]int, categories)
+	sum := 0
+	for category, v := range u {
+		rounded[category] = uint8(math.Floor(v))
+		sum += int(rounded[category])
+		order[category] = category
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return u[order[i]]-math.Floor(u[order[i]]) > u[order[j]]-math.Floor(u[order[j]])
+	})
+	for i := 0; sum < 100 && i < categories; i++ {
+		rounded[order[i]]++
+		sum++
+	}
+	return rounded
+}
+
+// setCPUStatistics sets the statistics of CPU index, or of all the CPUs when
+// index is nil, in system/cpus to those of the samples of w.
+func setCPUStatistics(device *gostruct.Device, index *uint32, w *cpuWindow, window time.Duration) {
+	stats, total := w.statistics(window)
+	state := cpuState(device, index)
+	state.User = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_User)(&stats[user])
+	state.Kernel = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Kernel)(&stats[kernel])
+	state.Nice = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Nice)(&stats[nice])
+	state.Idle = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Idle)(&stats[idle])
+	state.Wait = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Wait)(&stats[wait])
+	state.HardwareInterrupt = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_HardwareInterrupt)(&stats[hardwareInterrupt])
+	state.SoftwareInterrupt = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_SoftwareInterrupt)(&stats[softwareInterrupt])
+	state.Total = (*gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Total)(&total)
+}
+
+// cpuState returns the state of CPU index, or of all the CPUs when index is
+// nil, in system/cpus, adding the CPU when missing.
+func cpuState(device *gostruct.Device, index *uint32) *gostruct.OpenconfigSystem_System_Cpus_Cpu_State {
+	if device.System == nil {
+		device.System = &gostruct.OpenconfigSystem_System{}
+	}
+	if device.System.Cpus == nil {
+		device.System.Cpus = &gostruct.OpenconfigSystem_System_Cpus{}
+	}
+	if device.System.Cpus.Cpu == nil {
+		device.System.Cpus.Cpu = make(map[gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_Union]*gostruct.OpenconfigSystem_System_Cpus_Cpu)
+	}
+	var cpu *gostruct.OpenconfigSystem_System_Cpus_Cpu
+	for key, entry := range device.System.Cpus.Cpu {
+		switch k := key.(type) {
+		case *gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_Union_Uint32:
+			if index != nil && k.Uint32 == *index {
+				cpu = entry
+			}
+		case *gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_Union_E_OpenconfigSystem_System_Cpus_Cpu_State_Index:
+			if index == nil {
+				cpu = entry
+			}
+		}
+	}
+	if cpu == nil {
+		var key gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_Union = &gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_Union_E_OpenconfigSystem_System_Cpus_Cpu_State_Index{
+			E_OpenconfigSystem_System_Cpus_Cpu_State_Index: gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_ALL,
+		}
+		if index != nil {
+			key = &gostruct.OpenconfigSystem_System_Cpus_Cpu_State_Index_Union_Uint32{Uint32: *index}
+		}
+		cpu = &gostruct.OpenconfigSystem_System_Cpus_Cpu{Index: key}
+		device.System.Cpus.Cpu[key] = cpu
+	}
+	if cpu.State == nil {
+		cpu.State = &gostruct.OpenconfigSystem_System_Cpus_Cpu_State{Index: cpu.Index}
+	}
+	return cpu.State
+}
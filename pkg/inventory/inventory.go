@@ -0,0 +1,405 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inventory simulates the platform inventory of an openconfig
+// device. The component tree of the chassis, its linecards, ports,
+// transceivers, power supplies, fans and CPUs is built from a compact Spec,
+// and the values of their sensors drift over time, to be read with Get or
+// SAMPLE subscriptions.
+package inventory
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var log = logging.GetLogger("inventory")
+
+// Window is the period over which the avg, min and max statistics of the
+// sensors are computed, reported as their interval.
+const Window = time.Minute
+
+// Nominal values of the sensors.
+const (
+	nominalMemoryUtilization = 0.5  // of the memory
+	nominalFanSpeed          = 6000 // rpm at the ambient temperature
+	fanSpeedPerDegree        = 250  // rpm per degree above the ambient temperature
+	fanSpeedProperty         = "fan-speed"
+)
+
+// Target is the simulated device whose inventory is simulated. It is
+// implemented by gnmi.Server.
+type Target interface {
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+}
+
+// Simulator maintains the component tree of a device and the values of its
+// sensors.
+type Simulator struct {
+	target     Target
+	spec       Spec
+	components []*component // parents first
+
+	mu         sync.Mutex
+	random     *rand.Rand
+	cpuSource  cpuSource
+	cpuWindows []*cpuWindow // by CPU index
+	allCPUs    *cpuWindow
+}
+
+// component is a hardware component of the inventory.
+type component struct {
+	name        string
+	kind        gostruct.E_OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT
+	parent      string
+	children    []string
+	description string
+	partNo      string
+	serialNo    string
+	removable   bool
+	temperature *sensor // nil without temperature sensor
+	memory      uint64  // 0 without memory
+	utilization *sensor // fraction of the memory utilized
+	fan         bool
+}
+
+// NewSimulator returns the inventory simulator of target built from spec. The
+// serial numbers of the components are drawn at random once, so they are
+// kept when the tree is rebuilt after a reload.
+func NewSimulator(target Target, spec Spec) *Simulator {
+	s := &Simulator{
+		target:    target,
+		spec:      spec,
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		cpuSource: &simulatedCPUs{},
+		allCPUs:   &cpuWindow{},
+	}
+	for i := 0; i < spec.CPUs; i++ {
+		s.cpuWindows = append(s.cpuWindows, &cpuWindow{})
+	}
+	s.build()
+	return s
+}
+
+// UseProcessCPU makes the utilization of the CPUs the CPU usage of the
+// simulator process, spread over the CPUs of the host, instead of simulated
+// values. It fails on other systems than Linux.
+func (s *Simulator) UseProcessCPU() error {
+	source, err := newProcessCPUs()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cpuSource = source
+	return nil
+}
+
+// Run updates the inventory every interval until ctx is done.
+func (s *Simulator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Update(); err != nil {
+			log.Warnf("error in updating the inventory: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Update steps the sensors of the components and writes their values to the
+// config tree, adding the components missing from the tree, such as after the
+// config is reloaded. The stream subscribers are not notified of the sensor
+// values, which are meant for SAMPLE subscriptions.
+func (s *Simulator) Update() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, c := range s.components {
+		for _, sn := range []*sensor{c.temperature, c.utilization} {
+			if sn != nil {
+				sn.step(s.random, now, Window)
+			}
+		}
+	}
+	if len(s.cpuWindows) > 0 {
+		var all utilization
+		for i, u := range s.cpuSource.sample(s.random, now, len(s.cpuWindows)) {
+			s.cpuWindows[i].add(now, u, Window)
+			for category, v := range u {
+				all[category] += v / float64(len(s.cpuWindows))
+			}
+		}
+		s.allCPUs.add(now, all, Window)
+	}
+	return s.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "config tree is not an openconfig device: %T", config)
+		}
+		if device.Components == nil {
+			device.Components = &gostruct.OpenconfigPlatform_Components{}
+		}
+		if device.Components.Component == nil {
+			device.Components.Component = make(map[string]*gostruct.OpenconfigPlatform_Components_Component)
+		}
+		for _, c := range s.components {
+			comp, ok := device.Components.Component[c.name]
+			if !ok {
+				comp = s.newComponent(c)
+				device.Components.Component[c.name] = comp
+			}
+			if comp.State == nil {
+				comp.State = &gostruct.OpenconfigPlatform_Components_Component_State{}
+			}
+			if c.temperature != nil {
+				comp.State.Temperature = s.temperature(c.temperature.stats())
+			}
+			if c.utilization != nil {
+				utilized := uint64(float64(c.memory) * c.utilization.value)
+				comp.State.Memory = &gostruct.OpenconfigPlatform_Components_Component_State_Memory{
+					Available: ygot.Uint64(c.memory - utilized),
+					Utilized:  ygot.Uint64(utilized),
+				}
+			}
+			if c.fan {
+				setFanSpeed(comp, s.fanSpeed())
+			}
+		}
+		for i, w := range s.cpuWindows {
+			index := uint32(i)
+			setCPUStatistics(device, &index, w, Window)
+		}
+		if len(s.cpuWindows) > 0 {
+			setCPUStatistics(device, nil, s.allCPUs, Window)
+		}
+		return nil
+	})
+}
+
+// build plans the components of the spec.
+func (s *Simulator) build() {
+	ambient := s.spec.temperature()
+	partNo := s.spec.PartNo
+	if partNo == "" {
+		partNo = "SIM"
+	}
+	add := func(c *component) *component {
+		c.serialNo = fmt.Sprintf("%s%08X", partNo, s.random.Uint32())
+		if c.parent != "" {
+			for _, p := range s.components {
+				if p.name == c.parent {
+					p.children = append(p.children, c.name)
+				}
+			}
+		}
+		s.components = append(s.components, c)
+		return c
+	}
+	temperature := func(offset float64) *sensor {
+		return newSensor(ambient+offset, 0.5, ambient-10, ambient+offset+30)
+	}
+
+	chassis := add(&component{
+		name:        "chassis",
+		kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_CHASSIS,
+		description: "Chassis",
+		partNo:      partNo,
+		temperature: temperature(0),
+	})
+	if s.spec.Memory > 0 {
+		chassis.memory = s.spec.Memory
+		chassis.utilization = newSensor(nominalMemoryUtilization, 0.01, 0.1, 0.95)
+	}
+	for i := 0; i < s.spec.CPUs; i++ {
+		add(&component{
+			name:        fmt.Sprintf("cpu-%d", i),
+			kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_CPU,
+			parent:      chassis.name,
+			description: fmt.Sprintf("CPU %d", i),
+			partNo:      partNo + "-CPU",
+			temperature: temperature(20),
+		})
+	}
+	for i := 1; i <= s.spec.PowerSupplies; i++ {
+		add(&component{
+			name:        fmt.Sprintf("psu-%d", i),
+			kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_POWER_SUPPLY,
+			parent:      chassis.name,
+			description: fmt.Sprintf("Power supply %d", i),
+			partNo:      partNo + "-PSU",
+			removable:   true,
+			temperature: temperature(12),
+		})
+	}
+	for i := 1; i <= s.spec.Fans; i++ {
+		add(&component{
+			name:        fmt.Sprintf("fan-%d", i),
+			kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_FAN,
+			parent:      chassis.name,
+			description: fmt.Sprintf("Fan %d", i),
+			partNo:      partNo + "-FAN",
+			removable:   true,
+			fan:         true,
+		})
+	}
+	addPorts := func(parent, prefix string) {
+		for i := 1; i <= s.spec.Ports; i++ {
+			port := add(&component{
+				name:        fmt.Sprintf("port-%s%d", prefix, i),
+				kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_PORT,
+				parent:      parent,
+				description: fmt.Sprintf("Port %s%d", prefix, i),
+			})
+			if s.spec.Transceivers {
+				add(&component{
+					name:        fmt.Sprintf("transceiver-%s%d", prefix, i),
+					kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_TRANSCEIVER,
+					parent:      port.name,
+					description: fmt.Sprintf("Transceiver of port %s%d", prefix, i),
+					partNo:      partNo + "-XCVR",
+					removable:   true,
+					temperature: temperature(5),
+				})
+			}
+		}
+	}
+	if s.spec.Linecards == 0 {
+		addPorts(chassis.name, "")
+		return
+	}
+	for i := 1; i <= s.spec.Linecards; i++ {
+		linecard := add(&component{
+			name:        fmt.Sprintf("linecard-%d", i),
+			kind:        gostruct.OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_LINECARD,
+			parent:      chassis.name,
+			description: fmt.Sprintf("Linecard %d", i),
+			partNo:      partNo + "-LC",
+			removable:   true,
+			temperature: temperature(8),
+		})
+		if s.spec.Memory > 0 {
+			linecard.memory = s.spec.Memory
+			linecard.utilization = newSensor(nominalMemoryUtilization, 0.01, 0.1, 0.95)
+		}
+		addPorts(linecard.name, fmt.Sprintf("%d/", i))
+	}
+}
+
+// newComponent returns the component entry of c in the config tree.
+func (s *Simulator) newComponent(c *component) *gostruct.OpenconfigPlatform_Components_Component {
+	comp := &gostruct.OpenconfigPlatform_Components_Component{
+		Name:   ygot.String(c.name),
+		Config: &gostruct.OpenconfigPlatform_Components_Component_Config{Name: ygot.String(c.name)},
+		State: &gostruct.OpenconfigPlatform_Components_Component_State{
+			Name: ygot.String(c.name),
+			Type: &gostruct.OpenconfigPlatform_Components_Component_State_Type_Union_E_OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT{
+				E_OpenconfigPlatformTypes_OPENCONFIG_HARDWARE_COMPONENT: c.kind,
+			},
+			Id:          ygot.String(c.name),
+			Description: ygot.String(c.description),
+			OperStatus:  gostruct.OpenconfigPlatformTypes_COMPONENT_OPER_STATUS_ACTIVE,
+			Removable:   ygot.Bool(c.removable),
+			Empty:       ygot.Bool(false),
+		},
+	}
+	if c.parent != "" {
+		comp.State.Parent = ygot.String(c.parent)
+	}
+	if c.partNo != "" {
+		comp.State.MfgName = optional(s.spec.MfgName)
+		comp.State.PartNo = ygot.String(c.partNo)
+		comp.State.SerialNo = ygot.String(c.serialNo)
+		comp.State.HardwareVersion = optional(s.spec.HardwareVersion)
+	}
+	if len(c.children) > 0 {
+		comp.Subcomponents = &gostruct.OpenconfigPlatform_Components_Component_Subcomponents{
+			Subcomponent: make(map[string]*gostruct.OpenconfigPlatform_Components_Component_Subcomponents_Subcomponent),
+		}
+		for _, child := range c.children {
+			comp.Subcomponents.Subcomponent[child] = &gostruct.OpenconfigPlatform_Components_Component_Subcomponents_Subcomponent{
+				Name:   ygot.String(child),
+				Config: &gostruct.OpenconfigPlatform_Components_Component_Subcomponents_Subcomponent_Config{Name: ygot.String(child)},
+				State:  &gostruct.OpenconfigPlatform_Components_Component_Subcomponents_Subcomponent_State{Name: ygot.String(child)},
+			}
+		}
+	}
+	return comp
+}
+
+// temperature returns the temperature state of the statistics of a
+// temperature sensor, in alarm above the alarm threshold of the spec.
+func (s *Simulator) temperature(st stats) *gostruct.OpenconfigPlatform_Components_Component_State_Temperature {
+	threshold := s.spec.alarmThreshold()
+	alarm := st.instant > float64(threshold)
+	t := &gostruct.OpenconfigPlatform_Components_Component_State_Temperature{
+		Instant:        ygot.Float64(round(st.instant)),
+		Avg:            ygot.Float64(round(st.avg)),
+		Min:            ygot.Float64(round(st.min)),
+		Max:            ygot.Float64(round(st.max)),
+		MinTime:        ygot.Uint64(uint64(st.minTime.UnixNano())),
+		MaxTime:        ygot.Uint64(uint64(st.maxTime.UnixNano())),
+		Interval:       ygot.Uint64(uint64(Window)),
+		AlarmThreshold: ygot.Uint32(threshold),
+		AlarmStatus:    ygot.Bool(alarm),
+	}
+	if alarm {
+		t.AlarmSeverity = gostruct.OpenconfigAlarmTypes_OPENCONFIG_ALARM_SEVERITY_MAJOR
+	}
+	return t
+}
+
+// fanSpeed returns the speed of the fans in rpm, which rises with the
+// temperature of the chassis.
+func (s *Simulator) fanSpeed() uint64 {
+	ambient := s.spec.temperature()
+	excess := 0.0
+	if s.components[0].temperature != nil {
+		excess = s.components[0].temperature.value - ambient
+	}
+	rpm := nominalFanSpeed + fanSpeedPerDegree*excess + 50*s.random.NormFloat64()
+	return uint64(math.Max(0, math.Round(rpm)))
+}
+
+// setFanSpeed sets the fan-speed property of comp to rpm.
+func setFanSpeed(comp *gostruct.OpenconfigPlatform_Components_Component, rpm uint64) {
+	if comp.Properties == nil {
+		comp.Properties = &gostruct.OpenconfigPlatform_Components_Component_Properties{}
+	}
+	if comp.Properties.Property == nil {
+		comp.Properties.Property = make(map[string]*gostruct.OpenconfigPlatform_Components_Component_Properties_Property)
+	}
+	comp.Properties.Property[fanSpeedProperty] = &gostruct.OpenconfigPlatform_Components_Component_Properties_Property{
+		Name:   ygot.String(fanSpeedProperty),
+		Config: &gostruct.OpenconfigPlatform_Components_Component_Properties_Property_Config{Name: ygot.String(fanSpeedProperty)},
+		State: &gostruct.OpenconfigPlatform_Components_Component_Properties_Property_State{
+			Name:         ygot.String(fanSpeedProperty),
+			Value:        &gostruct.OpenconfigPlatform_Components_Component_Properties_Property_State_Value_Union_Uint64{Uint64: rpm},
+			Configurable: ygot.Bool(false),
+		},
+	}
+}
+
+// optional returns a pointer to s, or nil when s is empty.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
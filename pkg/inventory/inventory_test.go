@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var spec = Spec{
+	MfgName:         "ONF",
+	PartNo:          "OFSW",
+	HardwareVersion: "1.0",
+	Linecards:       2,
+	Ports:           2,
+	Transceivers:    true,
+	PowerSupplies:   2,
+	Fans:            2,
+	CPUs:            2,
+	Memory:          1 << 30,
+}
+
+func newServer(t *testing.T) *gnmi.Server {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(`{}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	return s
+}
+
+// read calls fn with the device config tree of s.
+func read(t *testing.T, s *gnmi.Server, fn func(device *gostruct.Device)) {
+	err := s.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		fn(config.(*gostruct.Device))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error in reading the config: %v", err)
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	s := newServer(t)
+	sim := NewSimulator(s, spec)
+	for i := 0; i < 10; i++ {
+		if err := sim.Update(); err != nil {
+			t.Fatalf("error in updating the inventory: %v", err)
+		}
+	}
+
+	var serial string
+	read(t, s, func(device *gostruct.Device) {
+		if err := device.Validate(); err != nil {
+			t.Errorf("invalid config tree: %v", err)
+		}
+		var names []string
+		for name := range device.Components.Component {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		want := []string{"chassis", "cpu-0", "cpu-1", "fan-1", "fan-2", "linecard-1", "linecard-2",
+			"port-1/1", "port-1/2", "port-2/1", "port-2/2", "psu-1", "psu-2",
+			"transceiver-1/1", "transceiver-1/2", "transceiver-2/1", "transceiver-2/2"}
+		if !reflect.DeepEqual(names, want) {
+			t.Fatalf("components are %v, want %v", names, want)
+		}
+
+		linecard := device.Components.Component["linecard-1"]
+		if len(linecard.Subcomponents.Subcomponent) != 2 || linecard.Subcomponents.Subcomponent["port-1/2"] == nil {
+			t.Errorf("subcomponents of linecard-1 are %v, want its ports", linecard.Subcomponents.Subcomponent)
+		}
+		if parent := device.Components.Component["transceiver-2/1"].State.Parent; parent == nil || *parent != "port-2/1" {
+			t.Errorf("parent of transceiver-2/1 is %v, want port-2/1", parent)
+		}
+		serial = *device.Components.Component["psu-1"].State.SerialNo
+
+		temp := device.Components.Component["cpu-0"].State.Temperature
+		if *temp.Min > *temp.Avg || *temp.Avg > *temp.Max || *temp.Instant < *temp.Min || *temp.Instant > *temp.Max {
+			t.Errorf("inconsistent temperature statistics %v <= %v <= %v, instant %v", *temp.Min, *temp.Avg, *temp.Max, *temp.Instant)
+		}
+		if *temp.AlarmThreshold != DefaultAlarmThreshold {
+			t.Errorf("alarm threshold is %d, want %d", *temp.AlarmThreshold, DefaultAlarmThreshold)
+		}
+
+		memory := device.Components.Component["linecard-2"].State.Memory
+		if *memory.Available+*memory.Utilized != spec.Memory {
+			t.Errorf("memory available %d and utilized %d do not add up to %d", *memory.Available, *memory.Utilized, spec.Memory)
+		}
+
+		speed := device.Components.Component["fan-1"].Properties.Property[fanSpeedProperty]
+		if rpm, ok := speed.State.Value.(*gostruct.OpenconfigPlatform_Components_Component_Properties_Property_State_Value_Union_Uint64); !ok || rpm.Uint64 == 0 {
+			t.Errorf("fan speed is %v, want a number of rpm", speed.State.Value)
+		}
+
+		if len(device.System.Cpus.Cpu) != 3 {
+			t.Fatalf("system has %d cpus, want 2 and all of them", len(device.System.Cpus.Cpu))
+		}
+		for _, cpu := range device.System.Cpus.Cpu {
+			checkCPU(t, cpu.State)
+		}
+	})
+
+	// The tree can be read and set.
+	for _, name := range []string{"components", "system"} {
+		req := &pb.GetRequest{Path: []*pb.Path{{Elem: []*pb.PathElem{{Name: name}}}}, Encoding: pb.Encoding_JSON_IETF}
+		if _, err := s.Get(context.Background(), req); err != nil {
+			t.Errorf("error in getting %s: %v", name, err)
+		}
+	}
+	hostname := &pb.Update{
+		Path: &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}},
+		Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch"}},
+	}
+	if _, err := s.Set(context.Background(), &pb.SetRequest{Update: []*pb.Update{hostname}}); err != nil {
+		t.Errorf("error in setting the hostname: %v", err)
+	}
+
+	// The components are added back after a reload, with the same serial
+	// numbers.
+	if err := s.Reload([]byte(`{}`)); err != nil {
+		t.Fatalf("error in reloading: %v", err)
+	}
+	if err := sim.Update(); err != nil {
+		t.Fatalf("error in updating the inventory: %v", err)
+	}
+	read(t, s, func(device *gostruct.Device) {
+		if n := len(device.Components.Component); n != 17 {
+			t.Errorf("%d components after reload, want 17", n)
+		}
+		if got := *device.Components.Component["psu-1"].State.SerialNo; got != serial {
+			t.Errorf("serial number of psu-1 is %s after reload, want %s", got, serial)
+		}
+	})
+}
+
+// checkCPU checks that the statistics of the utilization of a CPU are
+// consistent.
+func checkCPU(t *testing.T, state *gostruct.OpenconfigSystem_System_Cpus_Cpu_State) {
+	t.Helper()
+	categories := map[string]*cpuStatistics{
+		"user":               (*cpuStatistics)(state.User),
+		"kernel":             (*cpuStatistics)(state.Kernel),
+		"nice":               (*cpuStatistics)(state.Nice),
+		"idle":               (*cpuStatistics)(state.Idle),
+		"wait":               (*cpuStatistics)(state.Wait),
+		"hardware-interrupt": (*cpuStatistics)(state.HardwareInterrupt),
+		"software-interrupt": (*cpuStatistics)(state.SoftwareInterrupt),
+		"total":              (*cpuStatistics)(state.Total),
+	}
+	instants, avgs := 0, 0
+	for name, st := range categories {
+		if *st.Min > *st.Avg || *st.Avg > *st.Max || *st.Min > *st.Instant || *st.Instant > *st.Max || *st.Max > 100 {
+			t.Errorf("inconsistent %s statistics of cpu %v: min %d, avg %d, instant %d, max %d", name, state.Index, *st.Min, *st.Avg, *st.Instant, *st.Max)
+		}
+		if *st.Interval != uint64(Window) {
+			t.Errorf("interval of the %s statistics is %d, want %d", name, *st.Interval, Window)
+		}
+		if name != "total" {
+			instants += int(*st.Instant)
+			avgs += int(*st.Avg)
+		}
+	}
+	if instants != 100 || avgs != 100 {
+		t.Errorf("instant and avg percentages of cpu %v add up to %d and %d, want 100", state.Index, instants, avgs)
+	}
+	if *state.Total.Instant+*state.Idle.Instant != 100 || *state.Total.Avg+*state.Idle.Avg != 100 {
+		t.Errorf("total utilization of cpu %v is not the time not idle", state.Index)
+	}
+}
+
+func TestProcessCPU(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("not on Linux")
+	}
+	s := newServer(t)
+	sim := NewSimulator(s, Spec{CPUs: 2})
+	if err := sim.UseProcessCPU(); err != nil {
+		t.Fatalf("error in using the CPU usage of the process: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		// Keep the CPU busy between the samples.
+		for deadline := time.Now().Add(20 * time.Millisecond); time.Now().Before(deadline); {
+		}
+		if err := sim.Update(); err != nil {
+			t.Fatalf("error in updating the inventory: %v", err)
+		}
+	}
+	read(t, s, func(device *gostruct.Device) {
+		for _, cpu := range device.System.Cpus.Cpu {
+			checkCPU(t, cpu.State)
+		}
+	})
+}
+
+func TestReadProcessTimes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stat := filepath.Join(dir, "stat")
+	fields := make([]string, 52)
+	for i := range fields {
+		fields[i] = "0"
+	}
+	fields[0], fields[1], fields[2] = "42", "(gnmi target)", "R"
+	fields[13], fields[14], fields[18], fields[41] = "250", "40", "5", "7"
+	if err := ioutil.WriteFile(stat, []byte(strings.Join(fields, " ")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	times, err := readProcessTimes(stat)
+	if err != nil {
+		t.Fatalf("error in reading %s: %v", stat, err)
+	}
+	if want := (processTimes{user: 250, kernel: 40, wait: 7, nice: 5}); times != want {
+		t.Errorf("read %+v, want %+v", times, want)
+	}
+	if err := ioutil.WriteFile(stat, []byte("42 (gnmi) R 1 2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readProcessTimes(stat); err == nil {
+		t.Error("reading a truncated stat file succeeded")
+	}
+}
+
+func TestFixedConfiguration(t *testing.T) {
+	s := newServer(t)
+	sim := NewSimulator(s, Spec{Ports: 2})
+	if err := sim.Update(); err != nil {
+		t.Fatalf("error in updating the inventory: %v", err)
+	}
+	read(t, s, func(device *gostruct.Device) {
+		if parent := device.Components.Component["port-2"].State.Parent; parent == nil || *parent != "chassis" {
+			t.Errorf("parent of port-2 is %v, want the chassis", parent)
+		}
+		if device.System != nil && device.System.Cpus != nil {
+			t.Error("system cpus without cpu in the spec")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "inventory.json")
+	if err := ioutil.WriteFile(file, []byte(`{"linecards": 4, "ports": 48, "transceivers": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(file)
+	if err != nil {
+		t.Fatalf("error in loading %s: %v", file, err)
+	}
+	if want := (Spec{Linecards: 4, Ports: 48, Transceivers: true}); *got != want {
+		t.Errorf("loaded %+v, want %+v", *got, want)
+	}
+	if err := ioutil.WriteFile(file, []byte(`{"fans": -1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(file); err == nil {
+		t.Error("loading a negative number of fans succeeded")
+	}
+}
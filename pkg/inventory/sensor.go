@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// sensor is a sensor whose value drifts around its nominal value, keeping the
+// samples of the last window for its statistics.
+type sensor struct {
+	nominal  float64
+	value    float64
+	noise    float64 // standard deviation of a step
+	min, max float64 // bounds of the value
+	samples  []sample
+}
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// stats are the statistics of a sensor over its window.
+type stats struct {
+	instant, avg, min, max float64
+	minTime, maxTime       time.Time
+}
+
+// newSensor returns a sensor at its nominal value.
+func newSensor(nominal, noise, min, max float64) *sensor {
+	return &sensor{nominal: nominal, value: nominal, noise: noise, min: min, max: max}
+}
+
+// step moves the value of the sensor at random, pulled back towards its
+// nominal value, and samples it at now, dropping the samples older than
+// window.
+func (s *sensor) step(random *rand.Rand, now time.Time, window time.Duration) {
+	s.value += 0.1*(s.nominal-s.value) + s.noise*random.NormFloat64()
+	s.value = math.Max(s.min, math.Min(s.max, s.value))
+	s.samples = append(s.samples, sample{at: now, value: s.value})
+	i := 0
+	for i < len(s.samples)-1 && now.Sub(s.samples[i].at) > window {
+		i++
+	}
+	s.samples = s.samples[i:]
+}
+
+// stats returns the statistics of the samples of the sensor. It must have
+// been stepped.
+func (s *sensor) stats() stats {
+	st := stats{instant: s.value, min: math.Inf(1), max: math.Inf(-1)}
+	sum := 0.0
+	for _, sample := range s.samples {
+		sum += sample.value
+		if sample.value < st.min {
+			st.min, st.minTime = sample.value, sample.at
+		}
+		if sample.value > st.max {
+			st.max, st.maxTime = sample.value, sample.at
+		}
+	}
+	st.avg = sum / float64(len(s.samples))
+	return st
+}
+
+// round returns v rounded to a decimal place.
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Defaults of the unset fields of a Spec.
+const (
+	DefaultTemperature    = 35.0
+	DefaultAlarmThreshold = 75
+)
+
+// Spec is the compact profile of the hardware of a device, from which its
+// component tree is built. A chassis without linecards is a fixed
+// configuration device whose ports belong to the chassis.
+type Spec struct {
+	// MfgName is the name of the manufacturer of the components.
+	MfgName string `json:"mfg-name,omitempty"`
+	// PartNo is the part number of the chassis, which the part numbers of
+	// the other components derive from.
+	PartNo string `json:"part-no,omitempty"`
+	// HardwareVersion is the hardware version of the components.
+	HardwareVersion string `json:"hardware-version,omitempty"`
+	// Linecards is the number of linecards of the chassis.
+	Linecards int `json:"linecards,omitempty"`
+	// Ports is the number of ports of each linecard, or of the chassis
+	// without linecards.
+	Ports int `json:"ports,omitempty"`
+	// Transceivers is whether a transceiver is plugged in every port.
+	Transceivers bool `json:"transceivers,omitempty"`
+	// PowerSupplies is the number of power supplies of the chassis.
+	PowerSupplies int `json:"power-supplies,omitempty"`
+	// Fans is the number of fans of the chassis.
+	Fans int `json:"fans,omitempty"`
+	// CPUs is the number of CPUs of the chassis.
+	CPUs int `json:"cpus,omitempty"`
+	// Memory is the memory in bytes of the chassis and of each linecard.
+	Memory uint64 `json:"memory,omitempty"`
+	// Temperature is the ambient temperature in degrees Celsius,
+	// DefaultTemperature when 0.
+	Temperature float64 `json:"temperature,omitempty"`
+	// AlarmThreshold is the temperature alarm threshold of the components in
+	// degrees Celsius, DefaultAlarmThreshold when 0.
+	AlarmThreshold uint32 `json:"alarm-threshold,omitempty"`
+}
+
+// Load loads a Spec from a JSON file.
+func Load(file string) (*Spec, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid inventory file %s: %v", file, err)
+	}
+	return &spec, spec.Validate()
+}
+
+// Validate checks that the numbers of components of the spec are not
+// negative.
+func (s *Spec) Validate() error {
+	for _, count := range []struct {
+		name  string
+		value int
+	}{
+		{"linecards", s.Linecards},
+		{"ports", s.Ports},
+		{"power-supplies", s.PowerSupplies},
+		{"fans", s.Fans},
+		{"cpus", s.CPUs},
+	} {
+		if count.value < 0 {
+			return fmt.Errorf("invalid number of %s %d", count.name, count.value)
+		}
+	}
+	return nil
+}
+
+func (s *Spec) temperature() float64 {
+	if s.Temperature == 0 {
+		return DefaultTemperature
+	}
+	return s.Temperature
+}
+
+func (s *Spec) alarmThreshold() uint32 {
+	if s.AlarmThreshold == 0 {
+		return DefaultAlarmThreshold
+	}
+	return s.AlarmThreshold
+}
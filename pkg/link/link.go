@@ -0,0 +1,511 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package link simulates the physical links of the interfaces of an
+// openconfig device. The carrier of a link goes down and up on demand or at
+// random; its change is advertised after the hold-time of the interface, as
+// the operational status of the interface and of its subinterfaces.
+package link
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+var log = logging.GetLogger("link")
+
+const (
+	interfaceOperStatus    = "/interfaces/interface/state/oper-status"
+	subinterfaceOperStatus = "/interfaces/interface/subinterfaces/subinterface/state/oper-status"
+)
+
+// Target is the simulated device whose links are simulated. It is
+// implemented by gnmi.Server.
+type Target interface {
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+	InternalRead(fp func(config ygot.ValidatedGoStruct) error) error
+	NotifyUpdate(path *pb.Path)
+}
+
+// Status is the status of the link of an interface.
+type Status struct {
+	// Interface is the name of the interface.
+	Interface string
+	// Carrier is whether the physical link is up.
+	Carrier bool
+	// Up is whether the link is advertised up, once the hold-time of the
+	// interface has passed since the carrier changed.
+	Up bool
+	// CarrierTransitions is the carrier-transitions counter of the interface.
+	CarrierTransitions uint64
+}
+
+// Simulator simulates the links of the interfaces of a device. The links are
+// up until their carrier goes down.
+type Simulator struct {
+	target Target
+
+	mu       sync.Mutex
+	links    map[string]*link // by interface name
+	handlers []func(Status)
+}
+
+// link is the simulated link of an interface.
+type link struct {
+	carrier bool
+	up      bool
+	hold    *time.Timer // pending advertisement of the carrier
+	seq     uint64      // sequence number of the last advertisement scheduled
+	restore *time.Timer // pending end of a flap
+}
+
+// NewSimulator returns the link simulator of target.
+func NewSimulator(target Target) *Simulator {
+	return &Simulator{
+		target: target,
+		links:  make(map[string]*link),
+	}
+}
+
+// Gate returns derivations where the derivations of the operational status
+// of the interfaces and subinterfaces take the links into account: an
+// interface that would be UP is DOWN while its link is down, and its
+// subinterfaces are LOWER_LAYER_DOWN.
+func (s *Simulator) Gate(derivations []gnmi.Derivation) []gnmi.Derivation {
+	gated := make([]gnmi.Derivation, len(derivations))
+	for i, d := range derivations {
+		var down string
+		switch d.To {
+		case interfaceOperStatus:
+			down = "DOWN"
+		case subinterfaceOperStatus:
+			down = "LOWER_LAYER_DOWN"
+		default:
+			gated[i] = d
+			continue
+		}
+		derive := d.Derive
+		d.Derive = func(path *pb.Path, value interface{}) interface{} {
+			if derive != nil {
+				value = derive(path, value)
+			}
+			if value == "UP" && !s.up(interfaceName(path)) {
+				return down
+			}
+			return value
+		}
+		gated[i] = d
+	}
+	return gated
+}
+
+// AddChangeHandler adds a handler called with the status of a link every time
+// the link is advertised up or down.
+func (s *Simulator) AddChangeHandler(handler func(Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// Links returns the status of the links of the interfaces of the device,
+// sorted by interface name.
+func (s *Simulator) Links() ([]Status, error) {
+	var links []Status
+	err := s.target.InternalRead(func(config ygot.ValidatedGoStruct) error {
+		device, err := openconfigDevice(config)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if device.Interfaces == nil {
+			return nil
+		}
+		for name, intf := range device.Interfaces.Interface {
+			links = append(links, s.status(name, intf))
+		}
+		return nil
+	})
+	sort.Slice(links, func(i, j int) bool { return links[i].Interface < links[j].Interface })
+	return links, err
+}
+
+// Link returns the status of the link of interface name.
+func (s *Simulator) Link(name string) (Status, error) {
+	var st Status
+	err := s.target.InternalRead(func(config ygot.ValidatedGoStruct) error {
+		intf, err := lookupInterface(config, name)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		st = s.status(name, intf)
+		return nil
+	})
+	return st, err
+}
+
+// SetCarrier brings the carrier of the link of interface name up or down,
+// cancelling the end of a pending flap.
+func (s *Simulator) SetCarrier(name string, carrier bool) error {
+	s.mu.Lock()
+	if l, ok := s.links[name]; ok && l.restore != nil {
+		l.restore.Stop()
+		l.restore = nil
+	}
+	s.mu.Unlock()
+	return s.setCarrier(name, carrier)
+}
+
+// Flap brings the carrier of the link of interface name down for duration.
+// A flap shorter than the down hold-time of the interface is not advertised.
+func (s *Simulator) Flap(name string, duration time.Duration) error {
+	if err := s.SetCarrier(name, false); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.link(name)
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		s.mu.Lock()
+		if l.restore != timer {
+			s.mu.Unlock()
+			return
+		}
+		l.restore = nil
+		s.mu.Unlock()
+		if err := s.setCarrier(name, true); err != nil {
+			log.Warnf("error in ending the flap of %s: %v", name, err)
+		}
+	})
+	l.restore = timer
+	return nil
+}
+
+// Run flaps the link of an interface picked at random every interval on
+// average, for a random duration of at most maxDuration, until ctx is done.
+// Both must be positive.
+func (s *Simulator) Run(ctx context.Context, interval, maxDuration time.Duration) {
+	if interval <= 0 || maxDuration <= 0 {
+		return
+	}
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		wait := time.Duration(random.ExpFloat64() * float64(interval))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		links, err := s.Links()
+		if err != nil || len(links) == 0 {
+			continue
+		}
+		name := links[random.Intn(len(links))].Interface
+		duration := time.Duration(random.Int63n(int64(maxDuration)) + 1)
+		log.Infof("Flapping the link of %s for %v", name, duration)
+		if err := s.Flap(name, duration); err != nil {
+			log.Warnf("error in flapping the link of %s: %v", name, err)
+		}
+	}
+}
+
+// setCarrier changes the carrier of the link of interface name, counts the
+// transition and advertises it after the hold-time of the interface.
+func (s *Simulator) setCarrier(name string, carrier bool) error {
+	var changed []*pb.Path
+	var advertised *Status
+	var handlers []func(Status)
+	err := s.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		intf, err := lookupInterface(config, name)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		l := s.link(name)
+		if l.carrier == carrier {
+			return nil
+		}
+		l.carrier = carrier
+		changed = countTransition(name, intf)
+		if l.hold != nil {
+			l.hold.Stop()
+			l.hold = nil
+		}
+		if l.up == carrier {
+			// The carrier came back within the hold-time.
+			return nil
+		}
+		hold := holdTime(intf, carrier)
+		if hold == 0 {
+			l.up = carrier
+			changed = append(changed, advertise(name, intf, carrier)...)
+			st := s.status(name, intf)
+			advertised, handlers = &st, s.handlers
+			return nil
+		}
+		l.seq++
+		seq := l.seq
+		l.hold = time.AfterFunc(hold, func() { s.advertise(name, seq) })
+		return nil
+	})
+	for _, path := range changed {
+		s.target.NotifyUpdate(path)
+	}
+	if advertised != nil {
+		for _, handler := range handlers {
+			handler(*advertised)
+		}
+	}
+	return err
+}
+
+// advertise advertises the carrier of the link of interface name once the
+// hold timer of advertisement seq has expired, unless it was stopped in the
+// meantime.
+func (s *Simulator) advertise(name string, seq uint64) {
+	var changed []*pb.Path
+	var advertised *Status
+	var handlers []func(Status)
+	err := s.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		intf, err := lookupInterface(config, name)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		l := s.link(name)
+		if l.hold == nil || l.seq != seq {
+			return nil
+		}
+		l.hold = nil
+		l.up = l.carrier
+		changed = advertise(name, intf, l.up)
+		st := s.status(name, intf)
+		advertised, handlers = &st, s.handlers
+		return nil
+	})
+	if err != nil {
+		log.Warnf("error in advertising the link of %s: %v", name, err)
+	}
+	for _, path := range changed {
+		s.target.NotifyUpdate(path)
+	}
+	if advertised != nil {
+		for _, handler := range handlers {
+			handler(*advertised)
+		}
+	}
+}
+
+// link returns the link of interface name. s.mu must be held.
+func (s *Simulator) link(name string) *link {
+	l, ok := s.links[name]
+	if !ok {
+		l = &link{carrier: true, up: true}
+		s.links[name] = l
+	}
+	return l
+}
+
+// up returns whether the link of interface name is advertised up.
+func (s *Simulator) up(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.links[name]
+	return !ok || l.up
+}
+
+// status returns the status of the link of intf. s.mu must be held.
+func (s *Simulator) status(name string, intf *gostruct.OpenconfigInterfaces_Interfaces_Interface) Status {
+	st := Status{Interface: name, Carrier: true, Up: true}
+	if l, ok := s.links[name]; ok {
+		st.Carrier, st.Up = l.carrier, l.up
+	}
+	if intf.State != nil && intf.State.Counters != nil && intf.State.Counters.CarrierTransitions != nil {
+		st.CarrierTransitions = *intf.State.Counters.CarrierTransitions
+	}
+	return st
+}
+
+// countTransition increments the carrier-transitions counters of intf and its
+// subinterfaces, and returns their paths.
+func countTransition(name string, intf *gostruct.OpenconfigInterfaces_Interfaces_Interface) []*pb.Path {
+	if intf.State == nil {
+		intf.State = &gostruct.OpenconfigInterfaces_Interfaces_Interface_State{}
+	}
+	if intf.State.Counters == nil {
+		intf.State.Counters = &gostruct.OpenconfigInterfaces_Interfaces_Interface_State_Counters{}
+	}
+	intf.State.Counters.CarrierTransitions = increment(intf.State.Counters.CarrierTransitions)
+	changed := []*pb.Path{interfacePath(name, "state", "counters", "carrier-transitions")}
+	if intf.Subinterfaces == nil {
+		return changed
+	}
+	for index, subintf := range intf.Subinterfaces.Subinterface {
+		if subintf.State == nil {
+			subintf.State = &gostruct.OpenconfigInterfaces_Interfaces_Interface_Subinterfaces_Subinterface_State{}
+		}
+		if subintf.State.Counters == nil {
+			subintf.State.Counters = &gostruct.OpenconfigInterfaces_Interfaces_Interface_Subinterfaces_Subinterface_State_Counters{}
+		}
+		subintf.State.Counters.CarrierTransitions = increment(subintf.State.Counters.CarrierTransitions)
+		changed = append(changed, subinterfacePath(name, index, "state", "counters", "carrier-transitions"))
+	}
+	return changed
+}
+
+// advertise sets the operational status of intf and of its subinterfaces that
+// are administratively up after its link went up or down, and returns the
+// paths of the leaves it changed.
+func advertise(name string, intf *gostruct.OpenconfigInterfaces_Interfaces_Interface, up bool) []*pb.Path {
+	var changed []*pb.Path
+	now := uint64(time.Now().UnixNano())
+	if intf.State == nil {
+		intf.State = &gostruct.OpenconfigInterfaces_Interfaces_Interface_State{}
+	}
+	var enabled *bool
+	if intf.Config != nil {
+		enabled = intf.Config.Enabled
+	}
+	if adminUp(intf.State.AdminStatus, enabled) {
+		operStatus := gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_DOWN
+		if up {
+			operStatus = gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_UP
+		}
+		if intf.State.OperStatus != operStatus {
+			intf.State.OperStatus = operStatus
+			intf.State.LastChange = ygot.Uint64(now)
+			changed = append(changed, interfacePath(name, "state", "oper-status"), interfacePath(name, "state", "last-change"))
+		}
+	}
+	if intf.Subinterfaces == nil {
+		return changed
+	}
+	for index, subintf := range intf.Subinterfaces.Subinterface {
+		if subintf.State == nil {
+			subintf.State = &gostruct.OpenconfigInterfaces_Interfaces_Interface_Subinterfaces_Subinterface_State{}
+		}
+		enabled = nil
+		if subintf.Config != nil {
+			enabled = subintf.Config.Enabled
+		}
+		if !adminUp(subintf.State.AdminStatus, enabled) {
+			continue
+		}
+		operStatus := gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_LOWER_LAYER_DOWN
+		if up {
+			operStatus = gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_UP
+		}
+		if subintf.State.OperStatus != operStatus {
+			subintf.State.OperStatus = operStatus
+			subintf.State.LastChange = ygot.Uint64(now)
+			changed = append(changed, subinterfacePath(name, index, "state", "oper-status"), subinterfacePath(name, index, "state", "last-change"))
+		}
+	}
+	return changed
+}
+
+// adminUp returns whether an interface or subinterface is administratively
+// up: its admin status is UP, or it is unset and the interface is enabled,
+// which it is by default.
+func adminUp(adminStatus gostruct.E_OpenconfigInterfaces_Interfaces_Interface_State_AdminStatus, enabled *bool) bool {
+	if adminStatus != gostruct.OpenconfigInterfaces_Interfaces_Interface_State_AdminStatus_UNSET {
+		return adminStatus == gostruct.OpenconfigInterfaces_Interfaces_Interface_State_AdminStatus_UP
+	}
+	return enabled == nil || *enabled
+}
+
+// holdTime returns the hold-time of intf before advertising that its carrier
+// went up or down.
+func holdTime(intf *gostruct.OpenconfigInterfaces_Interfaces_Interface, up bool) time.Duration {
+	if intf.HoldTime == nil || intf.HoldTime.Config == nil {
+		return 0
+	}
+	ms := intf.HoldTime.Config.Down
+	if up {
+		ms = intf.HoldTime.Config.Up
+	}
+	if ms == nil {
+		return 0
+	}
+	return time.Duration(*ms) * time.Millisecond
+}
+
+func increment(counter *uint64) *uint64 {
+	if counter == nil {
+		return ygot.Uint64(1)
+	}
+	return ygot.Uint64(*counter + 1)
+}
+
+// openconfigDevice returns config as an openconfig device.
+func openconfigDevice(config ygot.ValidatedGoStruct) (*gostruct.Device, error) {
+	device, ok := config.(*gostruct.Device)
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "config tree is not an openconfig device: %T", config)
+	}
+	return device, nil
+}
+
+// lookupInterface returns interface name of config.
+func lookupInterface(config ygot.ValidatedGoStruct, name string) (*gostruct.OpenconfigInterfaces_Interfaces_Interface, error) {
+	device, err := openconfigDevice(config)
+	if err != nil {
+		return nil, err
+	}
+	if device.Interfaces == nil || device.Interfaces.Interface[name] == nil {
+		return nil, status.Errorf(codes.NotFound, "interface %s not found", name)
+	}
+	return device.Interfaces.Interface[name], nil
+}
+
+// interfaceName returns the name of the interface of path.
+func interfaceName(path *pb.Path) string {
+	for _, elem := range path.GetElem() {
+		if elem.GetName() == "interface" {
+			return elem.GetKey()["name"]
+		}
+	}
+	return ""
+}
+
+// interfacePath returns the path of the leaf at names of interface name.
+func interfacePath(name string, names ...string) *pb.Path {
+	path := &pb.Path{Elem: []*pb.PathElem{
+		{Name: "interfaces"},
+		{Name: "interface", Key: map[string]string{"name": name}},
+	}}
+	for _, n := range names {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: n})
+	}
+	return path
+}
+
+// subinterfacePath returns the path of the leaf at names of subinterface
+// index of interface name.
+func subinterfacePath(name string, index uint32, names ...string) *pb.Path {
+	path := interfacePath(name, "subinterfaces")
+	path.Elem = append(path.Elem, &pb.PathElem{Name: "subinterface", Key: map[string]string{"index": strconv.FormatUint(uint64(index), 10)}})
+	for _, n := range names {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: n})
+	}
+	return path
+}
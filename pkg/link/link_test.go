@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package link
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+const config = `{
+  "openconfig-interfaces:interfaces": {
+    "interface": [{
+      "name": "eth1",
+      "config": {"name": "eth1"},
+      "hold-time": {"config": {"down": 50, "up": 0}},
+      "subinterfaces": {"subinterface": [{"index": 0, "config": {"index": 0}}]}
+    }]
+  }
+}`
+
+func newServer(t *testing.T) (*gnmi.Server, *Simulator) {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	sim := NewSimulator(s)
+	var derivations []gnmi.Derivation
+	for _, p := range []string{"/interfaces/interface", "/interfaces/interface/subinterfaces/subinterface"} {
+		derivations = append(derivations, gnmi.Derivation{
+			From:    p + "/config/enabled",
+			To:      p + "/state/oper-status",
+			Derive:  operStatusOf,
+			Delayed: true,
+		})
+	}
+	if err := s.EnableMirroring(sim.Gate(derivations), time.Millisecond); err != nil {
+		t.Fatalf("error in enabling mirroring: %v", err)
+	}
+	return s, sim
+}
+
+// operStatusOf derives the operational status of an interface from its enabled
+// config.
+func operStatusOf(path *pb.Path, enabled interface{}) interface{} {
+	if enabled == true {
+		return "UP"
+	}
+	return "DOWN"
+}
+
+// operStatus returns the operational status of eth1 and of its subinterface.
+func operStatus(t *testing.T, s *gnmi.Server) (string, string) {
+	var intf, subintf string
+	err := s.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		eth1 := config.(*gostruct.Device).Interfaces.Interface["eth1"]
+		intf = eth1.State.OperStatus.String()
+		subintf = eth1.Subinterfaces.Subinterface[0].State.OperStatus.String()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error in reading the config: %v", err)
+	}
+	return intf, subintf
+}
+
+// waitOperStatus waits until eth1 and its subinterface have the given
+// operational status.
+func waitOperStatus(t *testing.T, s *gnmi.Server, intf, subintf string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		gotIntf, gotSubintf := operStatus(t, s)
+		if gotIntf == intf && gotSubintf == subintf {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("oper-status is %s and %s, want %s and %s", gotIntf, gotSubintf, intf, subintf)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSimulator(t *testing.T) {
+	s, sim := newServer(t)
+	changes := make(chan Status, 10)
+	sim.AddChangeHandler(func(st Status) { changes <- st })
+	waitOperStatus(t, s, "UP", "UP")
+
+	// A flap shorter than the down hold-time is not advertised.
+	if err := sim.Flap("eth1", time.Millisecond); err != nil {
+		t.Fatalf("error in flapping: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st, err := sim.Link("eth1")
+		if err != nil {
+			t.Fatalf("error in reading the link: %v", err)
+		}
+		if st.Carrier {
+			if !st.Up || st.CarrierTransitions != 2 {
+				t.Errorf("link after a short flap is %+v, want up with 2 carrier transitions", st)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the flap did not end")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if intf, subintf := operStatus(t, s); intf != "UP" || subintf != "UP" {
+		t.Errorf("oper-status after a short flap is %s and %s, want UP", intf, subintf)
+	}
+
+	// The carrier going down is advertised after the down hold-time.
+	start := time.Now()
+	if err := sim.SetCarrier("eth1", false); err != nil {
+		t.Fatalf("error in bringing the carrier down: %v", err)
+	}
+	if intf, _ := operStatus(t, s); intf != "UP" {
+		t.Errorf("oper-status within the hold-time is %s, want UP", intf)
+	}
+	waitOperStatus(t, s, "DOWN", "LOWER_LAYER_DOWN")
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("the link went down after %v, before the hold-time", elapsed)
+	}
+	select {
+	case st := <-changes:
+		if st.Interface != "eth1" || st.Up {
+			t.Errorf("change handler called with %+v, want eth1 down", st)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("change handler not called after the link went down")
+	}
+	var lastChange *uint64
+	_ = s.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		lastChange = config.(*gostruct.Device).Interfaces.Interface["eth1"].State.LastChange
+		return nil
+	})
+	if lastChange == nil || *lastChange < uint64(start.UnixNano()) {
+		t.Errorf("last-change is %v, want a time after %v", lastChange, start.UnixNano())
+	}
+
+	// The derivations of the oper-status are gated by the link.
+	if err := s.Reload([]byte(config)); err != nil {
+		t.Fatalf("error in reloading: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if intf, subintf := operStatus(t, s); intf != "DOWN" || subintf != "LOWER_LAYER_DOWN" {
+		t.Errorf("oper-status derived while the link is down is %s and %s, want DOWN and LOWER_LAYER_DOWN", intf, subintf)
+	}
+
+	// The carrier going up is advertised at once without up hold-time.
+	if err := sim.SetCarrier("eth1", true); err != nil {
+		t.Fatalf("error in bringing the carrier up: %v", err)
+	}
+	if intf, subintf := operStatus(t, s); intf != "UP" || subintf != "UP" {
+		t.Errorf("oper-status after the carrier went up is %s and %s, want UP", intf, subintf)
+	}
+	select {
+	case st := <-changes:
+		if !st.Up {
+			t.Errorf("change handler called with %+v, want eth1 up", st)
+		}
+	default:
+		t.Error("change handler not called after the link went up")
+	}
+	if len(changes) != 0 {
+		t.Errorf("change handler called %d more times, after the short flap", len(changes))
+	}
+
+	if err := sim.SetCarrier("eth2", false); err == nil {
+		t.Error("bringing down the carrier of an unknown interface succeeded")
+	}
+}
@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package messages simulates the syslog stream of an openconfig device. The
+// messages of the events of the device are published in turn into
+// messages/state/message, where clients subscribed ON_CHANGE receive them,
+// unless the severity and debug-service config of messages filter them out.
+package messages
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var log = logging.GetLogger("messages")
+
+// Target is the simulated device whose messages are generated. It is
+// implemented by gnmi.Server.
+type Target interface {
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+	NotifyUpdate(path *pb.Path)
+}
+
+// Facility is the syslog facility of a message, as in RFC 5424.
+type Facility uint8
+
+// Facilities of the messages of the simulated events.
+const (
+	FacilityDaemon Facility = 3
+	FacilityAuth   Facility = 4
+	FacilityLocal7 Facility = 23
+)
+
+// Message is a syslog message.
+type Message struct {
+	// Severity is the severity of the message.
+	Severity gostruct.E_OpenconfigMessages_SyslogSeverity
+	// Facility is the facility of the message, used with the severity to
+	// compute its priority.
+	Facility Facility
+	// AppName is the name of the application that originated the message.
+	AppName string
+	// Procid is the process id of the application, the process id of the
+	// simulator when empty.
+	Procid string
+	// Msgid is the type of the message.
+	Msgid string
+	// Msg is the text of the message.
+	Msg string
+	// Service is the debug service of a DEBUG message, which is only
+	// published while the debug-entries of the service are enabled.
+	Service string
+}
+
+// Generator publishes the messages of a device.
+type Generator struct {
+	target Target
+}
+
+// NewGenerator returns the message generator of target.
+func NewGenerator(target Target) *Generator {
+	return &Generator{target: target}
+}
+
+// Emit publishes m into messages/state/message and notifies the clients
+// subscribed to the leaves of the message it changed, or to its msg when it
+// repeats the last message, unless m is less severe than
+// messages/config/severity or is a DEBUG message of a service not enabled in
+// messages/debug-entries. It returns whether m was published.
+func (g *Generator) Emit(m Message) (bool, error) {
+	if m.Severity < gostruct.OpenconfigMessages_SyslogSeverity_EMERGENCY || m.Severity > gostruct.OpenconfigMessages_SyslogSeverity_DEBUG {
+		return false, status.Errorf(codes.InvalidArgument, "invalid severity %d", m.Severity)
+	}
+	var changed []string
+	published := false
+	err := g.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "config tree is not an openconfig device: %T", config)
+		}
+		if device.Messages == nil {
+			device.Messages = &gostruct.OpenconfigMessages_Messages{}
+		}
+		if !accepts(device.Messages, m) {
+			return nil
+		}
+		if device.Messages.State == nil {
+			device.Messages.State = &gostruct.OpenconfigMessages_Messages_State{}
+		}
+		procid := m.Procid
+		if procid == "" {
+			procid = strconv.Itoa(os.Getpid())
+		}
+		message := &gostruct.OpenconfigMessages_Messages_State_Message{
+			Msg:      ygot.String(m.Msg),
+			Priority: ygot.Uint8(priority(m)),
+			AppName:  ygot.String(m.AppName),
+			Procid:   ygot.String(procid),
+			Msgid:    ygot.String(m.Msgid),
+		}
+		changed = changedLeaves(device.Messages.State.Message, message)
+		device.Messages.State.Message = message
+		published = true
+		return nil
+	})
+	if err != nil || !published {
+		return false, err
+	}
+	if len(changed) == 0 {
+		changed = []string{"msg"}
+	}
+	for _, leaf := range changed {
+		g.target.NotifyUpdate(messagePath(leaf))
+	}
+	return true, nil
+}
+
+// changedLeaves returns the names of the leaves of message that differ from
+// those of old, if any.
+func changedLeaves(old, message *gostruct.OpenconfigMessages_Messages_State_Message) []string {
+	if old == nil {
+		old = &gostruct.OpenconfigMessages_Messages_State_Message{}
+	}
+	var changed []string
+	for _, leaf := range []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"msg", old.Msg, message.Msg},
+		{"priority", old.Priority, message.Priority},
+		{"app-name", old.AppName, message.AppName},
+		{"procid", old.Procid, message.Procid},
+		{"msgid", old.Msgid, message.Msgid},
+	} {
+		if !reflect.DeepEqual(leaf.old, leaf.new) {
+			changed = append(changed, leaf.name)
+		}
+	}
+	return changed
+}
+
+// Log publishes m, logging the error in publishing it if any. It is meant
+// for the handlers of the events of the device. A nil generator, of a device
+// without messages, publishes nothing.
+func (g *Generator) Log(m Message) {
+	if g == nil {
+		return
+	}
+	if _, err := g.Emit(m); err != nil {
+		log.Warnf("error in publishing message %q: %v", m.Msg, err)
+	}
+}
+
+// Commit returns the message of the commit of a Set request of user.
+func Commit(user string) Message {
+	if user == "" {
+		user = "unknown"
+	}
+	return Message{
+		Severity: gostruct.OpenconfigMessages_SyslogSeverity_NOTICE,
+		Facility: FacilityLocal7,
+		AppName:  "mgmtd",
+		Msgid:    "CONFIG_COMMIT",
+		Msg:      fmt.Sprintf("Configuration committed by user %s", user),
+	}
+}
+
+// Login returns the message of the authentication of user, with the outcome
+// reason of the authentication.
+func Login(user, reason string, allowed bool) Message {
+	if user == "" {
+		user = "unknown"
+	}
+	if allowed {
+		return Message{
+			Severity: gostruct.OpenconfigMessages_SyslogSeverity_INFORMATIONAL,
+			Facility: FacilityAuth,
+			AppName:  "aaad",
+			Msgid:    "LOGIN_SUCCESS",
+			Msg:      fmt.Sprintf("User %s logged in: %s", user, reason),
+		}
+	}
+	return Message{
+		Severity: gostruct.OpenconfigMessages_SyslogSeverity_WARNING,
+		Facility: FacilityAuth,
+		AppName:  "aaad",
+		Msgid:    "LOGIN_FAILED",
+		Msg:      fmt.Sprintf("Login failed for user %s: %s", user, reason),
+	}
+}
+
+// Link returns the message of the link of interface name going up or down.
+func Link(name string, up bool) Message {
+	if up {
+		return Message{
+			Severity: gostruct.OpenconfigMessages_SyslogSeverity_NOTICE,
+			Facility: FacilityDaemon,
+			AppName:  "ifmgr",
+			Msgid:    "LINK_UP",
+			Msg:      fmt.Sprintf("Interface %s, changed state to up", name),
+		}
+	}
+	return Message{
+		Severity: gostruct.OpenconfigMessages_SyslogSeverity_ERROR,
+		Facility: FacilityDaemon,
+		AppName:  "ifmgr",
+		Msgid:    "LINK_DOWN",
+		Msg:      fmt.Sprintf("Interface %s, changed state to down", name),
+	}
+}
+
+// Boot returns the message of the device booting after a reboot.
+func Boot() Message {
+	return Message{
+		Severity: gostruct.OpenconfigMessages_SyslogSeverity_CRITICAL,
+		Facility: FacilityDaemon,
+		AppName:  "sysmgr",
+		Msgid:    "SYSTEM_RESTART",
+		Msg:      "System restarted after a reboot",
+	}
+}
+
+// accepts returns whether the severity and debug-service config of messages
+// let m be published. All severities are published when the severity is not
+// configured.
+func accepts(messages *gostruct.OpenconfigMessages_Messages, m Message) bool {
+	if messages.Config != nil && messages.Config.Severity != gostruct.OpenconfigMessages_SyslogSeverity_UNSET &&
+		m.Severity > messages.Config.Severity {
+		return false
+	}
+	if m.Severity != gostruct.OpenconfigMessages_SyslogSeverity_DEBUG {
+		return true
+	}
+	if messages.DebugEntries == nil {
+		return false
+	}
+	for service, entry := range messages.DebugEntries.DebugService {
+		name, err := ygot.EnumName(service)
+		if err != nil || name != m.Service {
+			continue
+		}
+		return entry.Config != nil && entry.Config.Enabled != nil && *entry.Config.Enabled
+	}
+	return false
+}
+
+// priority returns the syslog priority of m: its facility times 8 plus its
+// syslog severity, which counts from 0 for EMERGENCY.
+func priority(m Message) uint8 {
+	return uint8(m.Facility)*8 + uint8(m.Severity-gostruct.OpenconfigMessages_SyslogSeverity_EMERGENCY)
+}
+
+// messagePath returns the path of messages/state/message or of its leaf at
+// names.
+func messagePath(names ...string) *pb.Path {
+	path := &pb.Path{Elem: []*pb.PathElem{{Name: "messages"}, {Name: "state"}, {Name: "message"}}}
+	for _, n := range names {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: n})
+	}
+	return path
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package messages
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+const config = `{
+  "openconfig-messages:messages": {
+    "config": {"severity": "WARNING"}
+  }
+}`
+
+// target is a gnmi.Server recording the paths it notifies.
+type target struct {
+	*gnmi.Server
+	notified []string
+}
+
+func (t *target) NotifyUpdate(path *pb.Path) {
+	t.notified = append(t.notified, path.String())
+	t.Server.NotifyUpdate(path)
+}
+
+func newTarget(t *testing.T) *target {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	return &target{Server: s}
+}
+
+// message returns messages/state/message of s.
+func message(t *testing.T, s *gnmi.Server) gostruct.OpenconfigMessages_Messages_State_Message {
+	var msg gostruct.OpenconfigMessages_Messages_State_Message
+	err := s.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		if m := config.(*gostruct.Device).Messages; m != nil && m.State != nil && m.State.Message != nil {
+			msg = *m.State.Message
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error in reading the config: %v", err)
+	}
+	return msg
+}
+
+func TestGenerator(t *testing.T) {
+	tgt := newTarget(t)
+	g := NewGenerator(tgt)
+
+	// A message less severe than the configured severity is filtered out.
+	if published, err := g.Emit(Commit("admin")); err != nil || published {
+		t.Errorf("emitting a NOTICE message returned %v, %v, want it filtered out", published, err)
+	}
+	if msg := message(t, tgt.Server); msg.Msg != nil {
+		t.Errorf("filtered message was published: %q", *msg.Msg)
+	}
+
+	if published, err := g.Emit(Link("eth1", false)); err != nil || !published {
+		t.Fatalf("emitting an ERROR message returned %v, %v, want it published", published, err)
+	}
+	msg := message(t, tgt.Server)
+	if msg.Msg == nil || *msg.Msg != "Interface eth1, changed state to down" {
+		t.Errorf("msg is %v, want the link down message", msg.Msg)
+	}
+	// daemon(3) * 8 + error(3)
+	if msg.Priority == nil || *msg.Priority != 27 {
+		t.Errorf("priority is %v, want 27", msg.Priority)
+	}
+	if msg.AppName == nil || *msg.AppName != "ifmgr" || msg.Msgid == nil || *msg.Msgid != "LINK_DOWN" {
+		t.Errorf("app-name and msgid are %v and %v, want ifmgr and LINK_DOWN", msg.AppName, msg.Msgid)
+	}
+	if msg.Procid == nil || *msg.Procid == "" {
+		t.Error("procid is not set")
+	}
+	var want []string
+	for _, leaf := range []string{"msg", "priority", "app-name", "procid", "msgid"} {
+		want = append(want, messagePath(leaf).String())
+	}
+	if !reflect.DeepEqual(tgt.notified, want) {
+		t.Errorf("notified paths are %v, want %v", tgt.notified, want)
+	}
+
+	// The same message again changes no leaf, its msg is notified anew.
+	tgt.notified = nil
+	if _, err := g.Emit(Link("eth1", false)); err != nil {
+		t.Fatalf("error in emitting the message again: %v", err)
+	}
+	if want := []string{messagePath("msg").String()}; !reflect.DeepEqual(tgt.notified, want) {
+		t.Errorf("notified paths are %v for the same message, want %v", tgt.notified, want)
+	}
+
+	// DEBUG messages need their debug service to be enabled.
+	if err := tgt.Reload([]byte(`{"openconfig-messages:messages": {"config": {"severity": "DEBUG"}}}`)); err != nil {
+		t.Fatalf("error in reloading: %v", err)
+	}
+	debug := Message{Severity: gostruct.OpenconfigMessages_SyslogSeverity_DEBUG, AppName: "ifmgr", Msg: "debug", Service: "LINK"}
+	if published, err := g.Emit(debug); err != nil || published {
+		t.Errorf("emitting a DEBUG message of a disabled service returned %v, %v, want it filtered out", published, err)
+	}
+	if published, err := g.Emit(Commit("admin")); err != nil || !published {
+		t.Errorf("emitting a NOTICE message with DEBUG severity returned %v, %v, want it published", published, err)
+	}
+
+	if _, err := g.Emit(Message{Msg: "no severity"}); err == nil {
+		t.Error("emitting a message without severity succeeded")
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+)
+
+var (
+	authenticationsDesc = prometheus.NewDesc("gnxi_aaa_authentications_total",
+		"Number of authentications of the users of the RPCs, by target and result.", []string{"target", "result"}, nil)
+	authorizationsDesc = prometheus.NewDesc("gnxi_aaa_authorizations_total",
+		"Number of authorizations of the authenticated users of the RPCs, by target and result.", []string{"target", "result"}, nil)
+	accountingRecordsDesc = prometheus.NewDesc("gnxi_aaa_accounting_records_total",
+		"Number of accounting records of the authorized RPCs, by target.", []string{"target"}, nil)
+)
+
+// AddAuthenticator adds the authenticator checking the users of device name
+// against its AAA configuration.
+func (d *Devices) AddAuthenticator(name string, a *aaa.Authenticator) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.authenticators == nil {
+		d.authenticators = make(map[string]*aaa.Authenticator)
+	}
+	d.authenticators[name] = a
+}
+
+// collectAAA collects the AAA counters of device name.
+func collectAAA(ch chan<- prometheus.Metric, name string, counters aaa.Counters) {
+	ch <- prometheus.MustNewConstMetric(authenticationsDesc, prometheus.CounterValue, float64(counters.AuthenticationAccepts), name, "accept")
+	ch <- prometheus.MustNewConstMetric(authenticationsDesc, prometheus.CounterValue, float64(counters.AuthenticationRejects), name, "reject")
+	ch <- prometheus.MustNewConstMetric(authorizationsDesc, prometheus.CounterValue, float64(counters.AuthorizationAccepts), name, "accept")
+	ch <- prometheus.MustNewConstMetric(authorizationsDesc, prometheus.CounterValue, float64(counters.AuthorizationRejects), name, "reject")
+	ch <- prometheus.MustNewConstMetric(accountingRecordsDesc, prometheus.CounterValue, float64(counters.AccountingRecords), name)
+}
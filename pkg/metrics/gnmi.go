@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+)
+
+var (
+	streamsDesc = prometheus.NewDesc("gnxi_gnmi_subscribe_streams",
+		"Number of active Subscribe streams, by target and mode of their subscription list.", []string{"target", "mode"}, nil)
+	subscriptionsDesc = prometheus.NewDesc("gnxi_gnmi_stream_subscriptions",
+		"Number of subscriptions of the active STREAM Subscribe streams, by target and mode.", []string{"target", "mode"}, nil)
+	sentDesc = prometheus.NewDesc("gnxi_gnmi_notifications_sent_total",
+		"Number of notifications sent to the subscribers, by target.", []string{"target"}, nil)
+	droppedDesc = prometheus.NewDesc("gnxi_gnmi_notifications_dropped_total",
+		"Number of notifications dropped, by target and reason.", []string{"target", "reason"}, nil)
+	setFailuresDesc = prometheus.NewDesc("gnxi_gnmi_set_failures_total",
+		"Number of failed Set requests, by target and reason.", []string{"target", "reason"}, nil)
+	configLeavesDesc = prometheus.NewDesc("gnxi_gnmi_config_leaves",
+		"Number of leaves and leaf-list values of the tree, by target.", []string{"target"}, nil)
+)
+
+// Devices exports the metrics of the gNMI servers of devices, labelled with
+// the names of the devices. They are collected from the servers, and from the
+// authenticators of the devices checking AAA, when the metrics are scraped.
+type Devices struct {
+	mu             sync.Mutex
+	names          []string
+	servers        []*gnmi.Server
+	authenticators map[string]*aaa.Authenticator
+}
+
+// NewDevices registers the metrics of the devices in r.
+func NewDevices(r prometheus.Registerer) *Devices {
+	d := &Devices{}
+	r.MustRegister(d)
+	return d
+}
+
+// Add adds the gNMI server of device name.
+func (d *Devices) Add(name string, s *gnmi.Server) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.names = append(d.names, name)
+	d.servers = append(d.servers, s)
+}
+
+// Describe sends the descriptions of the metrics of the devices.
+func (d *Devices) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		streamsDesc, subscriptionsDesc, sentDesc, droppedDesc, setFailuresDesc, configLeavesDesc,
+		authenticationsDesc, authorizationsDesc, accountingRecordsDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect collects the metrics of the devices from their servers.
+func (d *Devices) Collect(ch chan<- prometheus.Metric) {
+	d.mu.Lock()
+	names := append([]string(nil), d.names...)
+	servers := append([]*gnmi.Server(nil), d.servers...)
+	authenticators := make(map[string]*aaa.Authenticator, len(d.authenticators))
+	for name, a := range d.authenticators {
+		authenticators[name] = a
+	}
+	d.mu.Unlock()
+	for i, name := range names {
+		collectStreams(ch, name, servers[i])
+		collectStats(ch, name, servers[i].Stats())
+		if a, ok := authenticators[name]; ok {
+			collectAAA(ch, name, a.Counters())
+		}
+		leaves, err := servers[i].ConfigLeaves()
+		if err != nil {
+			log.Errorf("Error in counting the leaves of %s: %v", name, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(configLeavesDesc, prometheus.GaugeValue, float64(leaves), name)
+	}
+}
+
+// collectStreams collects the Subscribe streams and the subscriptions of
+// device name.
+func collectStreams(ch chan<- prometheus.Metric, name string, s *gnmi.Server) {
+	streams := zeroModes(pb.SubscriptionList_Mode_name)
+	subscriptions := zeroModes(pb.SubscriptionMode_name)
+	for _, sub := range s.Subscriptions() {
+		if sub.Request == nil {
+			continue
+		}
+		streams[modeName(sub.Request.GetMode().String())]++
+		if sub.Request.GetMode() != pb.SubscriptionList_STREAM {
+			continue
+		}
+		for _, subscription := range sub.Request.GetSubscription() {
+			subscriptions[modeName(subscription.GetMode().String())]++
+		}
+	}
+	for mode, n := range streams {
+		ch <- prometheus.MustNewConstMetric(streamsDesc, prometheus.GaugeValue, float64(n), name, mode)
+	}
+	for mode, n := range subscriptions {
+		ch <- prometheus.MustNewConstMetric(subscriptionsDesc, prometheus.GaugeValue, float64(n), name, mode)
+	}
+}
+
+// collectStats collects the counters of device name.
+func collectStats(ch chan<- prometheus.Metric, name string, stats gnmi.Stats) {
+	ch <- prometheus.MustNewConstMetric(sentDesc, prometheus.CounterValue, float64(stats.NotificationsSent), name)
+	for _, reason := range []string{gnmi.DropOverflow, gnmi.DropSend} {
+		ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(stats.NotificationsDropped[reason]), name, reason)
+	}
+	for reason, n := range stats.SetFailures {
+		ch <- prometheus.MustNewConstMetric(setFailuresDesc, prometheus.CounterValue, float64(n), name, reason)
+	}
+}
+
+// zeroModes returns a count of 0 for each mode of enumeration names.
+func zeroModes(names map[int32]string) map[string]int {
+	modes := make(map[string]int, len(names))
+	for _, mode := range names {
+		modes[modeName(mode)] = 0
+	}
+	return modes
+}
+
+// modeName returns the name of a subscription mode as a label value.
+func modeName(mode string) string {
+	return strings.ToLower(mode)
+}
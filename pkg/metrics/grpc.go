@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// RPCs records the RPCs served by gRPC servers through its interceptors: the
+// number of RPCs by service, method and code, and their latency by service
+// and method. The latency of a streaming RPC such as Subscribe is the time
+// the stream lasts.
+type RPCs struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewRPCs registers the metrics of the RPCs in r.
+func NewRPCs(r prometheus.Registerer) *RPCs {
+	m := &RPCs{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gnxi_grpc_requests_total",
+			Help: "Number of RPCs completed, by service, method and code.",
+		}, []string{"service", "method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gnxi_grpc_request_duration_seconds",
+			Help:    "Latency of the RPCs, by service and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"service", "method"}),
+	}
+	r.MustRegister(m.requests, m.latency)
+	return m
+}
+
+// UnaryInterceptor records the unary RPCs.
+func (m *RPCs) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.record(info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamInterceptor records the streaming RPCs.
+func (m *RPCs) StreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, stream)
+	m.record(info.FullMethod, start, err)
+	return err
+}
+
+// record records RPC fullMethod, which started at start and ended with err.
+func (m *RPCs) record(fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	m.requests.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	m.latency.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+// splitMethod returns the service and the method of full method name
+// /service/method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
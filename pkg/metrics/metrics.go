@@ -0,0 +1,14 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics exports the metrics of the simulator to Prometheus. The
+// metrics are registered in a prometheus.Registerer, whose registry serves
+// them in the Prometheus text exposition format through promhttp.
+package metrics
+
+import (
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+)
+
+var log = logging.GetLogger("metrics")
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/onosproject/gnxi-simulators/pkg/aaa"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+// scrape returns the lines of the metrics of r.
+func scrape(t *testing.T, r *prometheus.Registry) []string {
+	recorder := httptest.NewRecorder()
+	promhttp.HandlerFor(r, promhttp.HandlerOpts{}).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("scraping the metrics returned %d: %s", recorder.Code, recorder.Body.String())
+	}
+	return strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+}
+
+// contains checks that lines contain each of want.
+func contains(t *testing.T, lines []string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		found := false
+		for _, line := range lines {
+			found = found || line == w
+		}
+		if !found {
+			t.Errorf("metrics do not contain %q:\n%s", w, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+// waitFor waits for the metrics of r to contain line, recorded once an RPC
+// has returned.
+func waitFor(t *testing.T, r *prometheus.Registry, line string) {
+	t.Helper()
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		lines := scrape(t, r)
+		for _, l := range lines {
+			if l == line {
+				return
+			}
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("metrics do not contain %q:\n%s", line, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+func TestDevices(t *testing.T) {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(`{"openconfig-system:system": {"config": {"hostname": "switch1"}}}`), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	r := prometheus.NewRegistry()
+	rpcs := NewRPCs(r)
+	devices := NewDevices(r)
+	devices.Add("switch1", s)
+
+	g := grpc.NewServer(grpc.UnaryInterceptor(rpcs.UnaryInterceptor), grpc.StreamInterceptor(rpcs.StreamInterceptor))
+	pb.RegisterGNMIServer(g, s)
+	listen, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	go func() { _ = g.Serve(listen) }()
+	defer g.Stop()
+	conn, err := grpc.Dial(listen.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("error in dialing: %v", err)
+	}
+	defer conn.Close()
+	client := pb.NewGNMIClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hostname := &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}}}
+	if _, err := client.Get(ctx, &pb.GetRequest{Path: []*pb.Path{hostname}, Encoding: pb.Encoding_JSON}); err != nil {
+		t.Fatalf("error in Get: %v", err)
+	}
+	set := &pb.SetRequest{Update: []*pb.Update{{
+		Path: &pb.Path{Elem: []*pb.PathElem{{Name: "system"}, {Name: "unknown"}}},
+		Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "x"}},
+	}}}
+	if _, err := client.Set(ctx, set); err == nil {
+		t.Fatalf("Set of an unknown path succeeded")
+	}
+
+	stream, err := client.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("error in subscribing: %v", err)
+	}
+	list := &pb.SubscriptionList{Subscription: []*pb.Subscription{{Path: hostname, Mode: pb.SubscriptionMode_ON_CHANGE}}}
+	if err := stream.Send(&pb.SubscribeRequest{Request: &pb.SubscribeRequest_Subscribe{Subscribe: list}}); err != nil {
+		t.Fatalf("error in sending the subscription: %v", err)
+	}
+	for start := time.Now(); len(s.Subscriptions()) == 0 || s.Subscriptions()[0].Request == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("the subscription is not active")
+		}
+	}
+	set = &pb.SetRequest{Update: []*pb.Update{{Path: hostname, Val: &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: "switch2"}}}}}
+	if _, err := client.Set(ctx, set); err != nil {
+		t.Fatalf("error in Set: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.GetUpdate() == nil {
+		t.Fatalf("received %v, %v, want a notification", resp, err)
+	}
+
+	waitFor(t, r, `gnxi_gnmi_notifications_sent_total{target="switch1"} 1`)
+	contains(t, scrape(t, r),
+		`gnxi_grpc_requests_total{code="OK",method="Get",service="gnmi.gNMI"} 1`,
+		`gnxi_grpc_requests_total{code="NotFound",method="Set",service="gnmi.gNMI"} 1`,
+		`gnxi_grpc_requests_total{code="OK",method="Set",service="gnmi.gNMI"} 1`,
+		`gnxi_grpc_request_duration_seconds_count{method="Get",service="gnmi.gNMI"} 1`,
+		`gnxi_gnmi_subscribe_streams{mode="stream",target="switch1"} 1`,
+		`gnxi_gnmi_subscribe_streams{mode="once",target="switch1"} 0`,
+		`gnxi_gnmi_stream_subscriptions{mode="on_change",target="switch1"} 1`,
+		`gnxi_gnmi_stream_subscriptions{mode="sample",target="switch1"} 0`,
+		`gnxi_gnmi_notifications_dropped_total{reason="overflow",target="switch1"} 0`,
+		`gnxi_gnmi_set_failures_total{reason="unknown_path",target="switch1"} 1`,
+		`gnxi_gnmi_config_leaves{target="switch1"} 1`,
+	)
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("error in closing the stream: %v", err)
+	}
+	waitFor(t, r, `gnxi_grpc_requests_total{code="OK",method="Subscribe",service="gnmi.gNMI"} 1`)
+}
+
+func TestDevicesAAA(t *testing.T) {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	config := `{"openconfig-system:system": {"aaa": {"authentication": {"users": {"user": [
+		{"username": "bob", "config": {"username": "bob", "password": "secret", "role": "read-only"}}
+	]}}}}}`
+	s, err := gnmi.NewServer(model, []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	r := prometheus.NewRegistry()
+	devices := NewDevices(r)
+	devices.Add("switch1", s)
+	a := aaa.NewAuthenticator(s)
+	devices.AddAuthenticator("switch1", a)
+
+	a.AuthorizeIdentity("bob", aaa.OperationRead)
+	a.AuthorizeIdentity("bob", aaa.OperationWrite)
+	a.AuthorizeIdentity("carol", aaa.OperationRead)
+	contains(t, scrape(t, r),
+		`gnxi_aaa_authentications_total{result="accept",target="switch1"} 2`,
+		`gnxi_aaa_authentications_total{result="reject",target="switch1"} 1`,
+		`gnxi_aaa_authorizations_total{result="accept",target="switch1"} 1`,
+		`gnxi_aaa_authorizations_total{result="reject",target="switch1"} 1`,
+		`gnxi_aaa_accounting_records_total{target="switch1"} 1`,
+	)
+}
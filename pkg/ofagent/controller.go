@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ofagent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// Controller is a local stand-in for an OpenFlow controller: it completes the
+// HELLO exchange with the switches connecting to it, requests their features
+// and port descriptions, logs them with their port status changes, and
+// answers their echo requests.
+type Controller struct {
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+// NewController returns a controller stand-in.
+func NewController() *Controller {
+	return &Controller{conns: make(map[net.Conn]bool)}
+}
+
+// Serve accepts the connections of lis until the controller is closed.
+func (c *Controller) Serve(lis net.Listener) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return lis.Close()
+	}
+	c.listener = lis
+	c.mu.Unlock()
+	for {
+		nc, err := lis.Accept()
+		if err != nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.closed {
+				return nil
+			}
+			return err
+		}
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			nc.Close()
+			return nil
+		}
+		c.conns[nc] = true
+		c.mu.Unlock()
+		go c.serve(nc)
+	}
+}
+
+// Close stops accepting connections and closes the connections of the
+// switches.
+func (c *Controller) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for nc := range c.conns {
+		nc.Close()
+	}
+	if c.listener != nil {
+		return c.listener.Close()
+	}
+	return nil
+}
+
+// serve serves the connection of a switch until it is closed.
+func (c *Controller) serve(nc net.Conn) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.conns, nc)
+		c.mu.Unlock()
+		nc.Close()
+	}()
+	oc := &conn{Conn: nc}
+	if err := oc.handshake(); err != nil {
+		log.Debugf("Error in the handshake of %s: %v", nc.RemoteAddr(), err)
+		return
+	}
+	portDesc := make([]byte, multipartHeaderLen)
+	binary.BigEndian.PutUint16(portDesc, multipartTypePortDesc)
+	if err := oc.request(typeFeaturesRequest, nil); err != nil {
+		return
+	}
+	if err := oc.request(typeMultipartRequest, portDesc); err != nil {
+		return
+	}
+	for {
+		h, body, err := oc.receive()
+		if err != nil {
+			log.Infof("Switch %s disconnected: %v", nc.RemoteAddr(), err)
+			return
+		}
+		switch {
+		case h.typ == typeEchoRequest:
+			if err := oc.send(typeEchoReply, h.xid, body); err != nil {
+				return
+			}
+		case h.typ == typeFeaturesReply && len(body) >= 14:
+			log.Infof("Switch %s connected with datapath id %016x and auxiliary id %d", nc.RemoteAddr(), binary.BigEndian.Uint64(body), body[13])
+		case h.typ == typeMultipartReply && len(body) >= multipartHeaderLen && binary.BigEndian.Uint16(body) == multipartTypePortDesc:
+			for b := body[multipartHeaderLen:]; len(b) >= portDescLen; b = b[portDescLen:] {
+				log.Infof("Switch %s has port %d %s", nc.RemoteAddr(), binary.BigEndian.Uint32(b), portName(b))
+			}
+		case h.typ == typePortStatus && len(body) >= 8+portDescLen:
+			b := body[8:]
+			log.Infof("Switch %s port %d %s changed, reason %d, state %d", nc.RemoteAddr(), binary.BigEndian.Uint32(b), portName(b), body[0], binary.BigEndian.Uint32(b[36:]))
+		}
+	}
+}
+
+// portName returns the name of port description b.
+func portName(b []byte) string {
+	name := b[16 : 16+portNameLen]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	return string(name)
+}
@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ofagent
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// version is the OpenFlow version spoken by the agent, OpenFlow 1.3.
+const version = 0x04
+
+const headerLen = 8
+
+// msgType is the type of an OpenFlow message.
+type msgType uint8
+
+const (
+	typeHello            msgType = 0
+	typeError            msgType = 1
+	typeEchoRequest      msgType = 2
+	typeEchoReply        msgType = 3
+	typeFeaturesRequest  msgType = 5
+	typeFeaturesReply    msgType = 6
+	typeGetConfigRequest msgType = 7
+	typeGetConfigReply   msgType = 8
+	typeSetConfig        msgType = 9
+	typePortStatus       msgType = 12
+	typePacketOut        msgType = 13
+	typeFlowMod          msgType = 14
+	typeGroupMod         msgType = 15
+	typeMultipartRequest msgType = 18
+	typeMultipartReply   msgType = 19
+	typeBarrierRequest   msgType = 20
+	typeBarrierReply     msgType = 21
+	typeRoleRequest      msgType = 24
+	typeRoleReply        msgType = 25
+	typeMeterMod         msgType = 29
+)
+
+// Types and codes of the errors sent to a peer.
+const (
+	errorHelloFailed         = 0
+	errorHelloIncompatible   = 0
+	errorBadRequest          = 1
+	errorBadRequestType      = 1
+	errorBadRequestMultipart = 2
+	errorBadRequestLength    = 6
+	errorBadRequestPort      = 11
+)
+
+const (
+	maxMessageLen = 0xffff
+	errorLen      = 4  // length of the type and code of an error
+	maxErrorData  = 64 // bytes of the faulty message in an error
+)
+
+// Types, flags and lengths of the multipart messages.
+const (
+	multipartHeaderLen     = 8
+	multipartFlagReplyMore = 1
+	multipartTypeDesc      = 0
+	multipartTypeFlow      = 1
+	multipartTypePortStats = 4
+	multipartTypePortDesc  = 13
+	descLen                = 256
+	serialNumLen           = 32
+)
+
+// Port numbers, flags and lengths of the port descriptions and statistics.
+const (
+	portAny                = 0xffffffff
+	portDescLen            = 64
+	portStatsLen           = 112
+	portNameLen            = 16
+	portConfigDown         = 1 << 0
+	portStateLinkDown      = 1 << 0
+	portStateLive          = 1 << 2
+	portStatusReasonAdd    = 0
+	portStatusReasonDelete = 1
+	portStatusReasonModify = 2
+)
+
+// Capabilities of the switch and default of its configuration.
+const (
+	capabilityFlowStats  = 1 << 0
+	capabilityTableStats = 1 << 1
+	capabilityPortStats  = 1 << 2
+	defaultMissSendLen   = 128
+)
+
+// header is the header of an OpenFlow message.
+type header struct {
+	version uint8
+	typ     msgType
+	length  uint16
+	xid     uint32
+}
+
+// conn is an OpenFlow connection, on which messages can be sent concurrently.
+type conn struct {
+	net.Conn
+	mu  sync.Mutex
+	xid uint32 // transaction id of the last request
+}
+
+// send sends a message of type typ with transaction id xid.
+func (c *conn) send(typ msgType, xid uint32, body []byte) error {
+	if headerLen+len(body) > maxMessageLen {
+		return fmt.Errorf("message of %d bytes too long", headerLen+len(body))
+	}
+	msg := make([]byte, headerLen, headerLen+len(body))
+	msg[0] = version
+	msg[1] = byte(typ)
+	binary.BigEndian.PutUint16(msg[2:], uint16(headerLen+len(body)))
+	binary.BigEndian.PutUint32(msg[4:], xid)
+	msg = append(msg, body...)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.Write(msg)
+	return err
+}
+
+// request sends a message of type typ with a new transaction id.
+func (c *conn) request(typ msgType, body []byte) error {
+	c.mu.Lock()
+	c.xid++
+	xid := c.xid
+	c.mu.Unlock()
+	return c.send(typ, xid, body)
+}
+
+// receive receives a message.
+func (c *conn) receive() (header, []byte, error) {
+	var buf [headerLen]byte
+	if _, err := io.ReadFull(c, buf[:]); err != nil {
+		return header{}, nil, err
+	}
+	h := header{
+		version: buf[0],
+		typ:     msgType(buf[1]),
+		length:  binary.BigEndian.Uint16(buf[2:]),
+		xid:     binary.BigEndian.Uint32(buf[4:]),
+	}
+	if h.length < headerLen {
+		return h, nil, fmt.Errorf("invalid message length %d", h.length)
+	}
+	body := make([]byte, h.length-headerLen)
+	if _, err := io.ReadFull(c, body); err != nil {
+		return h, nil, err
+	}
+	return h, body, nil
+}
+
+// handshake exchanges HELLO messages with the peer, failing when the peer
+// does not support OpenFlow 1.3.
+func (c *conn) handshake() error {
+	if err := c.request(typeHello, nil); err != nil {
+		return err
+	}
+	h, _, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if h.typ != typeHello {
+		return fmt.Errorf("received a message of type %d instead of HELLO", h.typ)
+	}
+	if h.version < version {
+		body := make([]byte, 4)
+		binary.BigEndian.PutUint16(body, errorHelloFailed)
+		binary.BigEndian.PutUint16(body[2:], errorHelloIncompatible)
+		_ = c.send(typeError, h.xid, body)
+		return fmt.Errorf("unsupported OpenFlow version %d", h.version)
+	}
+	return nil
+}
+
+// sendError sends an error of type typ and code to the peer, with the
+// beginning of the faulty message of header h and body.
+func (c *conn) sendError(h header, body []byte, typ, code uint16) error {
+	data := make([]byte, errorLen, errorLen+maxErrorData)
+	binary.BigEndian.PutUint16(data, typ)
+	binary.BigEndian.PutUint16(data[2:], code)
+	msg := make([]byte, headerLen)
+	msg[0] = h.version
+	msg[1] = byte(h.typ)
+	binary.BigEndian.PutUint16(msg[2:], h.length)
+	binary.BigEndian.PutUint32(msg[4:], h.xid)
+	msg = append(msg, body...)
+	if len(msg) > maxErrorData {
+		msg = msg[:maxErrorData]
+	}
+	return c.send(typeError, h.xid, append(data, msg...))
+}
+
+// sendMultipart sends the reply of type mpType to multipart request xid,
+// split in as many messages as its entries require.
+func (c *conn) sendMultipart(xid uint32, mpType uint16, entries [][]byte) error {
+	for {
+		body := make([]byte, multipartHeaderLen)
+		binary.BigEndian.PutUint16(body, mpType)
+		for len(entries) > 0 && headerLen+len(body)+len(entries[0]) <= maxMessageLen {
+			body = append(body, entries[0]...)
+			entries = entries[1:]
+		}
+		if len(entries) > 0 {
+			binary.BigEndian.PutUint16(body[2:], multipartFlagReplyMore)
+		}
+		if err := c.send(typeMultipartReply, xid, body); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+	}
+}
+
+// putString copies s into b, padded with zeros and truncated to leave a
+// terminating zero.
+func putString(b []byte, s string) {
+	if len(s) >= len(b) {
+		s = s[:len(b)-1]
+	}
+	copy(b, s)
+}
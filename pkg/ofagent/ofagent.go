@@ -0,0 +1,501 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ofagent simulates the OpenFlow 1.3 agent of a switch connecting to
+// the controllers of its openconfig-openflow config. Every connection is
+// dialed, completes the OpenFlow HELLO exchange and is kept alive with echo
+// requests; its state reports whether it is connected. A lost connection is
+// dialed again after the backoff of the agent. The controllers see the
+// switch with the datapath-id of the config and a port for every interface,
+// whose status changes are sent to them and whose statistics are the
+// counters of the interface.
+package ofagent
+
+import (
+	"crypto/tls"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var log = logging.GetLogger("ofagent")
+
+// Defaults of the agent settings unset in the config, in seconds.
+const (
+	DefaultBackoffInterval = 5
+	DefaultMaxBackoff      = 60
+	DefaultInactivityProbe = 10
+)
+
+// DefaultPort is the port of a connection whose port is unset.
+const DefaultPort = 6653
+
+const (
+	reconcileInterval = time.Second
+	dialTimeout       = 5 * time.Second
+)
+
+// Target is the simulated switch whose agent is simulated. It is implemented
+// by gnmi.Server.
+type Target interface {
+	InternalUpdate(fp func(config ygot.ValidatedGoStruct) error) error
+	NotifyUpdate(path *pb.Path)
+}
+
+// Key identifies a connection to a controller.
+type Key struct {
+	// Controller is the name of the controller.
+	Controller string
+	// AuxID is the auxiliary id of the connection, 0 for the main one.
+	AuxID uint8
+}
+
+func (k Key) String() string {
+	return k.Controller + "/" + strconv.Itoa(int(k.AuxID))
+}
+
+// settings are the settings of a connection in the config.
+type settings struct {
+	address         string
+	port            uint16
+	transport       gostruct.E_OpenconfigOpenflow_Transport
+	sourceInterface string
+	backoff         time.Duration // first wait before dialing again
+	maxBackoff      time.Duration
+	probe           time.Duration // inactivity probe, none when 0
+}
+
+// worker maintains a connection.
+type worker struct {
+	key       Key
+	settings  settings
+	cancel    context.CancelFunc
+	connected bool
+	conn      *conn // while connected
+}
+
+// Agent is the OpenFlow agent of a switch. It maintains a connection for
+// every connection of the controllers of the config, restarted when its
+// settings change.
+type Agent struct {
+	target   Target
+	redirect string
+	dial     func(ctx context.Context, network, address string) (net.Conn, error)
+	second   time.Duration // unit of the durations of the config
+
+	mu      sync.Mutex
+	workers map[Key]*worker
+	ports   map[string][]byte // descriptions of the ports by name
+}
+
+// NewAgent returns the OpenFlow agent of target.
+func NewAgent(target Target) *Agent {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &Agent{
+		target:  target,
+		dial:    dialer.DialContext,
+		second:  time.Second,
+		workers: make(map[Key]*worker),
+	}
+}
+
+// Redirect makes every connection dial address, e.g. of a local Controller,
+// over TCP instead of the address, port and transport of its controller. The
+// state of the connections still reports the settings of their controller.
+func (a *Agent) Redirect(address string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.redirect = address
+}
+
+// Connected returns whether connection key is connected.
+func (a *Agent) Connected(key Key) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w, ok := a.workers[key]
+	return ok && w.connected
+}
+
+// Run maintains the connections of the config until ctx is done, picking up
+// the changes of the config every second. It stops when the config tree is
+// not an openconfig device.
+func (a *Agent) Run(ctx context.Context) {
+	defer a.stop()
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+	for {
+		if err := a.reconcile(ctx); err != nil {
+			log.Warnf("Stopping the OpenFlow agent: %v", err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile starts the connections added to the config, restarts those whose
+// settings changed, stops those removed from it and reports the state of the
+// others, which a reload of the config may have lost. It sends the changes of
+// the ports since the previous reconciliation to the connected controllers.
+func (a *Agent) reconcile(ctx context.Context) error {
+	var configured map[Key]settings
+	var ports []port
+	err := a.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "config tree is not an openconfig device: %T", config)
+		}
+		configured = a.connections(device)
+		ports = readView(device).ports
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var started, kept []*worker
+	var conns []*conn
+	a.mu.Lock()
+	changes, descs := portChanges(a.ports, ports)
+	a.ports = descs
+	for key, w := range a.workers {
+		if st, ok := configured[key]; !ok || st != w.settings {
+			w.cancel()
+			delete(a.workers, key)
+		}
+	}
+	for key, st := range configured {
+		if w, ok := a.workers[key]; ok {
+			kept = append(kept, w)
+			if w.conn != nil {
+				conns = append(conns, w.conn)
+			}
+			continue
+		}
+		wctx, cancel := context.WithCancel(ctx)
+		w := &worker{key: key, settings: st, cancel: cancel}
+		a.workers[key] = w
+		started = append(started, w)
+		go a.run(wctx, w)
+	}
+	a.mu.Unlock()
+	for _, w := range append(started, kept...) {
+		a.report(w)
+	}
+	for _, c := range conns {
+		for _, change := range changes {
+			if err := c.request(typePortStatus, change); err != nil {
+				log.Debugf("Error in sending a port status: %v", err)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// stop stops all the connections.
+func (a *Agent) stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, w := range a.workers {
+		w.cancel()
+		delete(a.workers, key)
+	}
+}
+
+// connections returns the settings of the connections of the controllers of
+// device.
+func (a *Agent) connections(device *gostruct.Device) map[Key]settings {
+	configured := make(map[Key]settings)
+	if device.System == nil || device.System.Openflow == nil || device.System.Openflow.Controllers == nil {
+		return configured
+	}
+	backoff, maxBackoff, probe := uint32(DefaultBackoffInterval), uint32(DefaultMaxBackoff), uint32(DefaultInactivityProbe)
+	if agent := device.System.Openflow.Agent; agent != nil && agent.Config != nil {
+		if agent.Config.BackoffInterval != nil {
+			backoff = *agent.Config.BackoffInterval
+		}
+		if agent.Config.MaxBackoff != nil {
+			maxBackoff = *agent.Config.MaxBackoff
+		}
+		if agent.Config.InactivityProbe != nil {
+			probe = *agent.Config.InactivityProbe
+		}
+	}
+	for name, controller := range device.System.Openflow.Controllers.Controller {
+		if controller.Connections == nil {
+			continue
+		}
+		for auxID, connection := range controller.Connections.Connection {
+			cfg := connection.Config
+			if cfg == nil || cfg.Address == nil {
+				continue
+			}
+			st := settings{
+				address:    *cfg.Address,
+				port:       DefaultPort,
+				transport:  cfg.Transport,
+				backoff:    time.Duration(backoff) * a.second,
+				maxBackoff: time.Duration(maxBackoff) * a.second,
+				probe:      time.Duration(probe) * a.second,
+			}
+			if cfg.Port != nil {
+				st.port = *cfg.Port
+			}
+			if st.transport == gostruct.OpenconfigOpenflow_Transport_UNSET {
+				st.transport = gostruct.OpenconfigOpenflow_Transport_TCP
+			}
+			if cfg.SourceInterface != nil {
+				st.sourceInterface = *cfg.SourceInterface
+			}
+			configured[Key{Controller: name, AuxID: auxID}] = st
+		}
+	}
+	return configured
+}
+
+// run maintains the connection of w until ctx is done, dialing it again
+// after a backoff that doubles at every failed attempt, from the
+// backoff-interval of the agent up to its max-backoff.
+func (a *Agent) run(ctx context.Context, w *worker) {
+	var backoff time.Duration
+	for {
+		err := a.session(ctx, w)
+		if ctx.Err() != nil {
+			return
+		}
+		a.mu.Lock()
+		connected := w.connected
+		w.connected, w.conn = false, nil
+		a.mu.Unlock()
+		if connected {
+			log.Infof("Lost connection %s to %s: %v", w.key, w.settings.address, err)
+			backoff = 0
+			a.report(w)
+		} else {
+			log.Debugf("Error in connecting %s to %s: %v", w.key, w.settings.address, err)
+		}
+		backoff = nextBackoff(backoff, w.settings)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// nextBackoff returns the wait before dialing again a connection that
+// failed after waiting backoff, 0 for the first failure.
+func nextBackoff(backoff time.Duration, st settings) time.Duration {
+	if backoff == 0 {
+		backoff = st.backoff
+	} else {
+		backoff *= 2
+	}
+	if backoff > st.maxBackoff {
+		backoff = st.maxBackoff
+	}
+	return backoff
+}
+
+// session dials the connection of w and serves it until it fails or ctx is
+// done.
+func (a *Agent) session(ctx context.Context, w *worker) error {
+	nc, err := a.connect(ctx, w.settings)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			nc.Close()
+		case <-done:
+		}
+	}()
+
+	c := &conn{Conn: nc}
+	if err := nc.SetDeadline(time.Now().Add(dialTimeout)); err != nil {
+		return err
+	}
+	if err := c.handshake(); err != nil {
+		return err
+	}
+	if err := nc.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	w.connected, w.conn = true, c
+	a.mu.Unlock()
+	log.Infof("Connection %s to %s established", w.key, w.settings.address)
+	a.report(w)
+
+	probe := w.settings.probe
+	if probe > 0 {
+		go func() {
+			ticker := time.NewTicker(probe)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					if err := c.request(typeEchoRequest, nil); err != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+	for {
+		if probe > 0 {
+			// The echo replies of the controller keep the connection alive.
+			if err := nc.SetReadDeadline(time.Now().Add(2 * probe)); err != nil {
+				return err
+			}
+		}
+		h, body, err := c.receive()
+		if err != nil {
+			return err
+		}
+		if err := a.handle(c, w, h, body); err != nil {
+			return err
+		}
+	}
+}
+
+// connect dials a connection with settings st. A TLS connection does not
+// verify the certificate of the controller, as the simulator does not have
+// the CA of the certificate-id of the connection.
+func (a *Agent) connect(ctx context.Context, st settings) (net.Conn, error) {
+	a.mu.Lock()
+	redirect := a.redirect
+	a.mu.Unlock()
+	if redirect != "" {
+		return a.dial(ctx, "tcp", redirect)
+	}
+	address := net.JoinHostPort(st.address, strconv.Itoa(int(st.port)))
+	nc, err := a.dial(ctx, "tcp", address)
+	if err != nil || st.transport != gostruct.OpenconfigOpenflow_Transport_TLS {
+		return nc, err
+	}
+	tc := tls.Client(nc, &tls.Config{InsecureSkipVerify: true})
+	if err := tc.Handshake(); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return tc, nil
+}
+
+// report writes the state of the connection of w to the config tree, unless
+// w was stopped, and notifies the leaves that changed.
+func (a *Agent) report(w *worker) {
+	var changed []string
+	err := a.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if a.workers[w.key] != w {
+			return nil
+		}
+		connection := lookupConnection(config, w.key)
+		if connection == nil {
+			return nil
+		}
+		if connection.State == nil {
+			connection.State = &gostruct.OpenconfigSystem_System_Openflow_Controllers_Controller_Connections_Connection_State{}
+		}
+		changed = setState(connection.State, w)
+		return nil
+	})
+	if err != nil {
+		log.Warnf("Error in reporting the state of connection %s: %v", w.key, err)
+		return
+	}
+	if len(changed) == 0 {
+		return
+	}
+	for _, name := range changed {
+		a.target.NotifyUpdate(connectionPath(w.key, "state", name))
+	}
+}
+
+// setState sets the state of the connection of w and returns the names of
+// the leaves it changed.
+func setState(state *gostruct.OpenconfigSystem_System_Openflow_Controllers_Controller_Connections_Connection_State, w *worker) []string {
+	var changed []string
+	if state.AuxId == nil || *state.AuxId != w.key.AuxID {
+		state.AuxId = ygot.Uint8(w.key.AuxID)
+		changed = append(changed, "aux-id")
+	}
+	if state.Connected == nil || *state.Connected != w.connected {
+		state.Connected = ygot.Bool(w.connected)
+		changed = append(changed, "connected")
+	}
+	if state.Address == nil || *state.Address != w.settings.address {
+		state.Address = ygot.String(w.settings.address)
+		changed = append(changed, "address")
+	}
+	if state.Port == nil || *state.Port != w.settings.port {
+		state.Port = ygot.Uint16(w.settings.port)
+		changed = append(changed, "port")
+	}
+	if state.Transport != w.settings.transport {
+		state.Transport = w.settings.transport
+		changed = append(changed, "transport")
+	}
+	if w.settings.sourceInterface == "" {
+		if state.SourceInterface != nil {
+			state.SourceInterface = nil
+			changed = append(changed, "source-interface")
+		}
+	} else if state.SourceInterface == nil || *state.SourceInterface != w.settings.sourceInterface {
+		state.SourceInterface = ygot.String(w.settings.sourceInterface)
+		changed = append(changed, "source-interface")
+	}
+	return changed
+}
+
+// lookupConnection returns connection key of config, or nil.
+func lookupConnection(config ygot.ValidatedGoStruct, key Key) *gostruct.OpenconfigSystem_System_Openflow_Controllers_Controller_Connections_Connection {
+	device, ok := config.(*gostruct.Device)
+	if !ok || device.System == nil || device.System.Openflow == nil || device.System.Openflow.Controllers == nil {
+		return nil
+	}
+	controller := device.System.Openflow.Controllers.Controller[key.Controller]
+	if controller == nil || controller.Connections == nil {
+		return nil
+	}
+	return controller.Connections.Connection[key.AuxID]
+}
+
+// connectionPath returns the path of the node at names of connection key.
+func connectionPath(key Key, names ...string) *pb.Path {
+	path := &pb.Path{Elem: []*pb.PathElem{
+		{Name: "system"},
+		{Name: "openflow"},
+		{Name: "controllers"},
+		{Name: "controller", Key: map[string]string{"name": key.Controller}},
+		{Name: "connections"},
+		{Name: "connection", Key: map[string]string{"aux-id": strconv.Itoa(int(key.AuxID))}},
+	}}
+	for _, n := range names {
+		path.Elem = append(path.Elem, &pb.PathElem{Name: n})
+	}
+	return path
+}
@@ -0,0 +1,325 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ofagent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+	"golang.org/x/net/context"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+)
+
+var mainConnection = Key{Controller: "main"}
+
+// newServer returns a server whose config has controller main at address,
+// with the agent settings given in seconds.
+func newServer(t *testing.T, address string, port int, backoff, maxBackoff, probe int) *gnmi.Server {
+	return newConfigServer(t, fmt.Sprintf(`{"openconfig-system:system": {"openconfig-openflow:openflow": {
+		"agent": {"config": {"backoff-interval": %d, "max-backoff": %d, "inactivity-probe": %d}},
+		"controllers": {"controller": [{"name": "main", "config": {"name": "main"},
+			"connections": {"connection": [{"aux-id": 0, "config": {"aux-id": 0, "address": %q, "port": %d, "transport": "TCP"}}]}}]}}}}`,
+		backoff, maxBackoff, probe, address, port))
+}
+
+// newConfigServer returns a server with config.
+func newConfigServer(t *testing.T, config string) *gnmi.Server {
+	model := gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum)
+	s, err := gnmi.NewServer(model, []byte(config), nil)
+	if err != nil {
+		t.Fatalf("error in creating server: %v", err)
+	}
+	return s
+}
+
+// connectionState returns the state of connection key of s.
+func connectionState(t *testing.T, s *gnmi.Server, key Key) gostruct.OpenconfigSystem_System_Openflow_Controllers_Controller_Connections_Connection_State {
+	var state gostruct.OpenconfigSystem_System_Openflow_Controllers_Controller_Connections_Connection_State
+	err := s.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		if connection := lookupConnection(config, key); connection != nil && connection.State != nil {
+			state = *connection.State
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error in reading the config: %v", err)
+	}
+	return state
+}
+
+// waitConnected waits until connection key of agent is connected or not.
+func waitConnected(t *testing.T, agent *Agent, key Key, connected bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); agent.Connected(key) != connected; {
+		if time.Now().After(deadline) {
+			t.Fatalf("connection %s is not connected %v", key, connected)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func listen(t *testing.T, address string) (*Controller, string) {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	controller := NewController()
+	go controller.Serve(lis)
+	return controller, lis.Addr().String()
+}
+
+func TestAgent(t *testing.T) {
+	controller, address := listen(t, "127.0.0.1:0")
+	host, port, _ := net.SplitHostPort(address)
+	portNumber, _ := strconv.Atoi(port)
+
+	s := newServer(t, host, portNumber, 1, 2, 1)
+	agent := NewAgent(s)
+	agent.second = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agent.Run(ctx)
+
+	waitConnected(t, agent, mainConnection, true)
+	state := connectionState(t, s, mainConnection)
+	if state.Connected == nil || !*state.Connected {
+		t.Errorf("state of the connection is not connected")
+	}
+	if state.Address == nil || *state.Address != host || state.Port == nil || int(*state.Port) != portNumber {
+		t.Errorf("state of the connection is at %v:%v, want %s", state.Address, state.Port, address)
+	}
+	if state.Transport != gostruct.OpenconfigOpenflow_Transport_TCP {
+		t.Errorf("transport of the connection is %v, want TCP", state.Transport)
+	}
+
+	// The echo requests keep the connection alive past the inactivity probe.
+	time.Sleep(5 * agent.second)
+	if !agent.Connected(mainConnection) {
+		t.Fatalf("connection lost while the controller is up")
+	}
+
+	controller.Close()
+	waitConnected(t, agent, mainConnection, false)
+	if state := connectionState(t, s, mainConnection); state.Connected == nil || *state.Connected {
+		t.Errorf("state of the connection is connected after the controller went down")
+	}
+
+	// The connection is dialed again once the controller is back.
+	controller, _ = listen(t, address)
+	defer controller.Close()
+	waitConnected(t, agent, mainConnection, true)
+}
+
+func TestBackoff(t *testing.T) {
+	s := newServer(t, "192.0.2.10", 6653, 2, 4, 10)
+	agent := NewAgent(s)
+	agent.second = 10 * time.Millisecond
+	var mu sync.Mutex
+	var attempts []time.Time
+	agent.dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address != "192.0.2.10:6653" {
+			t.Errorf("dialed %s, want the address of the controller", address)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, time.Now())
+		return nil, errors.New("unreachable")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go agent.Run(ctx)
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) < 4 {
+		t.Fatalf("%d attempts to connect, want at least 4", len(attempts))
+	}
+	for i, want := range []time.Duration{20, 40, 40} {
+		if wait := attempts[i+1].Sub(attempts[i]); wait < want*time.Millisecond {
+			t.Errorf("attempt %d after %v, want a backoff of %vms", i+2, wait, want)
+		}
+	}
+	if state := connectionState(t, s, mainConnection); state.Connected == nil || *state.Connected {
+		t.Errorf("state of an unreachable connection is not disconnected")
+	}
+}
+
+// expect receives messages from c until one of type typ, and returns its
+// body.
+func expect(t *testing.T, c *conn, typ msgType) []byte {
+	t.Helper()
+	if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		h, body, err := c.receive()
+		if err != nil {
+			t.Fatalf("error in receiving a message of type %d: %v", typ, err)
+		}
+		if h.typ == typ {
+			return body
+		}
+	}
+}
+
+// multipartRequest returns the body of a multipart request of type mpType.
+func multipartRequest(mpType uint16, body ...byte) []byte {
+	b := make([]byte, multipartHeaderLen)
+	binary.BigEndian.PutUint16(b, mpType)
+	return append(b, body...)
+}
+
+func TestSwitch(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error in listening: %v", err)
+	}
+	defer lis.Close()
+	host, port, _ := net.SplitHostPort(lis.Addr().String())
+	s := newConfigServer(t, fmt.Sprintf(`{
+	"openconfig-interfaces:interfaces": {"interface": [
+		{"name": "admin", "config": {"name": "admin"}},
+		{"name": "eth1", "config": {"name": "eth1"}, "state": {"oper-status": "UP", "last-change": "%d",
+			"counters": {"in-unicast-pkts": "10", "in-multicast-pkts": "2", "in-octets": "1000", "in-fcs-errors": "1",
+				"out-pkts": "7", "out-octets": "700"}}},
+		{"name": "eth2", "config": {"name": "eth2", "enabled": false}, "state": {"ifindex": 5}}]},
+	"openconfig-system:system": {"config": {"hostname": "switch-1"}, "openconfig-openflow:openflow": {
+		"agent": {"config": {"datapath-id": "00:00:00:00:00:00:00:2a", "inactivity-probe": 0}},
+		"controllers": {"controller": [{"name": "main", "config": {"name": "main"},
+			"connections": {"connection": [{"aux-id": 0, "config": {"aux-id": 0, "address": %q, "port": %s, "source-interface": "admin"}}]}}]}}}}`,
+		time.Now().Add(-time.Minute).UnixNano(), host, port))
+	agent := NewAgent(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go agent.Run(ctx)
+
+	nc, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("error in accepting the switch: %v", err)
+	}
+	defer nc.Close()
+	c := &conn{Conn: nc}
+	if err := c.handshake(); err != nil {
+		t.Fatalf("error in the handshake: %v", err)
+	}
+
+	if err := c.request(typeFeaturesRequest, nil); err != nil {
+		t.Fatal(err)
+	}
+	features := expect(t, c, typeFeaturesReply)
+	if id := binary.BigEndian.Uint64(features); id != 42 || features[13] != 0 {
+		t.Errorf("features of datapath %d and auxiliary id %d, want 42 and 0", id, features[13])
+	}
+
+	if err := c.request(typeMultipartRequest, multipartRequest(multipartTypeDesc)); err != nil {
+		t.Fatal(err)
+	}
+	desc := expect(t, c, typeMultipartReply)[multipartHeaderLen:]
+	if dp := string(bytes.TrimRight(desc[3*descLen+serialNumLen:], "\x00")); dp != "switch-1" {
+		t.Errorf("datapath description is %q, want the hostname", dp)
+	}
+
+	// The source interface of the connection is not a port.
+	if err := c.request(typeMultipartRequest, multipartRequest(multipartTypePortDesc)); err != nil {
+		t.Fatal(err)
+	}
+	ports := expect(t, c, typeMultipartReply)[multipartHeaderLen:]
+	if len(ports) != 2*portDescLen {
+		t.Fatalf("%d bytes of port descriptions, want 2 ports", len(ports))
+	}
+	for i, want := range []struct {
+		number        uint32
+		name          string
+		config, state uint32
+	}{
+		{1, "eth1", 0, portStateLive},
+		{5, "eth2", portConfigDown, portStateLinkDown},
+	} {
+		p := ports[i*portDescLen:]
+		if number, name := binary.BigEndian.Uint32(p), portName(p); number != want.number || name != want.name {
+			t.Errorf("port %d is %d %s, want %d %s", i, number, name, want.number, want.name)
+		}
+		if config, state := binary.BigEndian.Uint32(p[32:]), binary.BigEndian.Uint32(p[36:]); config != want.config || state != want.state {
+			t.Errorf("port %s has config %d and state %d, want %d and %d", want.name, config, state, want.config, want.state)
+		}
+	}
+
+	if err := c.request(typeMultipartRequest, multipartRequest(multipartTypePortStats, 0, 0, 0, 1, 0, 0, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	stats := expect(t, c, typeMultipartReply)[multipartHeaderLen:]
+	if len(stats) != portStatsLen {
+		t.Fatalf("%d bytes of port statistics, want port 1", len(stats))
+	}
+	for i, want := range map[int]uint64{0: 12, 1: 7, 2: 1000, 3: 700, 10: 1} {
+		if got := binary.BigEndian.Uint64(stats[8+8*i:]); got != want {
+			t.Errorf("port statistic %d is %d, want %d", i, got, want)
+		}
+	}
+	if alive := binary.BigEndian.Uint32(stats[104:]); alive < 60 {
+		t.Errorf("port alive for %ds, want a minute", alive)
+	}
+
+	if err := c.request(typeMultipartRequest, multipartRequest(multipartTypePortStats, 0, 0, 0, 9, 0, 0, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if code := binary.BigEndian.Uint16(expect(t, c, typeError)[2:]); code != errorBadRequestPort {
+		t.Errorf("error code of the statistics of an unknown port is %d, want %d", code, errorBadRequestPort)
+	}
+	if err := c.request(msgType(16), nil); err != nil {
+		t.Fatal(err)
+	}
+	if code := binary.BigEndian.Uint16(expect(t, c, typeError)[2:]); code != errorBadRequestType {
+		t.Errorf("error code of an unsupported message is %d, want %d", code, errorBadRequestType)
+	}
+	if err := c.request(typeEchoRequest, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	if echo := expect(t, c, typeEchoReply); string(echo) != "ping" {
+		t.Errorf("echo reply %q, want the data of the request", echo)
+	}
+
+	// The link of eth1 goes down.
+	err = s.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		intf := config.(*gostruct.Device).Interfaces.Interface["eth1"]
+		intf.State.OperStatus = gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_DOWN
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change := expect(t, c, typePortStatus)
+	if reason, name, state := change[0], portName(change[8:]), binary.BigEndian.Uint32(change[8+36:]); reason != portStatusReasonModify || name != "eth1" || state != portStateLinkDown {
+		t.Errorf("port status of %s with reason %d and state %d, want eth1 modified with its link down", name, reason, state)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	st := settings{backoff: 5 * time.Second, maxBackoff: 12 * time.Second}
+	var backoff time.Duration
+	for _, want := range []time.Duration{5, 10, 12, 12} {
+		backoff = nextBackoff(backoff, st)
+		if backoff != want*time.Second {
+			t.Errorf("backoff is %v, want %vs", backoff, want)
+		}
+	}
+}
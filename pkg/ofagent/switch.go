@@ -0,0 +1,373 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ofagent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	gnoios "github.com/onosproject/gnxi-simulators/pkg/gnoi/os"
+)
+
+// Descriptions of the switch when its inventory does not describe it.
+const (
+	defaultMfrDesc = "Open Networking Foundation"
+	defaultHwDesc  = "gNXI simulator"
+	defaultSwDesc  = "gnmi_target"
+)
+
+// chassisComponent is the component of the inventory describing the switch.
+const chassisComponent = "chassis"
+
+// view is the switch as seen by its controllers, read from the config tree.
+type view struct {
+	datapathID                      uint64
+	mfrDesc, hwDesc, swDesc, serial string
+	dpDesc                          string
+	ports                           []port // sorted by number
+}
+
+// port is an OpenFlow port, built from an interface.
+type port struct {
+	number     uint32
+	name       string
+	hwAddr     [6]byte
+	config     uint32
+	state      uint32
+	lastChange uint64 // nanoseconds since the epoch the link last changed
+	counters   gostruct.OpenconfigInterfaces_Interfaces_Interface_State_Counters
+}
+
+// readView reads the switch from device. The ports are the interfaces of
+// the device, except the source interfaces of the controller connections,
+// numbered with their ifindex or else their position in the interfaces
+// sorted by name.
+func readView(device *gostruct.Device) view {
+	v := view{mfrDesc: defaultMfrDesc, hwDesc: defaultHwDesc, swDesc: defaultSwDesc}
+	management := make(map[string]bool)
+	if device.System != nil {
+		if device.System.Config != nil && device.System.Config.Hostname != nil {
+			v.dpDesc = *device.System.Config.Hostname
+		}
+		if of := device.System.Openflow; of != nil {
+			if of.Agent != nil && of.Agent.Config != nil && of.Agent.Config.DatapathId != nil {
+				// An invalid datapath-id is 0.
+				v.datapathID, _ = parseDatapathID(*of.Agent.Config.DatapathId)
+			}
+			if of.Controllers != nil {
+				for _, controller := range of.Controllers.Controller {
+					if controller.Connections == nil {
+						continue
+					}
+					for _, connection := range controller.Connections.Connection {
+						if connection.Config != nil && connection.Config.SourceInterface != nil {
+							management[*connection.Config.SourceInterface] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	if device.Components != nil {
+		if chassis := device.Components.Component[chassisComponent]; chassis != nil && chassis.State != nil {
+			setString(&v.mfrDesc, chassis.State.MfgName)
+			setString(&v.hwDesc, chassis.State.PartNo)
+			setString(&v.serial, chassis.State.SerialNo)
+		}
+		if os := device.Components.Component[gnoios.ComponentName]; os != nil && os.State != nil {
+			setString(&v.swDesc, os.State.SoftwareVersion)
+		}
+	}
+	if device.Interfaces == nil {
+		return v
+	}
+
+	var names []string
+	for name := range device.Interfaces.Interface {
+		if !management[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		intf := device.Interfaces.Interface[name]
+		p := port{number: uint32(i + 1), name: name}
+		var enabled *bool
+		if intf.Config != nil {
+			enabled = intf.Config.Enabled
+		}
+		adminStatus := gostruct.OpenconfigInterfaces_Interfaces_Interface_State_AdminStatus_UNSET
+		operStatus := gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_UNSET
+		if st := intf.State; st != nil {
+			if st.Ifindex != nil {
+				p.number = *st.Ifindex
+			}
+			adminStatus, operStatus = st.AdminStatus, st.OperStatus
+			if st.LastChange != nil {
+				p.lastChange = *st.LastChange
+			}
+			if st.Counters != nil {
+				p.counters = *st.Counters
+			}
+		}
+		if adminStatus == gostruct.OpenconfigInterfaces_Interfaces_Interface_State_AdminStatus_DOWN ||
+			adminStatus == gostruct.OpenconfigInterfaces_Interfaces_Interface_State_AdminStatus_UNSET && enabled != nil && !*enabled {
+			p.config |= portConfigDown
+		}
+		if operStatus == gostruct.OpenconfigInterfaces_Interfaces_Interface_State_OperStatus_UP {
+			p.state |= portStateLive
+		} else {
+			p.state |= portStateLinkDown
+		}
+		// The hardware addresses of the ports follow the address of the
+		// switch in the low 48 bits of its datapath id.
+		var addr [8]byte
+		binary.BigEndian.PutUint64(addr[:], (v.datapathID&0xffffffffffff)+uint64(p.number))
+		copy(p.hwAddr[:], addr[2:])
+		v.ports = append(v.ports, p)
+	}
+	sort.Slice(v.ports, func(i, j int) bool { return v.ports[i].number < v.ports[j].number })
+	return v
+}
+
+// parseDatapathID parses a datapath-id of the form 00:16:3e:00:00:00:00:00.
+func parseDatapathID(s string) (uint64, error) {
+	digits := strings.ReplaceAll(s, ":", "")
+	if len(digits) != 16 {
+		return 0, fmt.Errorf("%q is not 8 octets", s)
+	}
+	return strconv.ParseUint(digits, 16, 64)
+}
+
+func setString(dst *string, src *string) {
+	if src != nil && *src != "" {
+		*dst = *src
+	}
+}
+
+// view reads the switch from the config tree.
+func (a *Agent) view() (view, error) {
+	var v view
+	err := a.target.InternalUpdate(func(config ygot.ValidatedGoStruct) error {
+		device, ok := config.(*gostruct.Device)
+		if !ok {
+			return status.Errorf(codes.FailedPrecondition, "config tree is not an openconfig device: %T", config)
+		}
+		v = readView(device)
+		return nil
+	})
+	return v, err
+}
+
+// handle handles a message received from the controller on connection c of
+// w. The flow, group and meter modifications and the packets out are
+// accepted and ignored, as the switch has no flow table.
+func (a *Agent) handle(c *conn, w *worker, h header, body []byte) error {
+	switch h.typ {
+	case typeHello, typeError, typeEchoReply:
+		return nil
+	case typeEchoRequest:
+		return c.send(typeEchoReply, h.xid, body)
+	case typeFeaturesRequest:
+		v, err := a.view()
+		if err != nil {
+			return err
+		}
+		return c.send(typeFeaturesReply, h.xid, featuresReply(v, w.key.AuxID))
+	case typeGetConfigRequest:
+		reply := make([]byte, 4)
+		binary.BigEndian.PutUint16(reply[2:], defaultMissSendLen)
+		return c.send(typeGetConfigReply, h.xid, reply)
+	case typeSetConfig, typePacketOut, typeFlowMod, typeGroupMod, typeMeterMod:
+		return nil
+	case typeBarrierRequest:
+		return c.send(typeBarrierReply, h.xid, nil)
+	case typeRoleRequest:
+		// The switch accepts any role.
+		if len(body) < 16 {
+			return c.sendError(h, body, errorBadRequest, errorBadRequestLength)
+		}
+		return c.send(typeRoleReply, h.xid, body[:16])
+	case typeMultipartRequest:
+		return a.multipart(c, h, body)
+	}
+	return c.sendError(h, body, errorBadRequest, errorBadRequestType)
+}
+
+// multipart answers a multipart request for the description of the switch,
+// its flows, which it has none, and the descriptions and statistics of its
+// ports.
+func (a *Agent) multipart(c *conn, h header, body []byte) error {
+	if len(body) < multipartHeaderLen {
+		return c.sendError(h, body, errorBadRequest, errorBadRequestLength)
+	}
+	mpType := binary.BigEndian.Uint16(body)
+	if mpType == multipartTypeFlow {
+		return c.sendMultipart(h.xid, mpType, nil)
+	}
+	if mpType != multipartTypeDesc && mpType != multipartTypePortDesc && mpType != multipartTypePortStats {
+		return c.sendError(h, body, errorBadRequest, errorBadRequestMultipart)
+	}
+	v, err := a.view()
+	if err != nil {
+		return err
+	}
+	var entries [][]byte
+	switch mpType {
+	case multipartTypeDesc:
+		entries = append(entries, desc(v))
+	case multipartTypePortDesc:
+		for _, p := range v.ports {
+			entries = append(entries, portDesc(p))
+		}
+	case multipartTypePortStats:
+		if len(body) < multipartHeaderLen+8 {
+			return c.sendError(h, body, errorBadRequest, errorBadRequestLength)
+		}
+		number := binary.BigEndian.Uint32(body[multipartHeaderLen:])
+		now := time.Now()
+		for _, p := range v.ports {
+			if number == portAny || number == p.number {
+				entries = append(entries, portStats(p, now))
+			}
+		}
+		if len(entries) == 0 {
+			return c.sendError(h, body, errorBadRequest, errorBadRequestPort)
+		}
+	}
+	return c.sendMultipart(h.xid, mpType, entries)
+}
+
+// featuresReply returns the body of the features reply of v on auxiliary
+// connection auxID.
+func featuresReply(v view, auxID uint8) []byte {
+	b := make([]byte, 24)
+	binary.BigEndian.PutUint64(b, v.datapathID)
+	b[12] = 1 // tables
+	b[13] = auxID
+	binary.BigEndian.PutUint32(b[16:], capabilityFlowStats|capabilityTableStats|capabilityPortStats)
+	return b
+}
+
+// desc returns the description of v.
+func desc(v view) []byte {
+	b := make([]byte, 4*descLen+serialNumLen)
+	putString(b[:descLen], v.mfrDesc)
+	putString(b[descLen:2*descLen], v.hwDesc)
+	putString(b[2*descLen:3*descLen], v.swDesc)
+	putString(b[3*descLen:3*descLen+serialNumLen], v.serial)
+	putString(b[3*descLen+serialNumLen:], v.dpDesc)
+	return b
+}
+
+// portDesc returns the description of p.
+func portDesc(p port) []byte {
+	b := make([]byte, portDescLen)
+	binary.BigEndian.PutUint32(b, p.number)
+	copy(b[8:14], p.hwAddr[:])
+	putString(b[16:16+portNameLen], p.name)
+	binary.BigEndian.PutUint32(b[32:], p.config)
+	binary.BigEndian.PutUint32(b[36:], p.state)
+	return b
+}
+
+// portStats returns the statistics of p at now, from the counters of its
+// interface.
+func portStats(p port, now time.Time) []byte {
+	b := make([]byte, portStatsLen)
+	c := p.counters
+	binary.BigEndian.PutUint32(b, p.number)
+	for i, counter := range []uint64{
+		packets(c.InPkts, c.InUnicastPkts, c.InMulticastPkts, c.InBroadcastPkts),
+		packets(c.OutPkts, c.OutUnicastPkts, c.OutMulticastPkts, c.OutBroadcastPkts),
+		value(c.InOctets),
+		value(c.OutOctets),
+		value(c.InDiscards),
+		value(c.OutDiscards),
+		value(c.InErrors),
+		value(c.OutErrors),
+		0, // frame errors
+		0, // overruns
+		value(c.InFcsErrors),
+		0, // collisions
+	} {
+		binary.BigEndian.PutUint64(b[8+8*i:], counter)
+	}
+	if p.state&portStateLive != 0 && p.lastChange != 0 {
+		if alive := now.Sub(time.Unix(0, int64(p.lastChange))); alive > 0 {
+			binary.BigEndian.PutUint32(b[104:], uint32(alive/time.Second))
+			binary.BigEndian.PutUint32(b[108:], uint32(alive%time.Second))
+		}
+	}
+	return b
+}
+
+// packets returns the number of packets of a counter, or else the sum of
+// the unicast, multicast and broadcast packets.
+func packets(total *uint64, casts ...*uint64) uint64 {
+	if total != nil {
+		return *total
+	}
+	var sum uint64
+	for _, c := range casts {
+		sum += value(c)
+	}
+	return sum
+}
+
+func value(counter *uint64) uint64 {
+	if counter == nil {
+		return 0
+	}
+	return *counter
+}
+
+// portStatus returns the body of the port status of p for reason.
+func portStatus(reason uint8, p []byte) []byte {
+	b := make([]byte, 8, 8+portDescLen)
+	b[0] = reason
+	return append(b, p...)
+}
+
+// portChanges returns the bodies of the port status messages of the changes
+// from the port descriptions old to ports, and the descriptions of ports by
+// port name.
+func portChanges(old map[string][]byte, ports []port) ([][]byte, map[string][]byte) {
+	descs := make(map[string][]byte, len(ports))
+	var changes [][]byte
+	for _, p := range ports {
+		d := portDesc(p)
+		descs[p.name] = d
+		if old == nil {
+			continue
+		}
+		if prev, ok := old[p.name]; !ok {
+			changes = append(changes, portStatus(portStatusReasonAdd, d))
+		} else if !bytes.Equal(prev, d) {
+			changes = append(changes, portStatus(portStatusReasonModify, d))
+		}
+	}
+	var deleted []string
+	for name := range old {
+		if _, ok := descs[name]; !ok {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Strings(deleted)
+	for _, name := range deleted {
+		changes = append(changes, portStatus(portStatusReasonDelete, old[name]))
+	}
+	return changes, descs
+}
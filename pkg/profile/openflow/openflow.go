@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package openflow is the profile of an OpenFlow switch modelled with the
+// openconfig interfaces, openflow, platform and system models. Importing the
+// package registers the profile.
+package openflow
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	targetconfigs "github.com/onosproject/gnxi-simulators/configs/target_configs"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata"
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi/modeldata/gostruct"
+	"github.com/onosproject/gnxi-simulators/pkg/inventory"
+	"github.com/onosproject/gnxi-simulators/pkg/profile"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Name is the name of the profile.
+const Name = "openflow-switch"
+
+func init() {
+	profile.Register(Profile{})
+}
+
+// Profile is the OpenFlow switch profile.
+type Profile struct{}
+
+// Name returns the name of the profile.
+func (Profile) Name() string {
+	return Name
+}
+
+// Description describes the OpenFlow switch.
+func (Profile) Description() string {
+	return "OpenFlow switch with the openconfig interfaces, openflow, platform and system models"
+}
+
+// Model returns the model of the GoStructs generated in modeldata/gostruct.
+func (Profile) Model() (*gnmi.Model, error) {
+	return gnmi.NewModel(modeldata.ModelData,
+		reflect.TypeOf((*gostruct.Device)(nil)),
+		gostruct.SchemaTree["Device"],
+		gostruct.Unmarshal,
+		gostruct.ΛEnum), nil
+}
+
+// StartupConfig returns configs/target_configs/typical_ofsw_config.json.
+func (Profile) StartupConfig() []byte {
+	return targetconfigs.TypicalOfswConfig
+}
+
+// StateGenerators returns the generator of the system clock.
+func (Profile) StateGenerators() []profile.StateGenerator {
+	return []profile.StateGenerator{dateTime}
+}
+
+// Derivations returns the derivations of the admin and operational status of
+// the interfaces and subinterfaces: the admin status follows the enabled
+// config, the operational status follows the admin status after a delay, and
+// the last change is the time the operational status last changed.
+func (Profile) Derivations() []gnmi.Derivation {
+	var derivations []gnmi.Derivation
+	for _, p := range []string{"/interfaces/interface", "/interfaces/interface/subinterfaces/subinterface"} {
+		derivations = append(derivations, gnmi.Derivation{
+			From:   p + "/config/enabled",
+			To:     p + "/state/admin-status",
+			Derive: adminStatus,
+		}, gnmi.Derivation{
+			From:    p + "/state/admin-status",
+			To:      p + "/state/oper-status",
+			Delayed: true,
+		}, gnmi.Derivation{
+			From:   p + "/state/oper-status",
+			To:     p + "/state/last-change",
+			Derive: lastChange,
+		})
+	}
+	return derivations
+}
+
+// Inventory returns the hardware of a fixed configuration switch with 8 ports
+// fitted with transceivers, 2 power supplies, 4 fans and 2 CPUs.
+func (Profile) Inventory() *inventory.Spec {
+	return &inventory.Spec{
+		MfgName:         "Open Networking Foundation",
+		PartNo:          "OFSW-8",
+		HardwareVersion: "1.0",
+		Ports:           8,
+		Transceivers:    true,
+		PowerSupplies:   2,
+		Fans:            4,
+		CPUs:            2,
+		Memory:          8 << 30,
+	}
+}
+
+// AlarmRules returns the rules raising a major alarm about the interfaces
+// administratively up but operationally down, and about the components whose
+// temperature is above their alarm threshold.
+func (Profile) AlarmRules() []gnmi.AlarmRule {
+	return []gnmi.AlarmRule{{
+		Name:     "interface-down",
+		Path:     "/interfaces/interface/state",
+		Raised:   interfaceDown,
+		Severity: "MAJOR",
+		TypeID:   "LOS",
+		Text: func(resource string, state map[string]interface{}) string {
+			return fmt.Sprintf("interface %s is operationally down", resource)
+		},
+	}, {
+		Name:     "temperature",
+		Path:     "/components/component/state/temperature",
+		Raised:   overheated,
+		Severity: "MAJOR",
+		TypeID:   "EQPT",
+		Text: func(resource string, temperature map[string]interface{}) string {
+			return fmt.Sprintf("temperature of %s is %v, above the alarm threshold of %v", resource, temperature["instant"], temperature["alarm-threshold"])
+		},
+	}}
+}
+
+// interfaceDown returns whether an interface is administratively up but
+// operationally down from its state container.
+func interfaceDown(state map[string]interface{}) bool {
+	return state["admin-status"] == "UP" && state["oper-status"] == "DOWN"
+}
+
+// overheated returns whether the instant temperature of a component is above
+// its alarm threshold.
+func overheated(temperature map[string]interface{}) bool {
+	instant, ok := number(temperature["instant"])
+	if !ok {
+		return false
+	}
+	threshold, ok := number(temperature["alarm-threshold"])
+	return ok && instant > threshold
+}
+
+// number returns the value of a numeric leaf of the RFC 7951 JSON tree, where
+// 64-bit integers and decimals are strings.
+func number(v interface{}) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(fmt.Sprintf("%v", v), 64)
+	return f, err == nil
+}
+
+// adminStatus returns the admin status of an interface from its enabled config.
+func adminStatus(path *pb.Path, enabled interface{}) interface{} {
+	if enabled == true {
+		return "UP"
+	}
+	return "DOWN"
+}
+
+// lastChange returns the current time as a timeticks64, the nanoseconds since
+// the Unix epoch, which RFC 7951 encodes as a string.
+func lastChange(path *pb.Path, operStatus interface{}) interface{} {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// dateTime updates system/state/current-datetime every second.
+func dateTime(ctx context.Context, target *gnmi.Server) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		_ = target.SetDateTime()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package openflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"golang.org/x/net/context"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/inventory"
+	"github.com/onosproject/gnxi-simulators/pkg/profile"
+)
+
+func TestProfile(t *testing.T) {
+	p, err := profile.Lookup(Name)
+	if err != nil {
+		t.Fatalf("the profile is not registered: %v", err)
+	}
+	model, err := p.Model()
+	if err != nil {
+		t.Fatalf("error in creating the model: %v", err)
+	}
+	s, err := gnmi.NewServer(model, p.StartupConfig(), nil)
+	if err != nil {
+		t.Fatalf("the startup config does not fit the model: %v", err)
+	}
+
+	if err := s.EnableMirroring(p.Derivations(), time.Millisecond); err != nil {
+		t.Fatalf("error in mirroring the startup config: %v", err)
+	}
+	if err := s.SetAlarmRules(p.AlarmRules()); err != nil {
+		t.Fatalf("error in setting the alarm rules: %v", err)
+	}
+	if err := inventory.NewSimulator(s, *p.Inventory()).Update(); err != nil {
+		t.Fatalf("error in building the inventory on the startup config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, generate := range p.StateGenerators() {
+		go generate(ctx, s)
+	}
+	var path pb.Path
+	if err := proto.UnmarshalText(`elem: <name: "system" > elem: <name: "state" > elem: <name: "current-datetime" > `, &path); err != nil {
+		t.Fatalf("error in unmarshaling path: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := s.Get(context.Background(), &pb.GetRequest{Path: []*pb.Path{&path}})
+		if err == nil && resp.GetNotification()[0].GetUpdate()[0].GetVal().GetStringVal() != "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the current date and time are not generated: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := proto.UnmarshalText(`elem: <name: "interfaces" > elem: <name: "interface" key: <key: "name" value: "admin" > > elem: <name: "state" > elem: <name: "oper-status" > `, &path); err != nil {
+		t.Fatalf("error in unmarshaling path: %v", err)
+	}
+	for {
+		resp, err := s.Get(context.Background(), &pb.GetRequest{Path: []*pb.Path{&path}})
+		if err == nil && resp.GetNotification()[0].GetUpdate()[0].GetVal().GetStringVal() == "UP" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the admin interface is not operationally up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestOverheated(t *testing.T) {
+	for _, tc := range []struct {
+		temperature map[string]interface{}
+		want        bool
+	}{
+		{map[string]interface{}{"instant": "85.5", "alarm-threshold": uint32(80)}, true},
+		{map[string]interface{}{"instant": "45", "alarm-threshold": uint32(80)}, false},
+		{map[string]interface{}{"instant": "85.5"}, false},
+	} {
+		if got := overheated(tc.temperature); got != tc.want {
+			t.Errorf("overheated(%v) = %v, want %v", tc.temperature, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package profile is the registry of the device profiles gnmi_target simulates.
+// A profile bundles everything that makes a type of device: its YANG model,
+// whose model data are the capabilities of the device, its default startup
+// config, the generators of its state and its hardware inventory. Profiles
+// are implemented in their own packages, which register them when they are
+// imported.
+package profile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/inventory"
+	"golang.org/x/net/context"
+)
+
+// Profile is a type of simulated device.
+type Profile interface {
+	// Name is the name the profile is selected with.
+	Name() string
+	// Description describes the type of device in a line.
+	Description() string
+	// Model returns the YANG model of the device.
+	Model() (*gnmi.Model, error)
+	// StartupConfig returns the IETF JSON config the device starts with when
+	// none is given, or nil to start empty.
+	StartupConfig() []byte
+	// StateGenerators returns the generators of the state of the device.
+	StateGenerators() []StateGenerator
+	// Derivations returns the derivations of state leaves from other leaves
+	// applied when the config is mirrored to the state.
+	Derivations() []gnmi.Derivation
+	// AlarmRules returns the rules raising the alarms of the device.
+	AlarmRules() []gnmi.AlarmRule
+	// Inventory returns the hardware of the device, or nil when the device
+	// has no simulated inventory.
+	Inventory() *inventory.Spec
+}
+
+// StateGenerator updates the state of a device until ctx is done.
+type StateGenerator func(ctx context.Context, target *gnmi.Server)
+
+var (
+	mu       sync.RWMutex
+	profiles = make(map[string]Profile)
+)
+
+// Register makes a profile available by its name. It panics if a profile of
+// the same name is already registered.
+func Register(p Profile) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := profiles[p.Name()]; ok {
+		panic(fmt.Sprintf("device profile %s is registered twice", p.Name()))
+	}
+	profiles[p.Name()] = p
+}
+
+// Lookup returns the profile registered as name.
+func Lookup(name string) (Profile, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown device profile %q, the profiles are: %s", name, strings.Join(names(), ", "))
+	}
+	return p, nil
+}
+
+// Names returns the sorted names of the registered profiles.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return names()
+}
+
+func names() []string {
+	list := make([]string, 0, len(profiles))
+	for name := range profiles {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// NewYANG returns a profile serving the YANG modules found in dir, compiled
+// with gnmi.NewSchemaModel when the model is requested. The devices of the
+// profile start empty and have no state generator, derivation, alarm rule nor
+// inventory.
+// The profile is not registered, as it depends on dir.
+func NewYANG(dir string) Profile {
+	return &yangProfile{dir: dir}
+}
+
+type yangProfile struct {
+	dir string
+}
+
+func (p *yangProfile) Name() string {
+	return "yang"
+}
+
+func (p *yangProfile) Description() string {
+	return fmt.Sprintf("Device serving the YANG modules of %s", p.dir)
+}
+
+func (p *yangProfile) Model() (*gnmi.Model, error) {
+	return gnmi.NewSchemaModel(p.dir)
+}
+
+func (p *yangProfile) StartupConfig() []byte {
+	return nil
+}
+
+func (p *yangProfile) StateGenerators() []StateGenerator {
+	return nil
+}
+
+func (p *yangProfile) Derivations() []gnmi.Derivation {
+	return nil
+}
+
+func (p *yangProfile) AlarmRules() []gnmi.AlarmRule {
+	return nil
+}
+
+func (p *yangProfile) Inventory() *inventory.Spec {
+	return nil
+}
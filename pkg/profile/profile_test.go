@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package profile
+
+import (
+	"testing"
+
+	"github.com/onosproject/gnxi-simulators/pkg/gnmi"
+	"github.com/onosproject/gnxi-simulators/pkg/inventory"
+)
+
+// testProfile is a profile without model.
+type testProfile struct {
+	name string
+}
+
+func (p testProfile) Name() string                      { return p.name }
+func (p testProfile) Description() string               { return "test device" }
+func (p testProfile) Model() (*gnmi.Model, error)       { return nil, nil }
+func (p testProfile) StartupConfig() []byte             { return nil }
+func (p testProfile) StateGenerators() []StateGenerator { return nil }
+func (p testProfile) Derivations() []gnmi.Derivation    { return nil }
+func (p testProfile) AlarmRules() []gnmi.AlarmRule      { return nil }
+func (p testProfile) Inventory() *inventory.Spec        { return nil }
+
+func TestRegistry(t *testing.T) {
+	Register(testProfile{name: "b-device"})
+	Register(testProfile{name: "a-device"})
+	if names := Names(); len(names) != 2 || names[0] != "a-device" || names[1] != "b-device" {
+		t.Errorf("got profiles %v, want a-device and b-device", names)
+	}
+	if p, err := Lookup("b-device"); err != nil || p.Name() != "b-device" {
+		t.Errorf("Lookup of b-device returned %v, %v", p, err)
+	}
+	if _, err := Lookup("c-device"); err == nil {
+		t.Error("Lookup of an unknown profile returned no error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a profile twice did not panic")
+		}
+	}()
+	Register(testProfile{name: "a-device"})
+}
+
+func TestYANG(t *testing.T) {
+	p := NewYANG("../gnmi/testdata/yang")
+	model, err := p.Model()
+	if err != nil {
+		t.Fatalf("error in loading the model: %v", err)
+	}
+	if models := model.SupportedModels(); len(models) != 3 {
+		t.Errorf("got models %v, want the 3 modules of the directory", models)
+	}
+	if _, err := gnmi.NewServer(model, p.StartupConfig(), nil); err != nil {
+		t.Errorf("error in creating a server with the startup config: %v", err)
+	}
+}